@@ -2,12 +2,14 @@ package quanx
 
 import (
 	"strings"
+	"time"
 
 	"github.com/go-xuan/quanx/core/cachex"
 	"github.com/go-xuan/quanx/core/gormx"
 	"github.com/go-xuan/quanx/core/logx"
 	"github.com/go-xuan/quanx/core/nacosx"
 	"github.com/go-xuan/quanx/core/redisx"
+	"github.com/go-xuan/quanx/core/tracex"
 	"github.com/go-xuan/quanx/types/stringx"
 )
 
@@ -20,6 +22,7 @@ func GetServer() *Server {
 type Config struct {
 	Server   *Server             `yaml:"server"`   // 服务配置
 	Log      *logx.Config        `yaml:"log"`      // 日志配置
+	Trace    *tracex.Config      `yaml:"trace"`    // 链路追踪配置
 	Nacos    *nacosx.Config      `yaml:"nacos"`    // nacos访问配置
 	Database *gormx.MultiConfig  `yaml:"database"` // 数据源配置
 	Redis    *redisx.MultiConfig `yaml:"redis"`    // redis配置
@@ -28,10 +31,50 @@ type Config struct {
 
 // Server 服务配置
 type Server struct {
-	Name   string `yaml:"name" default:"app"`       // 服务名
-	Host   string `yaml:"host" default:"127.0.0.1"` // 服务host
-	Port   int    `yaml:"port" default:"8888"`      // 服务端口
-	Prefix string `yaml:"prefix"`                   // api prefix（接口根路由）
+	Name            string `yaml:"name" default:"app"`           // 服务名
+	Host            string `yaml:"host" default:"127.0.0.1"`     // 服务host
+	Port            int    `yaml:"port" default:"8888"`          // 服务端口
+	Prefix          string `yaml:"prefix"`                       // api prefix（接口根路由）
+	ShutdownTimeout int    `yaml:"shutdownTimeout" default:"10"` // 优雅停止超时时间（秒）
+	H2C             bool   `yaml:"h2c"`                          // 未启用https时是否支持明文HTTP/2（h2c）
+	TLS             *TLS   `yaml:"tls"`                          // https配置
+	Admin           *Admin `yaml:"admin"`                        // 管理端口配置
+}
+
+// TLS https配置，启用后默认支持HTTP/2
+type TLS struct {
+	Enable       bool   `yaml:"enable"`       // 是否启用https
+	CertFile     string `yaml:"certFile"`     // 证书文件
+	KeyFile      string `yaml:"keyFile"`      // 私钥文件
+	ClientCAFile string `yaml:"clientCAFile"` // 客户端CA证书文件，设置后启用mTLS，要求并校验客户端证书
+	SelfSigned   bool   `yaml:"selfSigned"`   // 未设置证书时自动生成自签名证书，仅用于开发环境
+	DisableHTTP2 bool   `yaml:"disableHTTP2"` // 是否禁用HTTP/2
+}
+
+// Admin 管理端口配置，启用后健康检查、指标采集以及pprof仅在管理端口暴露，不再暴露在服务端口
+type Admin struct {
+	Enable bool   `yaml:"enable"`              // 是否启用管理端口
+	Host   string `yaml:"host"`                // 监听地址，为空时监听全部网卡
+	Port   int    `yaml:"port" default:"8889"` // 管理端口
+	Pprof  bool   `yaml:"pprof"`               // 是否启用pprof
+}
+
+// EnableTLS 是否启用https
+func (s *Server) EnableTLS() bool {
+	return s.TLS != nil && s.TLS.Enable
+}
+
+// EnableAdmin 是否启用管理端口
+func (s *Server) EnableAdmin() bool {
+	return s.Admin != nil && s.Admin.Enable
+}
+
+// Scheme 服务协议
+func (s *Server) Scheme() string {
+	if s.EnableTLS() {
+		return "https"
+	}
+	return "http"
 }
 
 // ApiPrefix API路由前缀
@@ -40,6 +83,14 @@ func (s *Server) ApiPrefix() string {
 	return stringx.AddPrefix(strings.ToLower(prefix), "/")
 }
 
+// GetShutdownTimeout 优雅停止超时时间
+func (s *Server) GetShutdownTimeout() time.Duration {
+	if s.ShutdownTimeout > 0 {
+		return time.Duration(s.ShutdownTimeout) * time.Second
+	}
+	return 10 * time.Second
+}
+
 // Instance 服务实例
 func (s *Server) Instance() nacosx.ServerInstance {
 	return nacosx.ServerInstance{
@@ -2,24 +2,41 @@ package cachex
 
 import (
 	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/patrickmn/go-cache"
 	"github.com/redis/go-redis/v9"
 
+	"github.com/go-xuan/quanx/core/redisx"
 	"github.com/go-xuan/quanx/os/errorx"
 	"github.com/go-xuan/quanx/os/taskx"
 	"github.com/go-xuan/quanx/utils/marshalx"
 )
 
+// NoExpiration 缓存永不过期时 TTL() 的返回值
+const NoExpiration time.Duration = -1
+
 type Client interface {
-	Config() *Config                                                                // 获取配置
-	Set(ctx context.Context, key string, value any, expiration time.Duration) error // 更新缓存
-	Get(ctx context.Context, key string, value any) bool                            // 获取缓存（指针，任意类型）
-	GetString(ctx context.Context, key string) string                               // 获取缓存（字符串类型）
-	Expire(ctx context.Context, key string, expiration time.Duration) error         // 续期
-	Delete(ctx context.Context, keys ...string) int64                               // 删除
-	Exist(ctx context.Context, keys ...string) bool                                 // 是否存在
+	Config() *Config                                                                                                         // 获取配置
+	Set(ctx context.Context, key string, value any, expiration time.Duration) error                                          // 更新缓存
+	Get(ctx context.Context, key string, value any) bool                                                                     // 获取缓存（指针，任意类型）
+	GetString(ctx context.Context, key string) string                                                                        // 获取缓存（字符串类型）
+	MSet(ctx context.Context, values map[string]any, expiration time.Duration) error                                         // 批量更新缓存
+	MGet(ctx context.Context, keys ...string) map[string]string                                                              // 批量获取缓存（字符串类型），仅包含存在的KEY
+	SetNX(ctx context.Context, key string, value any, expiration time.Duration) (bool, error)                                // KEY不存在时更新缓存，返回是否更新
+	Incr(ctx context.Context, key string, delta int64, expiration time.Duration) (int64, error)                              // 计数器增加，KEY不存在时从0开始并设置过期时间
+	Decr(ctx context.Context, key string, delta int64, expiration time.Duration) (int64, error)                              // 计数器减少，KEY不存在时从0开始并设置过期时间
+	Expire(ctx context.Context, key string, expiration time.Duration) error                                                  // 续期
+	TTL(ctx context.Context, key string) (time.Duration, error)                                                              // 剩余过期时间，永不过期时返回 NoExpiration，不存在时返回 ErrNotFound
+	Delete(ctx context.Context, keys ...string) int64                                                                        // 删除，返回实际删除的数量
+	Exist(ctx context.Context, keys ...string) bool                                                                          // 是否全部存在
+	Keys(ctx context.Context, pattern string) ([]string, error)                                                              // 获取匹配的KEY（不含前缀），支持 * ? [] 通配符
+	DeletePattern(ctx context.Context, pattern string) (int64, error)                                                        // 删除匹配的KEY，返回实际删除的数量
+	GetOrLoad(ctx context.Context, key string, value any, expiration time.Duration, loader Loader, opts ...LoadOption) error // 读取缓存，未命中时调用loader加载并写入缓存，同一KEY的并发未命中只调用一次loader
 }
 
 // LocalClient 本地缓存客户端
@@ -27,12 +44,17 @@ type LocalClient struct {
 	config  *Config
 	client  *cache.Cache
 	marshal marshalx.Strategy
+	mu      sync.Mutex // 计数器互斥锁
 }
 
 func (c *LocalClient) Config() *Config {
 	return c.config
 }
 
+func (c *LocalClient) items() int64 {
+	return int64(c.client.ItemCount())
+}
+
 func (c *LocalClient) Set(ctx context.Context, key string, value any, d time.Duration) error {
 	if bytes, err := c.marshal.Marshal(value); err != nil {
 		return errorx.Wrap(err, "marshal value error")
@@ -58,14 +80,97 @@ func (c *LocalClient) GetString(ctx context.Context, key string) string {
 	return ""
 }
 
+func (c *LocalClient) MSet(ctx context.Context, values map[string]any, d time.Duration) error {
+	for key, value := range values {
+		if err := c.Set(ctx, key, value, d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *LocalClient) MGet(ctx context.Context, keys ...string) map[string]string {
+	var result = make(map[string]string, len(keys))
+	for _, key := range keys {
+		if value := c.GetString(ctx, key); value != "" {
+			result[key] = value
+		}
+	}
+	return result
+}
+
+func (c *LocalClient) SetNX(ctx context.Context, key string, value any, d time.Duration) (bool, error) {
+	bytes, err := c.marshal.Marshal(value)
+	if err != nil {
+		return false, errorx.Wrap(err, "marshal value error")
+	}
+	return c.client.Add(c.config.GetKey(key), string(bytes), d) == nil, nil
+}
+
+func (c *LocalClient) Incr(ctx context.Context, key string, delta int64, d time.Duration) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key = c.config.GetKey(key)
+	var value int64
+	if result, expiration, ok := c.client.GetWithExpiration(key); ok {
+		var err error
+		if value, err = strconv.ParseInt(result.(string), 10, 64); err != nil {
+			return 0, errorx.Wrap(err, "value is not an integer")
+		}
+		// 已存在时保持原过期时间
+		if d = cache.NoExpiration; !expiration.IsZero() {
+			d = time.Until(expiration)
+		}
+	}
+	value += delta
+	c.client.Set(key, strconv.FormatInt(value, 10), d)
+	return value, nil
+}
+
+func (c *LocalClient) Decr(ctx context.Context, key string, delta int64, d time.Duration) (int64, error) {
+	return c.Incr(ctx, key, -delta, d)
+}
+
+func (c *LocalClient) TTL(ctx context.Context, key string) (time.Duration, error) {
+	if _, expiration, ok := c.client.GetWithExpiration(c.config.GetKey(key)); !ok {
+		return 0, ErrNotFound
+	} else if expiration.IsZero() {
+		return NoExpiration, nil
+	} else {
+		return time.Until(expiration), nil
+	}
+}
+
 func (c *LocalClient) Delete(ctx context.Context, keys ...string) int64 {
-	if len(keys) > 0 {
-		for _, key := range keys {
-			c.client.Delete(c.config.GetKey(key))
+	var count int64
+	for _, key := range keys {
+		key = c.config.GetKey(key)
+		if _, ok := c.client.Get(key); ok {
+			count++
+		}
+		c.client.Delete(key)
+	}
+	return count
+}
+
+func (c *LocalClient) Keys(ctx context.Context, pattern string) ([]string, error) {
+	var prefix = c.config.GetKey("")
+	var keys []string
+	for key := range c.client.Items() {
+		if strings.HasPrefix(key, prefix) && matchPattern(pattern, key[len(prefix):]) {
+			keys = append(keys, key[len(prefix):])
 		}
-		return int64(len(keys))
 	}
-	return 0
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (c *LocalClient) DeletePattern(ctx context.Context, pattern string) (int64, error) {
+	keys, err := c.Keys(ctx, pattern)
+	if err != nil {
+		return 0, err
+	}
+	return c.Delete(ctx, keys...), nil
 }
 
 func (c *LocalClient) Exist(ctx context.Context, keys ...string) bool {
@@ -80,6 +185,10 @@ func (c *LocalClient) Exist(ctx context.Context, keys ...string) bool {
 	return false
 }
 
+func (c *LocalClient) GetOrLoad(ctx context.Context, key string, value any, d time.Duration, loader Loader, opts ...LoadOption) error {
+	return getOrLoad(ctx, c, key, value, d, loader, opts...)
+}
+
 func (c *LocalClient) Expire(ctx context.Context, key string, d time.Duration) error {
 	key = c.config.GetKey(key)
 	if result, ok := c.client.Get(key); !ok {
@@ -93,7 +202,7 @@ func (c *LocalClient) Expire(ctx context.Context, key string, d time.Duration) e
 // RedisClient redis缓存客户端
 type RedisClient struct {
 	config  *Config
-	client  redis.UniversalClient
+	redis   *redisx.Handler // redis句柄，为空时使用redisx默认句柄
 	marshal marshalx.Strategy
 }
 
@@ -101,10 +210,18 @@ func (c *RedisClient) Config() *Config {
 	return c.config
 }
 
+// 每次调用时获取redis客户端，redis数据源热加载后自动使用新客户端
+func (c *RedisClient) client() redis.UniversalClient {
+	if c.redis != nil {
+		return c.redis.GetClient(c.config.Source)
+	}
+	return redisx.GetClient(c.config.Source)
+}
+
 func (c *RedisClient) Set(ctx context.Context, key string, value any, d time.Duration) error {
 	if bytes, err := c.marshal.Marshal(value); err != nil {
 		return errorx.Wrap(err, "marshal value error")
-	} else if err = c.client.Set(ctx, c.config.GetKey(key), bytes, d).Err(); err != nil {
+	} else if err = c.client().Set(ctx, c.config.GetKey(key), bytes, d).Err(); err != nil {
 		return errorx.Wrap(err, "set value error")
 	}
 	return nil
@@ -121,40 +238,187 @@ func (c *RedisClient) Get(ctx context.Context, key string, value any) bool {
 }
 
 func (c *RedisClient) GetString(ctx context.Context, key string) string {
-	if result, err := c.client.Get(ctx, c.config.GetKey(key)).Result(); err == nil {
+	if result, err := c.client().Get(ctx, c.config.GetKey(key)).Result(); err == nil {
 		return result
 	}
 	return ""
 }
 
-func (c *RedisClient) Delete(ctx context.Context, keys ...string) int64 {
-	var sum int64
-	_ = taskx.ExecWithBatches(len(keys), 100, func(x int, y int) error {
-		if s, err := c.client.Del(ctx, c.config.GetKeys(keys[x:y])...).Result(); err != nil {
-			return errorx.Wrap(err, "delete redis keys error")
-		} else {
-			sum += s
+// 按批次逐个KEY执行命令，集群模式下KEY可能分布在不同的槽位，不能使用多KEY命令
+func (c *RedisClient) pipelined(ctx context.Context, keys []string, f func(pipe redis.Pipeliner, key string) redis.Cmder) ([]redis.Cmder, error) {
+	var cmds = make([]redis.Cmder, 0, len(keys))
+	var client = c.client()
+	err := taskx.ExecWithBatches(len(keys), 100, func(x int, y int) error {
+		_, err := client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			for _, key := range keys[x:y] {
+				cmds = append(cmds, f(pipe, c.config.GetKey(key)))
+			}
 			return nil
+		})
+		if err != nil && err != redis.Nil {
+			return err
 		}
+		return nil
 	})
+	return cmds, err
+}
+
+func (c *RedisClient) MSet(ctx context.Context, values map[string]any, d time.Duration) error {
+	var keys = make([]string, 0, len(values))
+	var data = make(map[string][]byte, len(values))
+	for key, value := range values {
+		bytes, err := c.marshal.Marshal(value)
+		if err != nil {
+			return errorx.Wrap(err, "marshal value error")
+		}
+		keys = append(keys, key)
+		data[c.config.GetKey(key)] = bytes
+	}
+	if _, err := c.pipelined(ctx, keys, func(pipe redis.Pipeliner, key string) redis.Cmder {
+		return pipe.Set(ctx, key, data[key], d)
+	}); err != nil {
+		return errorx.Wrap(err, "redis mset error")
+	}
+	return nil
+}
+
+func (c *RedisClient) MGet(ctx context.Context, keys ...string) map[string]string {
+	var result = make(map[string]string, len(keys))
+	cmds, _ := c.pipelined(ctx, keys, func(pipe redis.Pipeliner, key string) redis.Cmder {
+		return pipe.Get(ctx, key)
+	})
+	for i, cmd := range cmds {
+		if value, err := cmd.(*redis.StringCmd).Result(); err == nil {
+			result[keys[i]] = value
+		}
+	}
+	return result
+}
+
+func (c *RedisClient) SetNX(ctx context.Context, key string, value any, d time.Duration) (bool, error) {
+	bytes, err := c.marshal.Marshal(value)
+	if err != nil {
+		return false, errorx.Wrap(err, "marshal value error")
+	}
+	ok, err := c.client().SetNX(ctx, c.config.GetKey(key), bytes, d).Result()
+	if err != nil {
+		return false, errorx.Wrap(err, "redis setnx error")
+	}
+	return ok, nil
+}
+
+// 计数器增加，KEY不存在（没有过期时间）时设置过期时间
+var incrScript = redis.NewScript(`
+local value = redis.call('INCRBY', KEYS[1], ARGV[1])
+if tonumber(ARGV[2]) > 0 and redis.call('PTTL', KEYS[1]) == -1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return value`)
+
+func (c *RedisClient) Incr(ctx context.Context, key string, delta int64, d time.Duration) (int64, error) {
+	value, err := incrScript.Run(ctx, c.client(), []string{c.config.GetKey(key)}, delta, d.Milliseconds()).Int64()
+	if err != nil {
+		return 0, errorx.Wrap(err, "redis incr error")
+	}
+	return value, nil
+}
+
+func (c *RedisClient) Decr(ctx context.Context, key string, delta int64, d time.Duration) (int64, error) {
+	return c.Incr(ctx, key, -delta, d)
+}
+
+func (c *RedisClient) TTL(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := c.client().PTTL(ctx, c.config.GetKey(key)).Result()
+	if err != nil {
+		return 0, errorx.Wrap(err, "redis pttl error")
+	}
+	// PTTL 不存在时返回-2，永不过期时返回-1
+	switch ttl {
+	case -2:
+		return 0, ErrNotFound
+	case -1:
+		return NoExpiration, nil
+	default:
+		return ttl, nil
+	}
+}
+
+func (c *RedisClient) Delete(ctx context.Context, keys ...string) int64 {
+	var sum int64
+	cmds, _ := c.pipelined(ctx, keys, func(pipe redis.Pipeliner, key string) redis.Cmder {
+		return pipe.Del(ctx, key)
+	})
+	for _, cmd := range cmds {
+		sum += cmd.(*redis.IntCmd).Val()
+	}
 	return sum
 }
 
 func (c *RedisClient) Exist(ctx context.Context, keys ...string) bool {
-	var sum int64
-	_ = taskx.ExecWithBatches(len(keys), 100, func(x int, y int) error {
-		if n, err := c.client.Exists(ctx, c.config.GetKeys(keys[x:y])...).Result(); err != nil {
-			return errorx.Wrap(err, "redis exists error")
-		} else {
-			sum += n
-			return nil
-		}
+	if len(keys) == 0 {
+		return false
+	}
+	cmds, err := c.pipelined(ctx, keys, func(pipe redis.Pipeliner, key string) redis.Cmder {
+		return pipe.Exists(ctx, key)
 	})
-	return sum > 0
+	if err != nil {
+		return false
+	}
+	for _, cmd := range cmds {
+		if cmd.(*redis.IntCmd).Val() == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Keys 使用SCAN遍历匹配的KEY，集群模式下遍历全部主节点
+func (c *RedisClient) Keys(ctx context.Context, pattern string) ([]string, error) {
+	var prefix = c.config.GetKey("")
+	var mu sync.Mutex
+	var exists = make(map[string]bool)
+	var scan = func(ctx context.Context, client redis.UniversalClient) error {
+		var iter = client.Scan(ctx, 0, prefix+pattern, 100).Iterator()
+		for iter.Next(ctx) {
+			mu.Lock()
+			exists[strings.TrimPrefix(iter.Val(), prefix)] = true
+			mu.Unlock()
+		}
+		return iter.Err()
+	}
+	var err error
+	if cluster, ok := c.client().(*redis.ClusterClient); ok {
+		err = cluster.ForEachMaster(ctx, func(ctx context.Context, client *redis.Client) error {
+			return scan(ctx, client)
+		})
+	} else {
+		err = scan(ctx, c.client())
+	}
+	if err != nil {
+		return nil, errorx.Wrap(err, "redis scan error")
+	}
+	var keys = make([]string, 0, len(exists))
+	for key := range exists {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (c *RedisClient) DeletePattern(ctx context.Context, pattern string) (int64, error) {
+	keys, err := c.Keys(ctx, pattern)
+	if err != nil {
+		return 0, err
+	}
+	return c.Delete(ctx, keys...), nil
+}
+
+func (c *RedisClient) GetOrLoad(ctx context.Context, key string, value any, d time.Duration, loader Loader, opts ...LoadOption) error {
+	return getOrLoad(ctx, c, key, value, d, loader, opts...)
 }
 
 func (c *RedisClient) Expire(ctx context.Context, key string, d time.Duration) error {
-	if err := c.client.Expire(ctx, c.config.GetKey(key), d).Err(); err != nil {
+	if err := c.client().Expire(ctx, c.config.GetKey(key), d).Err(); err != nil {
 		return errorx.Wrap(err, "redis expire error")
 	}
 	return nil
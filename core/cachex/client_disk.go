@@ -0,0 +1,244 @@
+package cachex
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/go-xuan/quanx/os/errorx"
+	"github.com/go-xuan/quanx/utils/marshalx"
+)
+
+// DiskClient 磁盘缓存客户端，数据写入本地文件，服务重启后仍然有效，适用于没有redis的边缘部署场景，
+// 定期清理过期数据，超出最大数量时淘汰最久未使用的数据
+type DiskClient struct {
+	config  *Config
+	store   *diskStore
+	marshal marshalx.Strategy
+	cancel  context.CancelFunc // 停止定期清理
+	once    sync.Once
+}
+
+func newDiskClient(config *Config) (*DiskClient, error) {
+	store, err := openDiskStore(config.DiskFile(), config.MaxSize)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	var client = &DiskClient{
+		config:  config,
+		store:   store,
+		marshal: marshalx.Apply(config.Marshal),
+		cancel:  cancel,
+	}
+	go client.sweep(ctx, time.Duration(config.SweepInterval)*time.Second)
+	return client, nil
+}
+
+// 定期清理过期数据
+func (c *DiskClient) sweep(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	var ticker = time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.store.sweep(); err != nil {
+				log.WithField("path", c.store.path).Error("sweep disk cache failed: ", err)
+			}
+		}
+	}
+}
+
+func (c *DiskClient) Config() *Config {
+	return c.config
+}
+
+// Close 停止定期清理并关闭缓存文件
+func (c *DiskClient) Close() error {
+	var err error
+	c.once.Do(func() {
+		c.cancel()
+		err = c.store.close()
+	})
+	return err
+}
+
+func (c *DiskClient) evictions() int64 {
+	evictions, _ := c.store.stats()
+	return evictions
+}
+
+func (c *DiskClient) items() int64 {
+	_, items := c.store.stats()
+	return items
+}
+
+// 过期时间转换为时间戳，小于等于0时永不过期
+func expireAt(d time.Duration) int64 {
+	if d <= 0 {
+		return 0
+	}
+	return time.Now().Add(d).UnixNano()
+}
+
+func (c *DiskClient) Set(ctx context.Context, key string, value any, d time.Duration) error {
+	bytes, err := c.marshal.Marshal(value)
+	if err != nil {
+		return errorx.Wrap(err, "marshal value error")
+	}
+	return c.store.set(c.config.GetKey(key), string(bytes), expireAt(d))
+}
+
+func (c *DiskClient) Get(ctx context.Context, key string, value any) bool {
+	if result := c.GetString(ctx, key); result != "" {
+		if err := c.marshal.Unmarshal([]byte(result), value); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *DiskClient) GetString(ctx context.Context, key string) string {
+	if result, _, ok := c.store.get(c.config.GetKey(key)); ok {
+		return result
+	}
+	return ""
+}
+
+func (c *DiskClient) MSet(ctx context.Context, values map[string]any, d time.Duration) error {
+	for key, value := range values {
+		if err := c.Set(ctx, key, value, d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *DiskClient) MGet(ctx context.Context, keys ...string) map[string]string {
+	var result = make(map[string]string, len(keys))
+	for _, key := range keys {
+		if value := c.GetString(ctx, key); value != "" {
+			result[key] = value
+		}
+	}
+	return result
+}
+
+func (c *DiskClient) SetNX(ctx context.Context, key string, value any, d time.Duration) (bool, error) {
+	bytes, err := c.marshal.Marshal(value)
+	if err != nil {
+		return false, errorx.Wrap(err, "marshal value error")
+	}
+	var ok bool
+	err = c.store.update(c.config.GetKey(key), func(entry *diskEntry) (*diskEntry, error) {
+		if entry != nil {
+			return nil, nil
+		}
+		ok = true
+		return &diskEntry{value: string(bytes), expireAt: expireAt(d)}, nil
+	})
+	return ok && err == nil, err
+}
+
+func (c *DiskClient) Incr(ctx context.Context, key string, delta int64, d time.Duration) (int64, error) {
+	var value int64
+	err := c.store.update(c.config.GetKey(key), func(entry *diskEntry) (*diskEntry, error) {
+		var next = &diskEntry{expireAt: expireAt(d)}
+		if entry != nil {
+			var err error
+			if value, err = strconv.ParseInt(entry.value, 10, 64); err != nil {
+				return nil, errorx.Wrap(err, "value is not an integer")
+			}
+			// 已存在时保持原过期时间
+			next.expireAt = entry.expireAt
+		}
+		value += delta
+		next.value = strconv.FormatInt(value, 10)
+		return next, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return value, nil
+}
+
+func (c *DiskClient) Decr(ctx context.Context, key string, delta int64, d time.Duration) (int64, error) {
+	return c.Incr(ctx, key, -delta, d)
+}
+
+func (c *DiskClient) Expire(ctx context.Context, key string, d time.Duration) error {
+	return c.store.update(c.config.GetKey(key), func(entry *diskEntry) (*diskEntry, error) {
+		if entry == nil {
+			return nil, errorx.New("key not found")
+		}
+		return &diskEntry{value: entry.value, expireAt: expireAt(d)}, nil
+	})
+}
+
+func (c *DiskClient) TTL(ctx context.Context, key string) (time.Duration, error) {
+	if _, expiration, ok := c.store.get(c.config.GetKey(key)); !ok {
+		return 0, ErrNotFound
+	} else if expiration == 0 {
+		return NoExpiration, nil
+	} else {
+		return time.Until(time.Unix(0, expiration)), nil
+	}
+}
+
+func (c *DiskClient) Delete(ctx context.Context, keys ...string) int64 {
+	var count int64
+	for _, key := range keys {
+		if ok, err := c.store.delete(c.config.GetKey(key)); err != nil {
+			log.WithField("key", key).Error("delete disk cache failed: ", err)
+		} else if ok {
+			count++
+		}
+	}
+	return count
+}
+
+func (c *DiskClient) Exist(ctx context.Context, keys ...string) bool {
+	if len(keys) == 0 {
+		return false
+	}
+	for _, key := range keys {
+		if _, _, ok := c.store.get(c.config.GetKey(key)); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *DiskClient) Keys(ctx context.Context, pattern string) ([]string, error) {
+	var prefix = c.config.GetKey("")
+	var keys []string
+	for _, key := range c.store.keys() {
+		if strings.HasPrefix(key, prefix) && matchPattern(pattern, key[len(prefix):]) {
+			keys = append(keys, key[len(prefix):])
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (c *DiskClient) DeletePattern(ctx context.Context, pattern string) (int64, error) {
+	keys, err := c.Keys(ctx, pattern)
+	if err != nil {
+		return 0, err
+	}
+	return c.Delete(ctx, keys...), nil
+}
+
+func (c *DiskClient) GetOrLoad(ctx context.Context, key string, value any, d time.Duration, loader Loader, opts ...LoadOption) error {
+	return getOrLoad(ctx, c, key, value, d, loader, opts...)
+}
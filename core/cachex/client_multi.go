@@ -0,0 +1,250 @@
+package cachex
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/go-xuan/quanx/core/redisx"
+	"github.com/go-xuan/quanx/os/errorx"
+	"github.com/go-xuan/quanx/utils/marshalx"
+)
+
+// 失效广播订阅断开后的重新订阅间隔
+const resubscribeInterval = time.Second
+
+// MultiClient 二级缓存客户端，读取时先读本地缓存（L1），未命中时读取redis（L2）并以较短的过期时间回填本地缓存，
+// 写入以及删除时通过redis发布失效消息，所有实例收到后移除本地缓存副本
+type MultiClient struct {
+	config *Config
+	local  *cache.Cache // 本地缓存，保存序列化后的字符串
+	remote *RedisClient // redis缓存
+	ttl    time.Duration
+	cancel context.CancelFunc // 停止订阅失效消息
+	once   sync.Once
+}
+
+func newMultiClient(config *Config, redis *redisx.Handler) *MultiClient {
+	var ttl = time.Duration(config.LocalTTL) * time.Second
+	if ttl <= 0 {
+		ttl = time.Minute
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	var client = &MultiClient{
+		config: config,
+		local:  cache.New(ttl, ttl),
+		remote: &RedisClient{config: config, redis: redis, marshal: marshalx.Apply(config.Marshal)},
+		ttl:    ttl,
+		cancel: cancel,
+	}
+	go client.subscribe(ctx)
+	return client
+}
+
+func (c *MultiClient) Config() *Config {
+	return c.config
+}
+
+// Close 停止订阅失效消息
+func (c *MultiClient) Close() error {
+	c.once.Do(c.cancel)
+	return nil
+}
+
+// 本地缓存的条目数
+func (c *MultiClient) items() int64 {
+	return int64(c.local.ItemCount())
+}
+
+func (c *MultiClient) Set(ctx context.Context, key string, value any, d time.Duration) error {
+	if err := c.remote.Set(ctx, key, value, d); err != nil {
+		return err
+	}
+	c.invalidate(ctx, key)
+	return nil
+}
+
+func (c *MultiClient) Get(ctx context.Context, key string, value any) bool {
+	if result := c.GetString(ctx, key); result != "" {
+		if err := c.remote.marshal.Unmarshal([]byte(result), value); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *MultiClient) GetString(ctx context.Context, key string) string {
+	var fullKey = c.config.GetKey(key)
+	if result, ok := c.local.Get(fullKey); ok {
+		return result.(string)
+	}
+	var result = c.remote.GetString(ctx, key)
+	if result != "" {
+		c.local.Set(fullKey, result, c.localTTL(ctx, key))
+	}
+	return result
+}
+
+// 本地缓存过期时间，不超过redis中的剩余过期时间
+func (c *MultiClient) localTTL(ctx context.Context, key string) time.Duration {
+	if ttl, err := c.remote.TTL(ctx, key); err == nil && ttl > 0 && ttl < c.ttl {
+		return ttl
+	}
+	return c.ttl
+}
+
+func (c *MultiClient) GetOrLoad(ctx context.Context, key string, value any, d time.Duration, loader Loader, opts ...LoadOption) error {
+	return getOrLoad(ctx, c, key, value, d, loader, opts...)
+}
+
+func (c *MultiClient) MSet(ctx context.Context, values map[string]any, d time.Duration) error {
+	if err := c.remote.MSet(ctx, values, d); err != nil {
+		return err
+	}
+	var keys = make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	c.invalidate(ctx, keys...)
+	return nil
+}
+
+// MGet 优先读取本地缓存，未命中的KEY批量读取redis并回填本地缓存
+func (c *MultiClient) MGet(ctx context.Context, keys ...string) map[string]string {
+	var result = make(map[string]string, len(keys))
+	var misses []string
+	for _, key := range keys {
+		if value, ok := c.local.Get(c.config.GetKey(key)); ok {
+			result[key] = value.(string)
+		} else {
+			misses = append(misses, key)
+		}
+	}
+	if len(misses) > 0 {
+		for key, value := range c.remote.MGet(ctx, misses...) {
+			result[key] = value
+			c.local.Set(c.config.GetKey(key), value, c.localTTL(ctx, key))
+		}
+	}
+	return result
+}
+
+func (c *MultiClient) SetNX(ctx context.Context, key string, value any, d time.Duration) (bool, error) {
+	ok, err := c.remote.SetNX(ctx, key, value, d)
+	if ok {
+		c.invalidate(ctx, key)
+	}
+	return ok, err
+}
+
+func (c *MultiClient) Incr(ctx context.Context, key string, delta int64, d time.Duration) (int64, error) {
+	value, err := c.remote.Incr(ctx, key, delta, d)
+	if err == nil {
+		c.invalidate(ctx, key)
+	}
+	return value, err
+}
+
+func (c *MultiClient) Decr(ctx context.Context, key string, delta int64, d time.Duration) (int64, error) {
+	return c.Incr(ctx, key, -delta, d)
+}
+
+func (c *MultiClient) TTL(ctx context.Context, key string) (time.Duration, error) {
+	return c.remote.TTL(ctx, key)
+}
+
+// Expire 续期仅作用于redis，本地缓存副本按照自身较短的过期时间失效
+func (c *MultiClient) Expire(ctx context.Context, key string, d time.Duration) error {
+	return c.remote.Expire(ctx, key, d)
+}
+
+func (c *MultiClient) Delete(ctx context.Context, keys ...string) int64 {
+	var count = c.remote.Delete(ctx, keys...)
+	c.invalidate(ctx, keys...)
+	return count
+}
+
+func (c *MultiClient) Exist(ctx context.Context, keys ...string) bool {
+	return c.remote.Exist(ctx, keys...)
+}
+
+func (c *MultiClient) Keys(ctx context.Context, pattern string) ([]string, error) {
+	return c.remote.Keys(ctx, pattern)
+}
+
+func (c *MultiClient) DeletePattern(ctx context.Context, pattern string) (int64, error) {
+	keys, err := c.remote.Keys(ctx, pattern)
+	if err != nil {
+		return 0, err
+	}
+	return c.Delete(ctx, keys...), nil
+}
+
+// 移除本地缓存并广播失效消息
+func (c *MultiClient) invalidate(ctx context.Context, keys ...string) {
+	if len(keys) == 0 {
+		return
+	}
+	c.evict(keys)
+	message, err := json.Marshal(keys)
+	if err != nil {
+		return
+	}
+	if err = c.remote.client().Publish(ctx, c.config.InvalidateChannel(), message).Err(); err != nil {
+		log.WithField("channel", c.config.InvalidateChannel()).Error("publish cache invalidation failed: ", err)
+	}
+}
+
+// 移除本地缓存
+func (c *MultiClient) evict(keys []string) {
+	for _, key := range keys {
+		c.local.Delete(c.config.GetKey(key))
+	}
+}
+
+// 订阅失效消息，订阅断开后（例如redis客户端热加载）清空本地缓存并重新订阅
+func (c *MultiClient) subscribe(ctx context.Context) {
+	var channel = c.config.InvalidateChannel()
+	var logger = log.WithField("channel", channel)
+	var last string
+	for {
+		// 相同的错误仅记录一次，避免redis不可用时持续输出日志
+		if err := c.receive(ctx, channel); err != nil && ctx.Err() == nil && err.Error() != last {
+			logger.Warn("cache invalidation subscription interrupted: ", err)
+			last = err.Error()
+		}
+		// 订阅断开期间可能错过失效消息
+		c.local.Flush()
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(resubscribeInterval):
+		}
+	}
+}
+
+func (c *MultiClient) receive(ctx context.Context, channel string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errorx.Errorf("redis client unavailable: %v", r)
+		}
+	}()
+	var pubsub = c.remote.client().Subscribe(ctx, channel)
+	defer func() { _ = pubsub.Close() }()
+	for {
+		message, err := pubsub.ReceiveMessage(ctx)
+		if err != nil {
+			return err
+		}
+		var keys []string
+		if err = json.Unmarshal([]byte(message.Payload), &keys); err != nil {
+			log.WithField("channel", channel).Error("invalid cache invalidation message: ", message.Payload)
+			continue
+		}
+		c.evict(keys)
+	}
+}
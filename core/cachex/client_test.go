@@ -2,8 +2,14 @@ package cachex
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/go-xuan/quanx/core/configx"
 	"github.com/go-xuan/quanx/core/redisx"
@@ -45,3 +51,263 @@ func TestHandler(t *testing.T) {
 	GetClient().Get(ctx, "test_1", &value)
 	fmt.Println(value)
 }
+
+func TestMultiClient(t *testing.T) {
+	var redis = redisx.NewHandler(nil)
+	if err := redis.Execute(&redisx.Config{
+		Source:   "default",
+		Enable:   true,
+		Host:     "localhost",
+		Port:     6379,
+		Password: "Init@1234",
+		Database: 1,
+	}); err != nil {
+		t.Skip("redis is not available: ", err)
+	}
+	var config = &Config{Type: CacheTypeMulti, Source: "default", Prefix: "multi_test", Marshal: "json", LocalTTL: 60}
+	var first, second = newMultiClient(config, redis), newMultiClient(config, redis)
+	defer first.Close()
+	defer second.Close()
+	time.Sleep(100 * time.Millisecond)
+
+	ctx := context.TODO()
+	if err := first.Set(ctx, "token", "v1", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if value := second.GetString(ctx, "token"); value != `"v1"` {
+		t.Fatalf("unexpected value: %s", value)
+	}
+	// 第一个实例更新后，第二个实例的本地缓存应被失效消息移除
+	if err := first.Set(ctx, "token", "v2", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if value := second.GetString(ctx, "token"); value != `"v2"` {
+		t.Fatalf("local cache should be invalidated: %s", value)
+	}
+	first.Delete(ctx, "token")
+}
+
+func TestGetOrLoad(t *testing.T) {
+	var client = (&Config{Type: CacheTypeLocal, Source: "load", Prefix: "load", Marshal: "json"}).InitClient()
+	var calls int32
+	var loader = func(ctx context.Context) (any, error) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(50 * time.Millisecond)
+		return map[string]string{"name": "quanx"}, nil
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var value map[string]string
+			if err := client.GetOrLoad(context.TODO(), "user", &value, time.Minute, loader); err != nil {
+				t.Error(err)
+			} else if value["name"] != "quanx" {
+				t.Errorf("unexpected value: %v", value)
+			}
+		}()
+	}
+	wg.Wait()
+	if calls != 1 {
+		t.Fatalf("concurrent misses should call loader once, got %d", calls)
+	}
+
+	// 空结果缓存
+	var notFound = func(ctx context.Context) (any, error) {
+		atomic.AddInt32(&calls, 1)
+		return nil, ErrNotFound
+	}
+	for i := 0; i < 3; i++ {
+		var value string
+		if err := client.GetOrLoad(context.TODO(), "missing", &value, time.Minute, notFound, WithNegativeCache(time.Minute)); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("expected not found, got %v", err)
+		}
+	}
+	if calls != 2 {
+		t.Fatalf("not found result should be cached, loader called %d times", calls)
+	}
+
+	// 首个调用方取消不影响等待同一加载的其他调用方
+	var started, once = make(chan struct{}), sync.Once{}
+	var slow = func(ctx context.Context) (any, error) {
+		once.Do(func() { close(started) })
+		select {
+		case <-time.After(50 * time.Millisecond):
+			return "value", nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	var first = make(chan error, 1)
+	go func() {
+		var value string
+		first <- client.GetOrLoad(ctx, "slow", &value, time.Minute, slow)
+	}()
+	<-started
+	var second = make(chan error, 1)
+	var value string
+	go func() {
+		second <- client.GetOrLoad(context.Background(), "slow", &value, time.Minute, slow)
+	}()
+	cancel()
+	if err := <-first; !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled caller should return context.Canceled, got %v", err)
+	}
+	if err := <-second; err != nil || value != "value" {
+		t.Errorf("other caller should get the loaded value, got %q %v", value, err)
+	}
+}
+
+func TestLocalClient(t *testing.T) {
+	var client = (&Config{Type: CacheTypeLocal, Source: "local", Prefix: "local", Marshal: "json"}).InitClient()
+	ctx := context.TODO()
+	if err := client.MSet(ctx, map[string]any{"user:1": 1, "user:2": 2, "order:1": 3}, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if values := client.MGet(ctx, "user:1", "user:2", "user:3"); len(values) != 2 || values["user:2"] != "2" {
+		t.Fatalf("unexpected mget result: %v", values)
+	}
+	if client.Exist(ctx, "user:1", "user:3") {
+		t.Fatal("exist should be false when any key is missing")
+	}
+	if ok, _ := client.SetNX(ctx, "user:1", 10, time.Minute); ok {
+		t.Fatal("setnx should fail on existing key")
+	}
+	if value, err := client.Incr(ctx, "counter", 5, time.Minute); err != nil || value != 5 {
+		t.Fatalf("unexpected incr result: %d, %v", value, err)
+	}
+	if value, _ := client.Decr(ctx, "counter", 2, 0); value != 3 {
+		t.Fatalf("unexpected decr result: %d", value)
+	}
+	if ttl, err := client.TTL(ctx, "counter"); err != nil || ttl <= 0 || ttl > time.Minute {
+		t.Fatalf("counter should keep its expiration, got %v, %v", ttl, err)
+	}
+	if _, err := client.TTL(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	if keys, _ := client.Keys(ctx, "user:[0-9]"); len(keys) != 2 || keys[0] != "user:1" {
+		t.Fatalf("unexpected keys: %v", keys)
+	}
+	if count, _ := client.DeletePattern(ctx, "user:*"); count != 2 {
+		t.Fatalf("unexpected delete count: %d", count)
+	}
+	if count := client.Delete(ctx, "order:1", "user:1"); count != 1 {
+		t.Fatalf("delete should count removed keys only, got %d", count)
+	}
+}
+
+func TestMatchPattern(t *testing.T) {
+	var cases = []struct {
+		pattern, key string
+		match        bool
+	}{
+		{"*", "", true},
+		{"user:*", "user:1", true},
+		{"user:?", "user:12", false},
+		{"user:[^0-9]", "user:a", true},
+		{"user:[a-c]*", "user:d1", false},
+		{`user\*`, "user*", true},
+		{"*:1", "order:1", true},
+	}
+	for _, c := range cases {
+		if matchPattern(c.pattern, c.key) != c.match {
+			t.Errorf("match %q with %q should be %v", c.pattern, c.key, c.match)
+		}
+	}
+}
+
+func TestDiskClient(t *testing.T) {
+	var config = &Config{Type: CacheTypeDisk, Source: "disk", Prefix: "disk", Marshal: "json", Dir: t.TempDir(), MaxSize: 3, SweepInterval: 60}
+	client, err := newDiskClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.TODO()
+	for i := 1; i <= 3; i++ {
+		if err = client.Set(ctx, fmt.Sprintf("token:%d", i), i, time.Minute); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 最大数量为3，token:2 最久未使用被淘汰
+	client.GetString(ctx, "token:1")
+	_ = client.Set(ctx, "expired", 0, time.Millisecond)
+	// 超出最大数量时优先移除过期数据
+	time.Sleep(10 * time.Millisecond)
+	_ = client.Set(ctx, "token:4", 4, 0)
+	if client.Exist(ctx, "token:2") || !client.Exist(ctx, "token:1", "token:3", "token:4") {
+		t.Fatal("least recently used key should be evicted")
+	}
+	_ = client.Delete(ctx, "token:3")
+	if err = client.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 重启后数据仍然有效
+	if client, err = newDiskClient(config); err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	if keys, _ := client.Keys(ctx, "*"); len(keys) != 2 || keys[0] != "token:1" || keys[1] != "token:4" {
+		t.Fatalf("unexpected keys after reopen: %v", keys)
+	}
+	if ttl, _ := client.TTL(ctx, "token:4"); ttl != NoExpiration {
+		t.Fatalf("unexpected ttl: %v", ttl)
+	}
+	var value int
+	if !client.Get(ctx, "token:1", &value) || value != 1 {
+		t.Fatalf("unexpected value: %d", value)
+	}
+
+	// 未设置最大数量时不限制
+	var unlimited = &Config{Type: CacheTypeDisk, Source: "unlimited", Dir: t.TempDir()}
+	if err = NewHandler(nil).Execute(unlimited); err != nil {
+		t.Fatal(err)
+	} else if unlimited.MaxSize != 0 {
+		t.Fatalf("max size should stay unlimited, got %d", unlimited.MaxSize)
+	}
+	// 磁盘存储打开失败时返回错误
+	var file = filepath.Join(t.TempDir(), "file")
+	if err = os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	var handler = NewHandler(nil)
+	if err = handler.Execute(&Config{Type: CacheTypeDisk, Source: "broken", Dir: file}); err == nil {
+		t.Fatal("execute should fail when the disk store cannot be opened")
+	} else if handler.IsInitialized() {
+		t.Fatal("client of broken disk store should not be registered")
+	}
+}
+
+func TestStats(t *testing.T) {
+	var handler = NewHandler(nil)
+	if err := handler.Execute(MultiConfig{
+		{Type: CacheTypeLocal, Source: "default", Prefix: "stats", Marshal: "json"},
+		{Type: CacheTypeDisk, Source: "disk", Prefix: "stats", Marshal: "json", Dir: t.TempDir(), MaxSize: 1},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	defer handler.Shutdown(MultiConfig{{Source: "default"}, {Source: "disk"}})
+	ctx := context.TODO()
+	var client = handler.GetClient()
+	_ = client.Set(ctx, "a", 1, time.Minute)
+	client.GetString(ctx, "a")
+	client.GetString(ctx, "b")
+	client.MGet(ctx, "a", "b")
+	client.Delete(ctx, "a", "b")
+	var disk = handler.Client("disk")
+	_ = disk.MSet(ctx, map[string]any{"a": 1, "b": 2}, time.Minute)
+
+	var stats = handler.Stats()
+	if len(stats) != 2 || stats[0].Source != "default" {
+		t.Fatalf("unexpected stats: %v", stats)
+	}
+	if s := stats[0]; s.Hits != 2 || s.Misses != 2 || s.Sets != 1 || s.Deletes != 1 || s.HitRatio != 0.5 || s.Items != 0 {
+		t.Fatalf("unexpected local stats: %+v", s)
+	}
+	if s := stats[1]; s.Sets != 2 || s.Evictions != 1 || s.Items != 1 {
+		t.Fatalf("unexpected disk stats: %+v", s)
+	}
+}
@@ -2,17 +2,16 @@ package cachex
 
 import (
 	"fmt"
+	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/patrickmn/go-cache"
 	log "github.com/sirupsen/logrus"
 
-	"github.com/go-xuan/quanx/common/constx"
 	"github.com/go-xuan/quanx/core/configx"
 	"github.com/go-xuan/quanx/core/redisx"
 	"github.com/go-xuan/quanx/os/errorx"
-	"github.com/go-xuan/quanx/types/anyx"
 	"github.com/go-xuan/quanx/types/stringx"
 	"github.com/go-xuan/quanx/utils/marshalx"
 )
@@ -20,13 +19,20 @@ import (
 const (
 	CacheTypeRedis = "redis"
 	CacheTypeLocal = "local"
+	CacheTypeMulti = "multi" // 二级缓存，本地缓存 + redis
+	CacheTypeDisk  = "disk"  // 磁盘缓存，服务重启后仍然有效
 )
 
 type Config struct {
-	Type    string `json:"type" yaml:"type" default:"redis"`         // 缓存类型（local/redis）
-	Source  string `json:"source" yaml:"source" default:"default"`   // 缓存存储数据源名称
-	Prefix  string `json:"prefix" yaml:"prefix" default:"default"`   // 缓存KEY前缀前缀
-	Marshal string `json:"marshal" yaml:"marshal" default:"msgpack"` // 序列化方案
+	Type          string `json:"type" yaml:"type" default:"redis" validate:"oneof=redis local multi disk"`                        // 缓存类型（local/redis/multi/disk）
+	Source        string `json:"source" yaml:"source" default:"default"`                                                          // 缓存存储数据源名称
+	Prefix        string `json:"prefix" yaml:"prefix" default:"default"`                                                          // 缓存KEY前缀前缀
+	Marshal       string `json:"marshal" yaml:"marshal" default:"msgpack" validate:"oneof=json yml yaml toml properties msgpack"` // 序列化方案
+	LocalTTL      int    `json:"localTTL" yaml:"localTTL" default:"60" validate:"min=1"`                                          // 二级缓存中本地缓存的过期时间（秒）
+	Channel       string `json:"channel" yaml:"channel"`                                                                          // 二级缓存失效广播的redis频道，为空时按照缓存前缀生成
+	Dir           string `json:"dir" yaml:"dir" default:"resource/cache"`                                                         // 磁盘缓存文件夹，文件名为缓存源名称
+	MaxSize       int    `json:"maxSize" yaml:"maxSize" validate:"min=0"`                                                         // 磁盘缓存最大数量，超出时淘汰最久未使用的数据，0（默认）表示不限制
+	SweepInterval int    `json:"sweepInterval" yaml:"sweepInterval" default:"60" validate:"min=1"`                                // 磁盘缓存清理过期数据的间隔（秒）
 }
 
 func (c *Config) Format() string {
@@ -34,50 +40,79 @@ func (c *Config) Format() string {
 		c.Type, c.Source, c.Prefix, c.Marshal)
 }
 
+// InvalidateChannel 二级缓存失效广播的redis频道，使用相同频道的实例互相同步失效消息
+func (c *Config) InvalidateChannel() string {
+	if c.Channel != "" {
+		return c.Channel
+	}
+	return "cache:invalidate:" + c.Prefix
+}
+
+// DiskFile 磁盘缓存文件路径
+func (c *Config) DiskFile() string {
+	return filepath.Join(c.Dir, c.Source+".cache")
+}
+
 func (c *Config) Reader() *configx.Reader {
 	return &configx.Reader{
 		FilePath:    "cache.yaml",
 		NacosDataId: "cache.yaml",
-		Listen:      false,
+		Listen:      true,
 	}
 }
 
-func (c *Config) Execute() error {
-	if err := anyx.SetDefaultValue(c); err != nil {
-		return errorx.Wrap(err, "set default value error")
+// DependsOn redis缓存以及二级缓存依赖redis组件先完成初始化
+func (c *Config) DependsOn() []string {
+	if c.Type == "" || c.Type == CacheTypeRedis || c.Type == CacheTypeMulti {
+		return []string{"redis"}
 	}
-	var client = c.InitClient()
-	if _handler == nil {
-		_handler = &Handler{
-			multi:     false,
-			client:    client,
-			clientMap: make(map[string]Client),
-		}
-	} else {
-		_handler.multi = true
-	}
-	_handler.clientMap[c.Source] = client
 	return nil
 }
 
-// InitClient 根据缓存配置初始化缓存客户端
+func (c *Config) Execute() error {
+	return Default().execute(c)
+}
+
+// Reload 重新加载缓存客户端，配置未变化的客户端保持不变，避免本地缓存被清空
+func (c *Config) Reload() error {
+	return Default().reload(c)
+}
+
+// InitClient 根据缓存配置初始化缓存客户端，初始化失败时返回nil
 func (c *Config) InitClient() Client {
+	client, err := c.initClient(nil)
+	if err != nil {
+		log.WithField("source", c.Source).Error("init cache client failed: ", err)
+		return nil
+	}
+	return client
+}
+
+// 初始化缓存客户端，redis缓存使用指定的redis句柄，为空时使用redisx默认句柄
+func (c *Config) initClient(redis *redisx.Handler) (Client, error) {
 	switch c.Type {
 	case CacheTypeRedis:
 		return &RedisClient{
 			config:  c,
-			client:  redisx.GetClient(c.Source),
+			redis:   redis,
 			marshal: marshalx.Apply(c.Marshal),
-		}
+		}, nil
 	case CacheTypeLocal:
 		return &LocalClient{
 			config:  c,
 			client:  cache.New(time.Duration(-1), time.Duration(-1)),
 			marshal: marshalx.Apply(c.Marshal),
+		}, nil
+	case CacheTypeMulti:
+		return newMultiClient(c, redis), nil
+	case CacheTypeDisk:
+		client, err := newDiskClient(c)
+		if err != nil {
+			return nil, errorx.Wrap(err, "init disk cache client failed: "+c.DiskFile())
 		}
+		return client, nil
 	default:
-		log.Error("cache client not support type: ", c.Type)
-		return nil
+		return nil, errorx.Errorf("cache client not support type: %s", c.Type)
 	}
 }
 
@@ -123,30 +158,37 @@ func (MultiConfig) Reader() *configx.Reader {
 	return &configx.Reader{
 		FilePath:    "cache.yaml",
 		NacosDataId: "cache.yaml",
-		Listen:      false,
+		Listen:      true,
 	}
 }
 
-func (m MultiConfig) Execute() error {
-	if _handler == nil {
-		_handler = &Handler{
-			multi:     true,
-			clientMap: make(map[string]Client),
+// DependsOn 存在redis缓存时依赖redis组件先完成初始化
+func (m MultiConfig) DependsOn() []string {
+	for _, c := range m {
+		if deps := c.DependsOn(); len(deps) > 0 {
+			return deps
 		}
-	} else {
-		_handler.multi = true
 	}
-	multi := anyx.IfZero(m, MultiConfig{&Config{
-		Source:  constx.DefaultSource,
-		Prefix:  "cache",
-		Marshal: "json",
-	}})
-	for i, c := range multi {
-		var client = c.InitClient()
-		_handler.clientMap[c.Source] = client
-		if i == 0 || c.Source == constx.DefaultSource {
-			_handler.client = client
+	return nil
+}
+
+// Validate 校验缓存源名称不能重复
+func (m MultiConfig) Validate() error {
+	var sources = make(map[string]bool)
+	for _, c := range m {
+		if sources[c.Source] {
+			return errorx.Errorf("source is duplicated: %s", c.Source)
 		}
+		sources[c.Source] = true
 	}
 	return nil
 }
+
+func (m MultiConfig) Execute() error {
+	return Default().executeMulti(m)
+}
+
+// Reload 重新加载多缓存客户端，已移除的缓存客户端将被移除
+func (m MultiConfig) Reload() error {
+	return Default().reloadMulti(m)
+}
@@ -0,0 +1,365 @@
+package cachex
+
+import (
+	"bufio"
+	"container/list"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/go-xuan/quanx/os/errorx"
+)
+
+const (
+	diskOpSet byte = 1 // 写入记录
+	diskOpDel byte = 2 // 删除记录
+
+	diskHeaderSize     = 8       // 记录头：crc32(4) + 记录长度(4)
+	diskRecordMaxSize  = 1 << 29 // 单条记录最大长度，超出时视为文件损坏
+	diskCompactMinimum = 1024    // 无效记录达到此数量并且超过有效记录数时压缩文件
+)
+
+// 磁盘缓存条目
+type diskEntry struct {
+	key      string
+	value    string
+	expireAt int64 // 过期时间（纳秒时间戳），0表示永不过期
+}
+
+func (e *diskEntry) expired(now int64) bool {
+	return e.expireAt > 0 && e.expireAt <= now
+}
+
+// 磁盘存储，数据全部保存在内存中并以追加日志的方式写入文件，启动时回放日志恢复数据，
+// 无效记录（被覆盖、删除、过期）过多时重写文件，超出最大数量时按照LRU淘汰
+type diskStore struct {
+	mu        sync.Mutex
+	path      string
+	file      *os.File
+	maxSize   int                      // 最大条目数，0表示不限制
+	entries   map[string]*list.Element // 缓存条目
+	lru       *list.List               // 最近使用的条目在前
+	garbage   int                      // 文件中的无效记录数
+	evictions int64                    // 淘汰的数量（过期清理、超出最大数量）
+	closed    bool
+}
+
+// 打开磁盘存储，文件不存在时创建
+func openDiskStore(path string, maxSize int) (*diskStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return nil, errorx.Wrap(err, "create cache dir error")
+	}
+	var s = &diskStore{
+		path:    path,
+		maxSize: maxSize,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+	if err := s.replay(); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, errorx.Wrap(err, "open cache file error")
+	}
+	s.file = file
+	s.evict()
+	if s.garbage > diskCompactMinimum {
+		if err = s.compact(); err != nil {
+			log.WithField("path", path).Warn("compact cache file failed: ", err)
+		}
+	}
+	return s, nil
+}
+
+// 回放日志文件，末尾不完整或者损坏的记录（例如写入时进程退出）将被截断
+func (s *diskStore) replay() error {
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return errorx.Wrap(err, "open cache file error")
+	}
+	defer func() { _ = file.Close() }()
+	var reader = bufio.NewReader(file)
+	var now = time.Now().UnixNano()
+	var offset int64
+	for {
+		op, entry, size, err := readDiskRecord(reader)
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			log.WithField("path", s.path).Warnf("cache file is corrupted at offset %d, truncated: %v", offset, err)
+			if err = file.Truncate(offset); err != nil {
+				return errorx.Wrap(err, "truncate cache file error")
+			}
+			return nil
+		}
+		offset += size
+		if _, ok := s.entries[entry.key]; ok {
+			s.remove(entry.key)
+			s.garbage++
+		}
+		if op == diskOpDel || entry.expired(now) {
+			s.garbage++
+		} else {
+			s.entries[entry.key] = s.lru.PushFront(entry)
+		}
+	}
+}
+
+// 读取一条记录，返回操作类型、条目以及记录长度
+func readDiskRecord(reader *bufio.Reader) (byte, *diskEntry, int64, error) {
+	var header = make([]byte, diskHeaderSize)
+	if n, err := io.ReadFull(reader, header); err != nil {
+		if err == io.EOF && n == 0 {
+			return 0, nil, 0, io.EOF
+		}
+		return 0, nil, 0, errorx.Wrap(err, "read record header error")
+	}
+	var size = binary.BigEndian.Uint32(header[4:])
+	if size > diskRecordMaxSize {
+		return 0, nil, 0, errorx.New("record size exceeds limit")
+	}
+	var payload = make([]byte, size)
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		return 0, nil, 0, errorx.Wrap(err, "read record payload error")
+	}
+	if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header) {
+		return 0, nil, 0, errorx.New("record checksum mismatch")
+	}
+	if len(payload) < 9 {
+		return 0, nil, 0, errorx.New("record too short")
+	}
+	var entry = &diskEntry{expireAt: int64(binary.BigEndian.Uint64(payload[1:9]))}
+	keyLen, n := binary.Uvarint(payload[9:])
+	if n <= 0 || uint64(len(payload)-9-n) < keyLen {
+		return 0, nil, 0, errorx.New("invalid record key length")
+	}
+	var start = 9 + n
+	entry.key = string(payload[start : start+int(keyLen)])
+	entry.value = string(payload[start+int(keyLen):])
+	return payload[0], entry, int64(diskHeaderSize + len(payload)), nil
+}
+
+// 编码一条记录：crc32 | 长度 | 操作类型 | 过期时间 | KEY长度 | KEY | VALUE
+func encodeDiskRecord(op byte, entry *diskEntry) []byte {
+	var payload = make([]byte, 9+binary.MaxVarintLen64, 9+binary.MaxVarintLen64+len(entry.key)+len(entry.value))
+	payload[0] = op
+	binary.BigEndian.PutUint64(payload[1:9], uint64(entry.expireAt))
+	var n = binary.PutUvarint(payload[9:], uint64(len(entry.key)))
+	payload = append(payload[:9+n], entry.key...)
+	payload = append(payload, entry.value...)
+	var record = make([]byte, diskHeaderSize, diskHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(record, crc32.ChecksumIEEE(payload))
+	binary.BigEndian.PutUint32(record[4:], uint32(len(payload)))
+	return append(record, payload...)
+}
+
+// 追加写入记录，不缓冲写入内容，进程退出时已返回的写入不会丢失
+func (s *diskStore) append(op byte, entry *diskEntry) error {
+	if s.closed {
+		return errorx.New("disk cache is closed")
+	}
+	if _, err := s.file.Write(encodeDiskRecord(op, entry)); err != nil {
+		return errorx.Wrap(err, "write cache file error")
+	}
+	return nil
+}
+
+// 获取未过期的条目，并标记为最近使用
+func (s *diskStore) lookup(key string) (*diskEntry, bool) {
+	elem, ok := s.entries[key]
+	if !ok {
+		return nil, false
+	}
+	var entry = elem.Value.(*diskEntry)
+	if entry.expired(time.Now().UnixNano()) {
+		return nil, false
+	}
+	s.lru.MoveToFront(elem)
+	return entry, true
+}
+
+// 移除内存中的条目
+func (s *diskStore) remove(key string) {
+	if elem, ok := s.entries[key]; ok {
+		s.lru.Remove(elem)
+		delete(s.entries, key)
+	}
+}
+
+// 超出最大条目数时先移除过期条目，仍然超出时淘汰最久未使用的条目，并写入删除记录，避免重启后被淘汰的条目重新出现
+func (s *diskStore) evict() {
+	if s.maxSize <= 0 || len(s.entries) <= s.maxSize {
+		return
+	}
+	s.removeExpired()
+	for len(s.entries) > s.maxSize {
+		var entry = s.lru.Back().Value.(*diskEntry)
+		s.remove(entry.key)
+		s.garbage++
+		s.evictions++
+		if s.file != nil && s.append(diskOpDel, &diskEntry{key: entry.key}) == nil {
+			s.garbage++
+		}
+	}
+}
+
+func (s *diskStore) get(key string) (string, int64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if entry, ok := s.lookup(key); ok {
+		return entry.value, entry.expireAt, true
+	}
+	return "", 0, false
+}
+
+func (s *diskStore) set(key, value string, expireAt int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.put(&diskEntry{key: key, value: value, expireAt: expireAt})
+}
+
+func (s *diskStore) put(entry *diskEntry) error {
+	if err := s.append(diskOpSet, entry); err != nil {
+		return err
+	}
+	if _, ok := s.entries[entry.key]; ok {
+		s.remove(entry.key)
+		s.garbage++
+	}
+	s.entries[entry.key] = s.lru.PushFront(entry)
+	s.evict()
+	return nil
+}
+
+// 更新条目，f的参数为当前未过期的条目（不存在时为nil），返回nil时不做更新
+func (s *diskStore) update(key string, f func(entry *diskEntry) (*diskEntry, error)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var current, _ = s.lookup(key)
+	entry, err := f(current)
+	if err != nil || entry == nil {
+		return err
+	}
+	entry.key = key
+	return s.put(entry)
+}
+
+// 删除条目，返回删除前是否存在
+func (s *diskStore) delete(key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.entries[key]; !ok {
+		return false, nil
+	}
+	var _, exist = s.lookup(key)
+	if err := s.append(diskOpDel, &diskEntry{key: key}); err != nil {
+		return false, err
+	}
+	s.remove(key)
+	s.garbage += 2
+	return exist, nil
+}
+
+// 全部未过期的KEY
+func (s *diskStore) keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var now = time.Now().UnixNano()
+	var keys = make([]string, 0, len(s.entries))
+	for key, elem := range s.entries {
+		if !elem.Value.(*diskEntry).expired(now) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// 移除过期条目，过期条目回放时会被忽略，无需写入删除记录
+func (s *diskStore) removeExpired() {
+	var now = time.Now().UnixNano()
+	for key, elem := range s.entries {
+		if elem.Value.(*diskEntry).expired(now) {
+			s.remove(key)
+			s.garbage++
+			s.evictions++
+		}
+	}
+}
+
+// 清理过期条目，无效记录过多时压缩文件，并将文件内容刷入磁盘
+func (s *diskStore) sweep() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.removeExpired()
+	if s.garbage > diskCompactMinimum && s.garbage > len(s.entries) {
+		return s.compact()
+	}
+	return s.file.Sync()
+}
+
+// 仅保留有效条目重写文件，先写入临时文件再替换，避免写入过程中退出导致数据丢失
+func (s *diskStore) compact() error {
+	var tmp = s.path + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return errorx.Wrap(err, "create compact file error")
+	}
+	var writer = bufio.NewWriter(file)
+	// 从最久未使用的条目开始写入，回放后保持LRU顺序
+	for elem := s.lru.Back(); elem != nil; elem = elem.Prev() {
+		if _, err = writer.Write(encodeDiskRecord(diskOpSet, elem.Value.(*diskEntry))); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return errorx.Wrap(err, "write compact file error")
+	}
+	if err = os.Rename(tmp, s.path); err != nil {
+		return errorx.Wrap(err, "replace cache file error")
+	}
+	_ = s.file.Close()
+	if s.file, err = os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND, 0644); err != nil {
+		s.closed = true
+		return errorx.Wrap(err, "reopen cache file error")
+	}
+	s.garbage = 0
+	return nil
+}
+
+// 淘汰的数量以及当前条目数
+func (s *diskStore) stats() (int64, int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.evictions, int64(len(s.entries))
+}
+
+func (s *diskStore) close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	_ = s.file.Sync()
+	return s.file.Close()
+}
@@ -1,7 +1,17 @@
 package cachex
 
 import (
+	"io"
+	"sort"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
 	"github.com/go-xuan/quanx/common/constx"
+	"github.com/go-xuan/quanx/core/configx"
+	"github.com/go-xuan/quanx/core/redisx"
+	"github.com/go-xuan/quanx/os/errorx"
+	"github.com/go-xuan/quanx/types/anyx"
 )
 
 var _handler *Handler
@@ -13,13 +23,153 @@ func this() *Handler {
 	return _handler
 }
 
+// Default 包级别默认缓存句柄，GetClient()等包级别函数以及 Config.Execute() 均在此句柄上操作，
+// 首次调用时创建，redis缓存使用redisx默认句柄
+func Default() *Handler {
+	if _handler == nil {
+		_handler = NewHandler(nil)
+	}
+	return _handler
+}
+
+// SetDefault 替换包级别默认缓存句柄，替换后包级别函数使用新句柄上的缓存客户端
+func SetDefault(h *Handler) {
+	_handler = h
+}
+
+// Handler 缓存句柄，按照缓存源名称持有缓存客户端以及统计计数器，每个Engine使用各自的句柄
 type Handler struct {
-	multi     bool // 是否多缓存
+	mu        sync.RWMutex
+	multi     bool            // 是否多缓存
+	redis     *redisx.Handler // redis缓存使用的redis句柄，为空时使用redisx默认句柄
 	client    Client
 	clientMap map[string]Client
+	counters  map[string]*counter // 各缓存源的统计计数器
+}
+
+// NewHandler 创建缓存句柄，redis为redis缓存使用的redis句柄，为空时使用redisx默认句柄
+func NewHandler(redis *redisx.Handler) *Handler {
+	return &Handler{
+		redis:     redis,
+		clientMap: make(map[string]Client),
+		counters:  make(map[string]*counter),
+	}
+}
+
+// IsInitialized 是否已初始化任一缓存客户端
+func (h *Handler) IsInitialized() bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.clientMap) > 0
+}
+
+// Accept 是否为单缓存 *Config 或者多缓存 MultiConfig
+func (h *Handler) Accept(conf configx.Configurator) bool {
+	return configx.Accepts[*Config, MultiConfig](conf)
+}
+
+// Execute 创建缓存客户端，多缓存时首个缓存源或者default缓存源作为默认客户端
+func (h *Handler) Execute(conf configx.Configurator) error {
+	return configx.Dispatch(conf, h.execute, h.executeMulti)
+}
+
+// Reload 替换变更的缓存客户端并移除已删除的缓存源
+func (h *Handler) Reload(conf configx.Configurator) error {
+	return configx.Dispatch(conf, h.reload, h.reloadMulti)
+}
+
+// Shutdown 移除配置中全部缓存源的客户端，实现io.Closer的客户端同时关闭
+func (h *Handler) Shutdown(conf configx.Configurator) error {
+	var sources = make(map[string]bool)
+	if err := configx.Dispatch(conf, func(c *Config) error {
+		sources[c.Source] = true
+		return nil
+	}, func(m MultiConfig) error {
+		for _, c := range m {
+			sources[c.Source] = true
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for source := range sources {
+		if client, ok := h.clientMap[source]; ok {
+			delete(h.clientMap, source)
+			delete(h.counters, source)
+			if h.client == client {
+				h.client = nil
+			}
+			closeClient(client)
+		}
+	}
+	return nil
+}
+
+func (h *Handler) execute(c *Config) error {
+	if err := anyx.SetDefaultValue(c); err != nil {
+		return errorx.Wrap(err, "set default value error")
+	}
+	return h.swapClient(c, c.Source == constx.DefaultSource)
+}
+
+func (h *Handler) executeMulti(m MultiConfig) error {
+	h.mu.Lock()
+	h.multi = true
+	h.mu.Unlock()
+	multi := anyx.IfZero(m, MultiConfig{&Config{
+		Source:  constx.DefaultSource,
+		Prefix:  "cache",
+		Marshal: "json",
+	}})
+	for i, c := range multi {
+		if err := anyx.SetDefaultValue(c); err != nil {
+			return errorx.Wrap(err, "set default value error")
+		}
+		if err := h.swapClient(c, i == 0 || c.Source == constx.DefaultSource); err != nil {
+			return errorx.Wrap(err, "init cache client failed")
+		}
+	}
+	return nil
+}
+
+func (h *Handler) reload(c *Config) error {
+	if !h.IsInitialized() {
+		return h.execute(c)
+	}
+	if err := anyx.SetDefaultValue(c); err != nil {
+		return errorx.Wrap(err, "set default value error")
+	}
+	if err := h.swapClient(c, c.Source == constx.DefaultSource); err != nil {
+		return errorx.Wrap(err, "reload cache client failed")
+	}
+	log.Info("cache reload success: ", c.Format())
+	return nil
+}
+
+func (h *Handler) reloadMulti(m MultiConfig) error {
+	if !h.IsInitialized() {
+		return h.executeMulti(m)
+	}
+	var sources = make(map[string]bool)
+	for i, c := range m {
+		if err := anyx.SetDefaultValue(c); err != nil {
+			return errorx.Wrap(err, "set default value error")
+		}
+		if err := h.swapClient(c, i == 0 || c.Source == constx.DefaultSource); err != nil {
+			return errorx.Wrap(err, "reload cache client failed")
+		}
+		sources[c.Source] = true
+	}
+	h.retain(sources)
+	log.Info("cache reload success: ", m.Format())
+	return nil
 }
 
 func (h *Handler) GetClient(source ...string) Client {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
 	if len(source) > 0 && source[0] != constx.DefaultSource {
 		if client, ok := h.clientMap[source[0]]; ok {
 			return client
@@ -28,6 +178,82 @@ func (h *Handler) GetClient(source ...string) Client {
 	return h.client
 }
 
+// 替换缓存客户端，配置未变化时保留原客户端，初始化失败时保留原客户端并返回错误
+func (h *Handler) swapClient(config *Config, isDefault bool) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var client Client
+	var old, ok = h.clientMap[config.Source]
+	if ok && old != nil && *old.Config() == *config {
+		client = old
+	} else if newClient, err := config.initClient(h.redis); err != nil {
+		return err
+	} else {
+		client = newClient
+		if old != nil {
+			closeClient(old)
+		}
+		// 热加载替换客户端后继续累计统计
+		var c, exist = h.counters[config.Source]
+		if !exist {
+			c = &counter{}
+			h.counters[config.Source] = c
+		}
+		client = &statsClient{Client: client, counter: c}
+	}
+	h.clientMap[config.Source] = client
+	if isDefault || h.client == nil || h.client.Config().Source == config.Source {
+		h.client = client
+	}
+	h.multi = h.multi || len(h.clientMap) > 1
+	return nil
+}
+
+// 仅保留指定的缓存客户端
+func (h *Handler) retain(sources map[string]bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for source, client := range h.clientMap {
+		if !sources[source] {
+			delete(h.clientMap, source)
+			delete(h.counters, source)
+			closeClient(client)
+		}
+	}
+}
+
+// Stats 获取全部缓存源的统计，按照缓存源名称排序
+func (h *Handler) Stats() []*Stats {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	var stats = make([]*Stats, 0, len(h.clientMap))
+	for _, client := range h.clientMap {
+		if sc, ok := client.(*statsClient); ok {
+			stats = append(stats, sc.Stats())
+		}
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Source < stats[j].Source
+	})
+	return stats
+}
+
+// Client 获取指定缓存源的客户端，与 GetClient() 不同，缓存源不存在时返回nil
+func (h *Handler) Client(source string) Client {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.clientMap[source]
+}
+
+// 释放缓存客户端持有的资源，例如二级缓存的失效消息订阅
+func closeClient(client Client) {
+	if closer, ok := client.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			log.WithField("source", client.Config().Source).Error("close cache client failed: ", err)
+		}
+	}
+}
+
 // GetConfig 获取配置
 func GetConfig(source ...string) *Config {
 	return this().GetClient(source...).Config()
@@ -37,3 +263,8 @@ func GetConfig(source ...string) *Config {
 func GetClient(source ...string) Client {
 	return this().GetClient(source...)
 }
+
+// GetStats 获取默认句柄中全部缓存源的统计
+func GetStats() []*Stats {
+	return this().Stats()
+}
@@ -0,0 +1,174 @@
+package cachex
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/go-xuan/quanx/os/errorx"
+	"github.com/go-xuan/quanx/utils/marshalx"
+)
+
+// ErrNotFound 数据不存在，Loader返回此错误时可以缓存空结果，避免缓存穿透
+var ErrNotFound = errors.New("cache: not found")
+
+// 空结果占位值
+const notFoundValue = "\x00quanx:cache:not-found"
+
+// 合并加载以及后台提前刷新的超时时间，加载不受单个调用方取消的影响
+const loadTimeout = 30 * time.Second
+
+// 同一缓存KEY的并发加载合并为一次
+var _loadGroup singleflight.Group
+
+// Loader 缓存未命中时的数据加载函数，数据不存在时返回 ErrNotFound
+type Loader func(ctx context.Context) (any, error)
+
+// LoadOption 加载选项
+type LoadOption func(*loadOptions)
+
+type loadOptions struct {
+	negativeTTL  time.Duration // 空结果缓存时间
+	refreshRatio float64       // 提前刷新比例
+}
+
+// WithNegativeCache 数据不存在时缓存空结果，期间直接返回 ErrNotFound 而不再调用Loader
+func WithNegativeCache(expiration time.Duration) LoadOption {
+	return func(o *loadOptions) {
+		o.negativeTTL = expiration
+	}
+}
+
+// WithEarlyRefresh 剩余过期时间小于 expiration*ratio 时按概率在后台提前刷新，越接近过期刷新概率越高，
+// 避免热点KEY过期瞬间大量请求同时回源
+func WithEarlyRefresh(ratio float64) LoadOption {
+	return func(o *loadOptions) {
+		o.refreshRatio = ratio
+	}
+}
+
+// 读取缓存，未命中时通过Loader加载并写入缓存，同一KEY的并发未命中只调用一次Loader
+func getOrLoad(ctx context.Context, client Client, key string, value any, expiration time.Duration, loader Loader, opts ...LoadOption) error {
+	var options = &loadOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	var config = client.Config()
+	var marshal = marshalx.Apply(config.Marshal)
+	notFound, err := marshal.Marshal(notFoundValue)
+	if err != nil {
+		return errorx.Wrap(err, "marshal not found value error")
+	}
+	var groupKey = config.Source + "@" + config.GetKey(key)
+	if result := client.GetString(ctx, key); result == string(notFound) {
+		return ErrNotFound
+	} else if result != "" {
+		if err = marshal.Unmarshal([]byte(result), value); err == nil {
+			if shouldRefresh(ctx, client, key, expiration, options.refreshRatio) {
+				go func() {
+					ctx, cancel := context.WithTimeout(detach(ctx), loadTimeout)
+					defer cancel()
+					if _, err, _ := _loadGroup.Do(groupKey, func() (any, error) {
+						return load(ctx, client, key, expiration, loader, options, true)
+					}); err != nil && !errors.Is(err, ErrNotFound) {
+						log.WithField("key", key).Warn("refresh cache failed: ", err)
+					}
+				}()
+			}
+			return nil
+		}
+	}
+	// 合并后的加载由全部调用方共享，使用独立的超时时间，首个调用方取消时不影响其他调用方
+	var loaded = _loadGroup.DoChan(groupKey, func() (any, error) {
+		ctx, cancel := context.WithTimeout(detach(ctx), loadTimeout)
+		defer cancel()
+		// 等待期间可能已被其他调用写入
+		if result := client.GetString(ctx, key); result == string(notFound) {
+			return nil, ErrNotFound
+		} else if result != "" {
+			return []byte(result), nil
+		}
+		return load(ctx, client, key, expiration, loader, options, false)
+	})
+	var result singleflight.Result
+	select {
+	case result = <-loaded:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if result.Err != nil {
+		return result.Err
+	}
+	if err = marshal.Unmarshal(result.Val.([]byte), value); err != nil {
+		return errorx.Wrap(err, "unmarshal value error")
+	}
+	return nil
+}
+
+// 调用Loader加载数据并写入缓存，返回序列化后的数据，refresh为true时表示提前刷新已缓存的数据
+func load(ctx context.Context, client Client, key string, expiration time.Duration, loader Loader, options *loadOptions, refresh bool) (any, error) {
+	value, err := loader(ctx)
+	if errors.Is(err, ErrNotFound) {
+		if options.negativeTTL > 0 {
+			if err = client.Set(ctx, key, notFoundValue, options.negativeTTL); err != nil {
+				log.WithField("key", key).Warn("set not found cache failed: ", err)
+			}
+		} else if refresh {
+			// 数据已不存在，移除已缓存的旧数据
+			client.Delete(ctx, key)
+		}
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, errorx.Wrap(err, "load value error")
+	}
+	bytes, err := marshalx.Apply(client.Config().Marshal).Marshal(value)
+	if err != nil {
+		return nil, errorx.Wrap(err, "marshal value error")
+	}
+	if err = client.Set(ctx, key, value, expiration); err != nil {
+		return nil, err
+	}
+	return bytes, nil
+}
+
+// 是否提前刷新，剩余时间进入刷新窗口后，刷新概率随剩余时间减少线性增加
+func shouldRefresh(ctx context.Context, client Client, key string, expiration time.Duration, ratio float64) bool {
+	if ratio <= 0 || expiration <= 0 {
+		return false
+	}
+	var window = time.Duration(float64(expiration) * ratio)
+	remaining, err := client.TTL(ctx, key)
+	if err != nil || remaining <= 0 || remaining >= window {
+		return false
+	}
+	return rand.Float64() < 1-float64(remaining)/float64(window)
+}
+
+// 保留调用方上下文中的值（例如链路追踪信息），但不继承调用方的取消信号以及截止时间
+type detachedContext struct {
+	parent context.Context
+}
+
+func detach(ctx context.Context) context.Context {
+	return detachedContext{parent: ctx}
+}
+
+func (c detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (c detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (c detachedContext) Err() error {
+	return nil
+}
+
+func (c detachedContext) Value(key any) any {
+	return c.parent.Value(key)
+}
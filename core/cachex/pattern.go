@@ -0,0 +1,82 @@
+package cachex
+
+// 按照redis KEYS/SCAN的规则匹配KEY，支持 * ? [abc] [^abc] [a-z] 以及 \ 转义，
+// 使本地缓存与redis缓存的 Keys() 结果保持一致
+func matchPattern(pattern, key string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			// 合并连续的*
+			for len(pattern) > 1 && pattern[1] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 1 {
+				return true
+			}
+			for i := 0; i <= len(key); i++ {
+				if matchPattern(pattern[1:], key[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(key) == 0 {
+				return false
+			}
+			key = key[1:]
+			pattern = pattern[1:]
+		case '[':
+			if len(key) == 0 {
+				return false
+			}
+			var matched bool
+			if matched, pattern = matchClass(pattern[1:], key[0]); !matched {
+				return false
+			}
+			key = key[1:]
+		case '\\':
+			if len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+			fallthrough
+		default:
+			if len(key) == 0 || pattern[0] != key[0] {
+				return false
+			}
+			key = key[1:]
+			pattern = pattern[1:]
+		}
+	}
+	return len(key) == 0
+}
+
+// 匹配字符集合，pattern为 [ 之后的内容，返回是否匹配以及 ] 之后剩余的pattern
+func matchClass(pattern string, c byte) (bool, string) {
+	var not = len(pattern) > 0 && pattern[0] == '^'
+	if not {
+		pattern = pattern[1:]
+	}
+	var matched bool
+	for len(pattern) > 0 && pattern[0] != ']' {
+		if pattern[0] == '\\' && len(pattern) > 1 {
+			pattern = pattern[1:]
+			matched = matched || pattern[0] == c
+			pattern = pattern[1:]
+		} else if len(pattern) > 2 && pattern[1] == '-' && pattern[2] != ']' {
+			var start, end = pattern[0], pattern[2]
+			if start > end {
+				start, end = end, start
+			}
+			matched = matched || (c >= start && c <= end)
+			pattern = pattern[3:]
+		} else {
+			matched = matched || pattern[0] == c
+			pattern = pattern[1:]
+		}
+	}
+	if len(pattern) > 0 {
+		// 跳过 ]
+		pattern = pattern[1:]
+	}
+	return matched != not, pattern
+}
@@ -0,0 +1,158 @@
+package cachex
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// Stats 缓存统计
+type Stats struct {
+	Source    string  `json:"source"`    // 缓存源名称
+	Type      string  `json:"type"`      // 缓存类型
+	Prefix    string  `json:"prefix"`    // 缓存KEY前缀
+	Hits      int64   `json:"hits"`      // 命中次数
+	Misses    int64   `json:"misses"`    // 未命中次数
+	HitRatio  float64 `json:"hitRatio"`  // 命中率
+	Sets      int64   `json:"sets"`      // 写入次数
+	Deletes   int64   `json:"deletes"`   // 实际删除的数量
+	Evictions int64   `json:"evictions"` // 缓存自身淘汰的数量（过期清理、超出最大数量），redis缓存由服务端淘汰不统计
+	Items     int64   `json:"items"`     // 本地保存的条目数，redis缓存不统计
+}
+
+// 缓存计数器，缓存客户端热加载替换后继续累计
+type counter struct {
+	hits    int64
+	misses  int64
+	sets    int64
+	deletes int64
+}
+
+func (c *counter) hit(hit bool) {
+	if hit {
+		atomic.AddInt64(&c.hits, 1)
+	} else {
+		atomic.AddInt64(&c.misses, 1)
+	}
+}
+
+// 本地缓存实现此接口以统计淘汰数量
+type evictionCounter interface {
+	evictions() int64
+}
+
+// 本地缓存实现此接口以统计条目数
+type itemCounter interface {
+	items() int64
+}
+
+// 统计缓存客户端的读写情况，不改变被包装客户端的行为
+type statsClient struct {
+	Client
+	counter *counter
+}
+
+// Stats 获取缓存统计
+func (c *statsClient) Stats() *Stats {
+	var config = c.Config()
+	var stats = &Stats{
+		Source:  config.Source,
+		Type:    config.Type,
+		Prefix:  config.Prefix,
+		Hits:    atomic.LoadInt64(&c.counter.hits),
+		Misses:  atomic.LoadInt64(&c.counter.misses),
+		Sets:    atomic.LoadInt64(&c.counter.sets),
+		Deletes: atomic.LoadInt64(&c.counter.deletes),
+	}
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRatio = float64(stats.Hits) / float64(total)
+	}
+	if ec, ok := c.Client.(evictionCounter); ok {
+		stats.Evictions = ec.evictions()
+	}
+	if ic, ok := c.Client.(itemCounter); ok {
+		stats.Items = ic.items()
+	}
+	return stats
+}
+
+// Close 释放被包装客户端持有的资源
+func (c *statsClient) Close() error {
+	if closer, ok := c.Client.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+func (c *statsClient) Get(ctx context.Context, key string, value any) bool {
+	var ok = c.Client.Get(ctx, key, value)
+	c.counter.hit(ok)
+	return ok
+}
+
+func (c *statsClient) GetString(ctx context.Context, key string) string {
+	var result = c.Client.GetString(ctx, key)
+	c.counter.hit(result != "")
+	return result
+}
+
+func (c *statsClient) MGet(ctx context.Context, keys ...string) map[string]string {
+	var result = c.Client.MGet(ctx, keys...)
+	atomic.AddInt64(&c.counter.hits, int64(len(result)))
+	atomic.AddInt64(&c.counter.misses, int64(len(keys)-len(result)))
+	return result
+}
+
+func (c *statsClient) Set(ctx context.Context, key string, value any, d time.Duration) error {
+	var err = c.Client.Set(ctx, key, value, d)
+	if err == nil {
+		atomic.AddInt64(&c.counter.sets, 1)
+	}
+	return err
+}
+
+func (c *statsClient) MSet(ctx context.Context, values map[string]any, d time.Duration) error {
+	var err = c.Client.MSet(ctx, values, d)
+	if err == nil {
+		atomic.AddInt64(&c.counter.sets, int64(len(values)))
+	}
+	return err
+}
+
+func (c *statsClient) SetNX(ctx context.Context, key string, value any, d time.Duration) (bool, error) {
+	ok, err := c.Client.SetNX(ctx, key, value, d)
+	if ok {
+		atomic.AddInt64(&c.counter.sets, 1)
+	}
+	return ok, err
+}
+
+func (c *statsClient) Incr(ctx context.Context, key string, delta int64, d time.Duration) (int64, error) {
+	value, err := c.Client.Incr(ctx, key, delta, d)
+	if err == nil {
+		atomic.AddInt64(&c.counter.sets, 1)
+	}
+	return value, err
+}
+
+func (c *statsClient) Decr(ctx context.Context, key string, delta int64, d time.Duration) (int64, error) {
+	return c.Incr(ctx, key, -delta, d)
+}
+
+func (c *statsClient) Delete(ctx context.Context, keys ...string) int64 {
+	var count = c.Client.Delete(ctx, keys...)
+	atomic.AddInt64(&c.counter.deletes, count)
+	return count
+}
+
+func (c *statsClient) DeletePattern(ctx context.Context, pattern string) (int64, error) {
+	count, err := c.Client.DeletePattern(ctx, pattern)
+	atomic.AddInt64(&c.counter.deletes, count)
+	return count, err
+}
+
+// GetOrLoad 通过当前客户端读写，使加载过程中的读写同样被统计
+func (c *statsClient) GetOrLoad(ctx context.Context, key string, value any, d time.Duration, loader Loader, opts ...LoadOption) error {
+	return getOrLoad(ctx, c, key, value, d, loader, opts...)
+}
@@ -0,0 +1,254 @@
+package configx
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-xuan/quanx/os/errorx"
+	"github.com/go-xuan/quanx/utils/marshalx"
+)
+
+// DefaultOrigin 未被任何配置源设置时，配置来源于结构体default标签
+const DefaultOrigin = "tag@default"
+
+// Chain 配置源优先级链，按优先级从高到低排列，高优先级配置源覆盖低优先级配置源的同名配置项
+type Chain struct {
+	sources []Source
+}
+
+// NewChain 创建配置源优先级链，sources按优先级从高到低排列
+func NewChain(sources ...Source) *Chain {
+	return &Chain{sources: sources}
+}
+
+// Sources 全部配置源
+func (c *Chain) Sources() []Source {
+	return c.sources
+}
+
+// Load 从全部配置源读取并合并配置后反序列化到v，返回每个配置项的来源
+func (c *Chain) Load(reader *Reader, v any) (*Origin, error) {
+	var origin = &Origin{keys: make(map[string]string)}
+	var merged any
+	// 从低优先级到高优先级依次合并
+	for i := len(c.sources) - 1; i >= 0; i-- {
+		var source = c.sources[i]
+		tree, err := source.Get(reader, v)
+		if err != nil {
+			return nil, errorx.Wrap(err, "get config from source failed: "+source.Name(reader))
+		} else if tree == nil {
+			continue
+		}
+		merged = mergeTree(merged, normalize(tree), "", source.Name(reader), origin.keys)
+	}
+	if merged == nil {
+		return origin, nil
+	}
+	merged, err := resolveTree(merged)
+	if err != nil {
+		return nil, err
+	}
+	if err = decodeTree(readerName(reader), merged, v); err != nil {
+		return nil, errorx.Wrap(err, "decode merged config failed")
+	}
+	return origin, nil
+}
+
+// Watch 监听全部配置源，任一配置源变更时回调
+func (c *Chain) Watch(reader *Reader, onChange func()) error {
+	for _, source := range c.sources {
+		if err := source.Watch(reader, onChange); err != nil {
+			return errorx.Wrap(err, "watch config source failed: "+source.Name(reader))
+		}
+	}
+	return nil
+}
+
+// Origin 配置项来源
+type Origin struct {
+	keys map[string]string // 配置项路径 -> 配置源名称
+}
+
+// Found 是否从任一配置源读取到配置
+func (o *Origin) Found() bool {
+	return len(o.keys) > 0
+}
+
+// Of 获取配置项来源，未被配置源设置的配置项来源于default标签
+func (o *Origin) Of(key string) string {
+	if source, ok := o.keys[key]; ok {
+		return source
+	}
+	return DefaultOrigin
+}
+
+// Keys 配置项来源明细
+func (o *Origin) Keys() map[string]string {
+	return o.keys
+}
+
+// Sources 读取到配置的全部配置源名称
+func (o *Origin) Sources() []string {
+	var exists = make(map[string]bool)
+	var sources []string
+	for _, source := range o.keys {
+		if !exists[source] {
+			exists[source] = true
+			sources = append(sources, source)
+		}
+	}
+	sort.Strings(sources)
+	return sources
+}
+
+// String 按配置源汇总配置项，例如：env@QUANX_DATABASE_*[password] local@conf/database.yaml[host,port]
+func (o *Origin) String() string {
+	if len(o.keys) == 0 {
+		return DefaultOrigin
+	}
+	var groups = make(map[string][]string)
+	for key, source := range o.keys {
+		groups[source] = append(groups[source], key)
+	}
+	var sources = o.Sources()
+	var parts = make([]string, 0, len(sources))
+	for _, source := range sources {
+		var keys = groups[source]
+		sort.Strings(keys)
+		parts = append(parts, source+"["+strings.Join(keys, ",")+"]")
+	}
+	return strings.Join(parts, " ")
+}
+
+// 合并配置树，src覆盖dst，记录叶子节点来源
+func mergeTree(dst, src any, path, source string, origins map[string]string) any {
+	switch s := src.(type) {
+	case map[string]any:
+		d, ok := dst.(map[string]any)
+		if !ok {
+			d = make(map[string]any, len(s))
+		}
+		for key, value := range s {
+			d[key] = mergeTree(d[key], value, joinPath(path, key), source, origins)
+		}
+		return d
+	case []any:
+		d, _ := dst.([]any)
+		// 标量列表整体覆盖，对象列表按下标合并
+		if len(s) > 0 && isScalarValue(s[0]) {
+			removeOrigins(origins, path)
+			origins[path] = source
+			return s
+		}
+		for i, value := range s {
+			if i < len(d) {
+				d[i] = mergeTree(d[i], value, joinPath(path, strconv.Itoa(i)), source, origins)
+			} else {
+				d = append(d, mergeTree(nil, value, joinPath(path, strconv.Itoa(i)), source, origins))
+			}
+		}
+		return d
+	default:
+		removeOrigins(origins, path)
+		origins[path] = source
+		return src
+	}
+}
+
+// 移除路径下已记录的来源
+func removeOrigins(origins map[string]string, path string) {
+	for key := range origins {
+		if key == path || strings.HasPrefix(key, path+".") {
+			delete(origins, key)
+		}
+	}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func isScalarValue(v any) bool {
+	switch v.(type) {
+	case map[string]any, []any:
+		return false
+	default:
+		return true
+	}
+}
+
+// 配置树统一为 map[string]any / []any / 标量，整数值的浮点数转换为整数
+func normalize(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for key, value := range t {
+			t[key] = normalize(value)
+		}
+		return t
+	case map[any]any:
+		var m = make(map[string]any, len(t))
+		for key, value := range t {
+			m[fmt.Sprint(key)] = normalize(value)
+		}
+		return m
+	case []any:
+		for i, value := range t {
+			t[i] = normalize(value)
+		}
+		return t
+	case []map[string]any:
+		var list = make([]any, len(t))
+		for i, value := range t {
+			list[i] = normalize(value)
+		}
+		return list
+	case float64:
+		if t == math.Trunc(t) && math.Abs(t) < 1<<53 {
+			return int64(t)
+		}
+		return t
+	default:
+		return v
+	}
+}
+
+// 替换配置树中的 ${ENV:default} 占位符后解密 ENC(...) 加密值
+func resolveTree(tree any) (any, error) {
+	return DecryptTree(ExpandTree(tree))
+}
+
+// 配置树按照配置文件格式序列化后反序列化到v
+func decodeTree(name string, tree any, v any) error {
+	if ref := reflect.ValueOf(v); ref.Kind() != reflect.Ptr || ref.IsNil() {
+		return errorx.New("the decoded object must be a non-nil pointer")
+	}
+	var strategy = marshalx.Apply(name)
+	switch strategy.Name() {
+	case "json", "yml", "yaml", "toml":
+	default:
+		strategy = marshalx.Apply("yaml")
+	}
+	bytes, err := strategy.Marshal(tree)
+	if err != nil {
+		return errorx.Wrap(err, "marshal config tree failed")
+	}
+	if err = strategy.Unmarshal(bytes, v); err != nil {
+		return errorx.Wrap(err, "unmarshal config tree failed")
+	}
+	return nil
+}
+
+// 配置名称，优先使用本地文件路径
+func readerName(reader *Reader) string {
+	if reader.FilePath != "" {
+		return reader.FilePath
+	}
+	return reader.NacosDataId
+}
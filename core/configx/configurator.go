@@ -11,6 +11,17 @@ type Configurator interface {
 	Execute() error  // 配置器运行
 }
 
+// Closer 可关闭的配置器，服务停止时按照初始化的逆序调用 Close() 释放资源
+type Closer interface {
+	Close() error // 释放配置器初始化的资源
+}
+
+// Reloadable 支持热加载的配置器，配置变更时将变更内容反序列化到新的配置器实例后调用 Reload()
+type Reloadable interface {
+	Configurator
+	Reload() error // 使用新配置重新初始化，并替换已有资源
+}
+
 // Reader 配置文件读取器
 type Reader struct {
 	FilePath    string `json:"filePath" yaml:"filePath"`       // 本地配置文件路径
@@ -19,8 +30,11 @@ type Reader struct {
 	Listen      bool   `json:"listen" yaml:"listen"`           // 是否监听
 }
 
-// Execute 运行配置器
+// Execute 校验配置后运行配置器
 func Execute(conf Configurator) error {
+	if err := Validate(conf); err != nil {
+		return errorx.Wrap(err, "configurator validate err")
+	}
 	if err := conf.Execute(); err != nil {
 		return errorx.Wrap(err, "configurator execute err")
 	}
@@ -1,8 +1,14 @@
 package configx
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/go-xuan/quanx/types/anyx"
 )
@@ -43,3 +49,392 @@ func TestConfigurator(t *testing.T) {
 	fmt.Println("after execute, id:", config.Id)
 	fmt.Println(config)
 }
+
+func TestPublish(t *testing.T) {
+	var received []string
+	Subscribe(NacosKey("group", "test.yaml"), func(content []byte) {
+		received = append(received, string(content))
+	})
+	Subscribe(NacosKey("group", "test.yaml"), func(content []byte) {
+		panic("handler panic should not break other handlers")
+	})
+	Subscribe(NacosKey("group", "test.yaml"), func(content []byte) {
+		received = append(received, string(content))
+	})
+	Publish(NacosKey("group", "other.yaml"), []byte("ignored"))
+	Publish(NacosKey("group", "test.yaml"), []byte("changed"))
+	if len(received) != 2 || received[0] != "changed" {
+		t.Errorf("received = %v, want 2 changed contents", received)
+	}
+}
+
+func TestFileWatcher(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "test.yaml")
+	if err := os.WriteFile(path, []byte("level: info"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var watcher = NewFileWatcher(time.Hour)
+	defer watcher.Stop()
+	if err := watcher.Watch(path); err != nil {
+		t.Fatal(err)
+	}
+	var received []string
+	Subscribe(LocalKey(path), func(content []byte) {
+		received = append(received, string(content))
+	})
+
+	watcher.Check()
+	if len(received) != 0 {
+		t.Fatalf("unchanged file should not publish, received = %v", received)
+	}
+	// 修改时间变化但内容不变时不发布
+	var later = time.Now().Add(time.Minute)
+	_ = os.Chtimes(path, later, later)
+	watcher.Check()
+	if len(received) != 0 {
+		t.Fatalf("touched file should not publish, received = %v", received)
+	}
+	if err := os.WriteFile(path, []byte("level: debug"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	watcher.Check()
+	if len(received) != 1 || received[0] != "level: debug" {
+		t.Errorf("received = %v, want [level: debug]", received)
+	}
+}
+
+type chainTest struct {
+	Host     string   `json:"host" yaml:"host" default:"localhost"`
+	Port     int      `json:"port" yaml:"port"`
+	Password string   `json:"password" yaml:"password"`
+	Debug    bool     `json:"debug" yaml:"debug"`
+	Tags     []string `json:"tags" yaml:"tags"`
+}
+
+func (c *chainTest) Format() string {
+	return fmt.Sprintf("host=%s port=%d", c.Host, c.Port)
+}
+
+func (c *chainTest) Reader() *Reader {
+	return &Reader{FilePath: "chain.yaml"}
+}
+
+func (c *chainTest) Execute() error {
+	return nil
+}
+
+func TestChain(t *testing.T) {
+	var dir = t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "chain.yaml"), []byte("host: file\nport: 3306\ndebug: true\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var memory = NewMemorySource("test")
+	memory.Set("chain.yaml", map[string]any{"port": 5432, "tags": []any{"a", "b"}})
+	var env = NewEnvSource("")
+	env.lookup = func(key string) (string, bool) {
+		if key == "QUANX_CHAIN_PASSWORD" {
+			return "123456", true
+		}
+		return "", false
+	}
+
+	var conf = &chainTest{}
+	origin, err := NewChain(env, memory, NewFileSource(dir, false)).Load(conf.Reader(), conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conf.Host != "file" || conf.Port != 5432 || conf.Password != "123456" || !conf.Debug || len(conf.Tags) != 2 {
+		t.Fatalf("unexpected config: %+v", conf)
+	}
+	for key, want := range map[string]string{
+		"host":     LocalKey(filepath.Join(dir, "chain.yaml")),
+		"port":     "memory@test@chain.yaml",
+		"password": "env@QUANX_CHAIN_*",
+		"missing":  DefaultOrigin,
+	} {
+		if got := origin.Of(key); got != want {
+			t.Errorf("origin of %s = %s, want %s", key, got, want)
+		}
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	for name, want := range map[string]string{
+		"MaxIdleConns": "MAX_IDLE_CONNS",
+		"NacosDataId":  "NACOS_DATA_ID",
+		"HTTPServer":   "HTTP_SERVER",
+		"database":     "DATABASE",
+		"access-key":   "ACCESS_KEY",
+	} {
+		if got := envName(name); got != want {
+			t.Errorf("envName(%s) = %s, want %s", name, got, want)
+		}
+	}
+}
+
+func TestExpand(t *testing.T) {
+	t.Setenv("QUANX_TEST_HOST", "localhost")
+	t.Setenv("QUANX_TEST_PORT", "3306")
+	for s, want := range map[string]string{
+		"${QUANX_TEST_HOST}":                    "localhost",
+		"${QUANX_TEST_HOST:127.0.0.1}":          "localhost",
+		"${QUANX_TEST_MISSING:default}":         "default",
+		"${QUANX_TEST_MISSING}":                 "",
+		"${QUANX_TEST_HOST}:${QUANX_TEST_PORT}": "localhost:3306",
+		"$${QUANX_TEST_HOST}":                   "${QUANX_TEST_HOST}",
+		"plain":                                 "plain",
+	} {
+		if got := Expand(s); got != want {
+			t.Errorf("Expand(%s) = %s, want %s", s, got, want)
+		}
+	}
+
+	var conf = &chainTest{}
+	if err := Unmarshal("chain.yaml", []byte("host: ${QUANX_TEST_HOST}\nport: ${QUANX_TEST_PORT:5432}\ndebug: ${QUANX_TEST_DEBUG:true}\n"), conf); err != nil {
+		t.Fatal(err)
+	}
+	if conf.Host != "localhost" || conf.Port != 3306 || !conf.Debug {
+		t.Fatalf("unexpected config: %+v", conf)
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	type server struct {
+		Name string `yaml:"name"`
+		Port int    `yaml:"port"`
+	}
+	type app struct {
+		Server *server `yaml:"server"`
+	}
+	t.Setenv("QUANX_SERVER_PORT", "9090")
+	var conf = &app{Server: &server{Name: "app", Port: 8888}}
+	if err := ApplyEnv(EnvPrefix, conf); err != nil {
+		t.Fatal(err)
+	}
+	if conf.Server.Name != "app" || conf.Server.Port != 9090 {
+		t.Fatalf("unexpected config: %+v", conf.Server)
+	}
+}
+
+func TestUnmarshalWithEnv(t *testing.T) {
+	type source struct {
+		Source   string `yaml:"source"`
+		Type     string `yaml:"type"`
+		Host     string `yaml:"host"`
+		Port     int    `yaml:"port"`
+		Password string `yaml:"password"`
+	}
+	type app struct {
+		Database []*source `yaml:"database"`
+	}
+	var content = []byte(`
+database:
+  - source: default
+    type: mysql
+    host: localhost
+    port: 3306
+  - source: backup
+    type: postgres
+    host: backup
+    port: 5432
+`)
+	t.Setenv("QUANX_DATABASE_0_PASSWORD", "secret")
+	t.Setenv("QUANX_DATABASE_1_PORT", "5433")
+	var conf = &app{}
+	if err := UnmarshalWithEnv("config.yaml", content, EnvPrefix, conf); err != nil {
+		t.Fatal(err)
+	}
+	if len(conf.Database) != 2 {
+		t.Fatalf("expected 2 sources, got %d", len(conf.Database))
+	}
+	if first := conf.Database[0]; first.Source != "default" || first.Type != "mysql" ||
+		first.Host != "localhost" || first.Port != 3306 || first.Password != "secret" {
+		t.Errorf("unexpected first source: %+v", first)
+	}
+	if second := conf.Database[1]; second.Source != "backup" || second.Host != "backup" || second.Port != 5433 {
+		t.Errorf("unexpected second source: %+v", second)
+	}
+
+	// 已加载的配置同样按下标覆盖
+	t.Setenv("QUANX_DATABASE_0_PASSWORD", "changed")
+	if err := ApplyEnv(EnvPrefix, conf); err != nil {
+		t.Fatal(err)
+	}
+	if first := conf.Database[0]; first.Host != "localhost" || first.Password != "changed" {
+		t.Errorf("unexpected first source: %+v", first)
+	}
+}
+
+func TestCrypt(t *testing.T) {
+	if err := SetEncryptKey("quanx-test-key"); err == nil {
+		t.Fatal("key of invalid length should be rejected")
+	}
+	key, err := GenerateEncryptKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = SetEncryptKey(key); err != nil {
+		t.Fatal(err)
+	}
+	defer SetEncryptKey("")
+	ciphertext, err := Encrypt("123456")
+	if err != nil {
+		t.Fatal(err)
+	} else if !IsEncrypted(ciphertext) {
+		t.Fatalf("unexpected ciphertext: %s", ciphertext)
+	}
+	var conf = &chainTest{}
+	if err = Unmarshal("chain.yaml", []byte("host: localhost\npassword: "+ciphertext+"\n"), conf); err != nil {
+		t.Fatal(err)
+	}
+	if conf.Host != "localhost" || conf.Password != "123456" {
+		t.Fatalf("unexpected config: %+v", conf)
+	}
+
+	// 秘钥同样支持hex编码
+	_ = SetEncryptKey(strings.Repeat("ab", 32))
+	if _, err = Decrypt(ciphertext); err == nil {
+		t.Fatal("decrypt with wrong key should fail")
+	}
+}
+
+type validateTest struct {
+	Type string `yaml:"type" default:"mysql" validate:"oneof=mysql postgres"`
+	Port int    `yaml:"port" validate:"min=1,max=65535"`
+	Host string `yaml:"host"`
+}
+
+func (v *validateTest) Validate() error {
+	var err = &ValidationError{}
+	if v.Host == "" {
+		err.Add("host", "is required")
+	}
+	return err.Err()
+}
+
+func TestValidate(t *testing.T) {
+	var conf = &validateTest{Port: 3306, Host: "localhost"}
+	if err := Validate(conf); err != nil {
+		t.Fatal(err)
+	} else if conf.Type != "mysql" {
+		t.Fatalf("default value not set: %+v", conf)
+	}
+
+	var list = []*validateTest{{Type: "oracle", Port: 3306, Host: "localhost"}, {Port: 70000}}
+	err := Validate(&list)
+	var invalid *ValidationError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var fields = make(map[string]bool)
+	for _, field := range invalid.Fields {
+		fields[field.Field] = true
+	}
+	for _, field := range []string{"0.type", "1.port", "1.host"} {
+		if !fields[field] {
+			t.Errorf("field %s should be invalid: %v", field, err)
+		}
+	}
+}
+
+func TestGraph(t *testing.T) {
+	var mu sync.Mutex
+	var order []string
+	var run = func(name string) func() error {
+		return func() error {
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+			if name == "redis" {
+				return errors.New("connect failed")
+			}
+			return nil
+		}
+	}
+	var graph = NewGraph()
+	for name, deps := range map[string][]string{
+		"log":     {"mongo"},
+		"mongo":   nil,
+		"redis":   {"log"},
+		"cache":   {"redis", "log"},
+		"captcha": {"cache"},
+	} {
+		if err := graph.Add(name, run(name), deps...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	levels, err := graph.Levels()
+	if err != nil {
+		t.Fatal(err)
+	} else if fmt.Sprint(levels) != "[[mongo] [log] [redis] [cache] [captcha]]" {
+		t.Fatalf("unexpected levels: %v", levels)
+	}
+	if err = graph.Run(); err == nil {
+		t.Fatal("run should fail when redis failed")
+	} else if fmt.Sprint(order) != "[mongo log redis]" {
+		t.Fatalf("dependents of failed component should be skipped: %v", order)
+	}
+
+	var cycle = NewGraph()
+	_ = cycle.Add("a", nil, "b")
+	_ = cycle.Add("b", nil, "c")
+	_ = cycle.Add("c", nil, "a")
+	if _, err = cycle.Levels(); err == nil || !strings.Contains(err.Error(), "a -> b -> c -> a") {
+		t.Fatalf("cycle should be detected: %v", err)
+	}
+	if err = cycle.Add("a", nil); err == nil {
+		t.Fatal("duplicated component should be rejected")
+	}
+}
+
+// 记录运行次数的句柄，每个句柄独立计数
+type containerTarget struct {
+	executed map[string]int
+}
+
+func (t *containerTarget) Accept(conf Configurator) bool {
+	_, ok := conf.(*Test)
+	return ok
+}
+
+func (t *containerTarget) Execute(conf Configurator) error {
+	t.executed[conf.(*Test).Name]++
+	return nil
+}
+
+func (t *containerTarget) Reload(conf Configurator) error {
+	return t.Execute(conf)
+}
+
+func (t *containerTarget) Shutdown(conf Configurator) error {
+	delete(t.executed, conf.(*Test).Name)
+	return nil
+}
+
+func TestContainer(t *testing.T) {
+	var first, second = &containerTarget{executed: map[string]int{}}, &containerTarget{executed: map[string]int{}}
+	var c1, c2 = NewContainer(), NewContainer()
+	c1.Register("test", first)
+	c2.Register("test", second)
+
+	if err := c1.Execute(&Test{Name: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c2.Execute(&Test{Name: "b"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c2.Reload(&Test{Name: "b"}); err != nil {
+		t.Fatal(err)
+	}
+	if first.executed["a"] != 1 || first.executed["b"] != 0 || second.executed["b"] != 2 {
+		t.Fatalf("containers should be isolated: %v %v", first.executed, second.executed)
+	}
+	if err := c1.Close(&Test{Name: "a"}); err != nil {
+		t.Fatal(err)
+	} else if len(first.executed) != 0 || len(second.executed) != 1 {
+		t.Fatalf("close should only affect its own container: %v %v", first.executed, second.executed)
+	}
+	if target := NewContainer().Target(&Test{}); target != nil {
+		t.Fatal("empty container should not have a target")
+	}
+}
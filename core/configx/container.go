@@ -0,0 +1,118 @@
+package configx
+
+import (
+	"sync"
+
+	"github.com/go-xuan/quanx/os/errorx"
+)
+
+// Target 配置器运行目标，通常为组件的连接句柄，使配置器运行在指定的句柄上而不是包级别默认句柄
+type Target interface {
+	Accept(conf Configurator) bool    // 是否可以运行此配置器
+	Execute(conf Configurator) error  // 运行配置器
+	Reload(conf Configurator) error   // 热加载配置器
+	Shutdown(conf Configurator) error // 释放配置器初始化的资源
+}
+
+// Accepts 配置器是否为单配置类型S或者多配置类型M（包括*M），用于同时支持单配置以及多配置的句柄实现 Target.Accept
+func Accepts[S Configurator, M any](conf Configurator) bool {
+	switch any(conf).(type) {
+	case S, M, *M:
+		return true
+	default:
+		return false
+	}
+}
+
+// Dispatch 按照配置器类型调用单配置或者多配置的处理函数，配置器类型不匹配时返回错误
+func Dispatch[S Configurator, M any](conf Configurator, single func(S) error, multi func(M) error) error {
+	switch c := any(conf).(type) {
+	case S:
+		return single(c)
+	case M:
+		return multi(c)
+	case *M:
+		return multi(*c)
+	default:
+		return errorx.Errorf("unsupported configurator: %T", conf)
+	}
+}
+
+// Container 组件容器，按照名称持有各组件的句柄，同一进程中的多个容器相互隔离，
+// 配置器没有对应的句柄时回退到配置器自身的 Execute()/Reload()/Close()
+type Container struct {
+	mu      sync.RWMutex
+	names   []string          // 句柄名称，按照注册顺序
+	targets map[string]Target // 句柄
+}
+
+// NewContainer 创建组件容器
+func NewContainer() *Container {
+	return &Container{targets: make(map[string]Target)}
+}
+
+// Register 注册句柄，名称已存在时替换
+func (c *Container) Register(name string, target Target) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.targets[name]; !ok {
+		c.names = append(c.names, name)
+	}
+	c.targets[name] = target
+}
+
+// Get 获取句柄
+func (c *Container) Get(name string) Target {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.targets[name]
+}
+
+// Target 获取可以运行此配置器的句柄，按照注册顺序匹配
+func (c *Container) Target(conf Configurator) Target {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, name := range c.names {
+		if target := c.targets[name]; target.Accept(conf) {
+			return target
+		}
+	}
+	return nil
+}
+
+// Execute 校验配置后使用容器中的句柄运行配置器
+func (c *Container) Execute(conf Configurator) error {
+	if err := Validate(conf); err != nil {
+		return errorx.Wrap(err, "configurator validate err")
+	}
+	var err error
+	if target := c.Target(conf); target != nil {
+		err = target.Execute(conf)
+	} else {
+		err = conf.Execute()
+	}
+	if err != nil {
+		return errorx.Wrap(err, "configurator execute err")
+	}
+	return nil
+}
+
+// Reload 使用容器中的句柄热加载配置器
+func (c *Container) Reload(conf Configurator) error {
+	if target := c.Target(conf); target != nil {
+		return target.Reload(conf)
+	} else if reloadable, ok := conf.(Reloadable); ok {
+		return reloadable.Reload()
+	}
+	return errorx.Errorf("configurator does not support reload: %T", conf)
+}
+
+// Close 使用容器中的句柄释放配置器初始化的资源
+func (c *Container) Close(conf Configurator) error {
+	if target := c.Target(conf); target != nil {
+		return target.Shutdown(conf)
+	} else if closer, ok := conf.(Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,237 @@
+package configx
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-xuan/quanx/common/constx"
+	"github.com/go-xuan/quanx/os/errorx"
+	"github.com/go-xuan/quanx/os/flagx"
+	"github.com/go-xuan/quanx/utils/encryptx"
+)
+
+const (
+	EncryptKeyEnv     = "QUANX_CONFIG_KEY"      // 配置加密秘钥环境变量
+	EncryptKeyFileEnv = "QUANX_CONFIG_KEY_FILE" // 配置加密秘钥文件环境变量
+	encryptKeySize    = 32                      // 配置加密秘钥字节数，使用AES-256
+	encryptedPrefix   = "ENC("
+	encryptedSuffix   = ")"
+)
+
+// DefaultKeyFile 默认配置加密秘钥文件
+var DefaultKeyFile = filepath.Join(constx.DefaultConfDir, ".config.key")
+
+var _encryptKey = &encryptKey{}
+
+// 配置加密秘钥，首次使用时加载
+type encryptKey struct {
+	mu  sync.Mutex
+	key []byte
+}
+
+func (k *encryptKey) get() ([]byte, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.key == nil {
+		key, err := loadEncryptKey()
+		if err != nil {
+			return nil, err
+		}
+		k.key = key
+	}
+	return k.key, nil
+}
+
+func (k *encryptKey) set(key []byte) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if len(key) == 0 {
+		key = nil
+	}
+	k.key = key
+}
+
+// 按照 环境变量QUANX_CONFIG_KEY > QUANX_CONFIG_KEY_FILE指定文件 > DefaultKeyFile 的顺序加载秘钥
+func loadEncryptKey() ([]byte, error) {
+	if key := os.Getenv(EncryptKeyEnv); key != "" {
+		return ParseEncryptKey(key)
+	}
+	var path = os.Getenv(EncryptKeyFileEnv)
+	if path == "" {
+		path = DefaultKeyFile
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errorx.Errorf("config encrypt key not found, set %s or %s: %s", EncryptKeyEnv, EncryptKeyFileEnv, err)
+	}
+	if key := strings.TrimSpace(string(content)); key != "" {
+		return ParseEncryptKey(key)
+	}
+	return nil, errorx.Errorf("config encrypt key file is empty: %s", path)
+}
+
+// ParseEncryptKey 解析base64或者hex编码的秘钥，秘钥必须为32字节随机值，可使用 GenerateEncryptKey 生成
+func ParseEncryptKey(key string) ([]byte, error) {
+	if bytes, err := hex.DecodeString(key); err == nil && len(bytes) == encryptKeySize {
+		return bytes, nil
+	}
+	if bytes, err := base64.StdEncoding.DecodeString(key); err == nil && len(bytes) == encryptKeySize {
+		return bytes, nil
+	}
+	return nil, errorx.Errorf("config encrypt key must be %d bytes encoded in base64 or hex", encryptKeySize)
+}
+
+// GenerateEncryptKey 生成base64编码的32字节随机秘钥
+func GenerateEncryptKey() (string, error) {
+	var key = make([]byte, encryptKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", errorx.Wrap(err, "generate config encrypt key failed")
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// SetEncryptKey 设置base64或者hex编码的配置加密秘钥，优先于环境变量以及秘钥文件，key为空时重新从环境变量或秘钥文件加载
+func SetEncryptKey(key string) error {
+	if key == "" {
+		_encryptKey.set(nil)
+		return nil
+	}
+	bytes, err := ParseEncryptKey(key)
+	if err != nil {
+		return err
+	}
+	_encryptKey.set(bytes)
+	return nil
+}
+
+// IsEncrypted 是否为 ENC(...) 加密值
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, encryptedPrefix) && strings.HasSuffix(value, encryptedSuffix)
+}
+
+// Encrypt 加密配置值，返回 ENC(...) 格式密文
+func Encrypt(plaintext string) (string, error) {
+	key, err := _encryptKey.get()
+	if err != nil {
+		return "", err
+	}
+	ciphertext, err := encryptx.AesGcmEncrypt([]byte(plaintext), key)
+	if err != nil {
+		return "", errorx.Wrap(err, "encrypt config value failed")
+	}
+	return encryptedPrefix + encryptx.Base64Encode(ciphertext, true) + encryptedSuffix, nil
+}
+
+// Decrypt 解密 ENC(...) 格式配置值，非加密值原样返回
+func Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	key, err := _encryptKey.get()
+	if err != nil {
+		return "", err
+	}
+	var text = strings.TrimSuffix(strings.TrimPrefix(value, encryptedPrefix), encryptedSuffix)
+	ciphertext, err := encryptx.Base64Decode(text, true)
+	if err != nil {
+		return "", errorx.Wrap(err, "decode config value failed")
+	}
+	plaintext, err := encryptx.AesGcmDecrypt(ciphertext, key)
+	if err != nil {
+		return "", errorx.Wrap(err, "decrypt config value failed")
+	}
+	return string(plaintext), nil
+}
+
+// DecryptTree 解密配置树中全部 ENC(...) 格式的字符串值
+func DecryptTree(tree any) (any, error) {
+	return decryptTree(tree, "")
+}
+
+func decryptTree(tree any, path string) (any, error) {
+	switch t := tree.(type) {
+	case map[string]any:
+		for key, value := range t {
+			decrypted, err := decryptTree(value, joinPath(path, key))
+			if err != nil {
+				return nil, err
+			}
+			t[key] = decrypted
+		}
+		return t, nil
+	case []any:
+		for i, value := range t {
+			decrypted, err := decryptTree(value, joinPath(path, strconv.Itoa(i)))
+			if err != nil {
+				return nil, err
+			}
+			t[i] = decrypted
+		}
+		return t, nil
+	case string:
+		if !IsEncrypted(t) {
+			return t, nil
+		}
+		plaintext, err := Decrypt(t)
+		if err != nil {
+			return nil, errorx.Wrap(err, "decrypt config failed: "+path)
+		}
+		return plaintext, nil
+	default:
+		return tree, nil
+	}
+}
+
+// CryptCommand 配置值加解密命令，秘钥仅读取环境变量 QUANX_CONFIG_KEY 或者秘钥文件，避免秘钥出现在命令历史以及进程列表中，
+// 需要在main函数中注册并执行，例如：
+//
+//	flagx.Register(configx.CryptCommand())
+//	if len(os.Args) > 1 {
+//		if err := flagx.Execute(); err != nil {
+//			log.Fatal(err)
+//		}
+//		return
+//	}
+//	quanx.NewEngine().RUN()
+//
+// app crypt -genkey 生成秘钥，app crypt -value=123456 加密，app crypt -decrypt -value=ENC(...) 解密
+func CryptCommand() *flagx.Command {
+	var command = flagx.NewCommand("crypt", "配置值加解密，秘钥读取 "+EncryptKeyEnv+" 或 "+EncryptKeyFileEnv,
+		flagx.StringOption("value", "待加密明文或待解密的ENC(...)密文", ""),
+		flagx.BoolOption("decrypt", "是否解密", false),
+		flagx.BoolOption("genkey", "生成base64编码的32字节随机秘钥", false),
+	)
+	return command.SetExecutor(func() error {
+		if command.GetOptionValue("genkey").Bool() {
+			key, err := GenerateEncryptKey()
+			if err != nil {
+				return err
+			}
+			fmt.Println(key)
+			return nil
+		}
+		var value = command.GetOptionValue("value").String()
+		if value == "" {
+			return errorx.New("value is required")
+		}
+		var result string
+		var err error
+		if command.GetOptionValue("decrypt").Bool() {
+			result, err = Decrypt(value)
+		} else {
+			result, err = Encrypt(value)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Println(result)
+		return nil
+	})
+}
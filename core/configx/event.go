@@ -0,0 +1,53 @@
+package configx
+
+import (
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var _subscribers = &subscribers{handlers: make(map[string][]ChangeHandler)}
+
+// ChangeHandler 配置变更处理函数，content为变更后的配置内容
+type ChangeHandler func(content []byte)
+
+type subscribers struct {
+	mu       sync.RWMutex
+	handlers map[string][]ChangeHandler
+}
+
+// NacosKey nacos配置变更事件key
+func NacosKey(group, dataId string) string {
+	return "nacos@" + group + "@" + dataId
+}
+
+// LocalKey 本地配置文件变更事件key
+func LocalKey(path string) string {
+	return "local@" + path
+}
+
+// Subscribe 订阅配置变更事件
+func Subscribe(key string, handler ChangeHandler) {
+	_subscribers.mu.Lock()
+	defer _subscribers.mu.Unlock()
+	_subscribers.handlers[key] = append(_subscribers.handlers[key], handler)
+}
+
+// Publish 发布配置变更事件，按订阅顺序同步调用处理函数
+func Publish(key string, content []byte) {
+	_subscribers.mu.RLock()
+	var handlers = append([]ChangeHandler{}, _subscribers.handlers[key]...)
+	_subscribers.mu.RUnlock()
+	for _, handler := range handlers {
+		publish(key, handler, content)
+	}
+}
+
+func publish(key string, handler ChangeHandler, content []byte) {
+	defer func() {
+		if err := recover(); err != nil {
+			log.WithField("key", key).Error("config change handler panic: ", err)
+		}
+	}()
+	handler(content)
+}
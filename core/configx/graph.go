@@ -0,0 +1,231 @@
+package configx
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/go-xuan/quanx/os/errorx"
+)
+
+// Named 声明组件名称的配置器，未实现时使用配置文件名作为组件名称，例如database.yaml对应database
+type Named interface {
+	ComponentName() string // 组件名称
+}
+
+// Dependent 声明依赖组件的配置器，依赖的组件运行完成后才会运行当前配置器
+type Dependent interface {
+	DependsOn() []string // 依赖的组件名称
+}
+
+// ComponentName 配置器对应的组件名称
+func ComponentName(conf Configurator) string {
+	if named, ok := conf.(Named); ok {
+		if name := named.ComponentName(); name != "" {
+			return name
+		}
+	}
+	if reader := conf.Reader(); reader != nil {
+		if name := readerName(reader); name != "" {
+			return strings.TrimSuffix(filepath.Base(name), filepath.Ext(name))
+		}
+	}
+	return fmt.Sprintf("%T", conf)
+}
+
+// DependsOn 配置器依赖的组件名称
+func DependsOn(conf Configurator) []string {
+	if dependent, ok := conf.(Dependent); ok {
+		return dependent.DependsOn()
+	}
+	return nil
+}
+
+// Graph 组件依赖图，按照依赖关系拓扑排序后分层运行，同一层的组件之间没有依赖，并行运行
+type Graph struct {
+	names []string              // 组件名称，按照添加顺序
+	nodes map[string]*graphNode // 组件
+}
+
+type graphNode struct {
+	name string
+	deps []string
+	run  func() error
+}
+
+func NewGraph() *Graph {
+	return &Graph{nodes: make(map[string]*graphNode)}
+}
+
+// Add 添加组件，组件名称不能重复
+func (g *Graph) Add(name string, run func() error, deps ...string) error {
+	if _, ok := g.nodes[name]; ok {
+		return errorx.Errorf("component is duplicated: %s", name)
+	}
+	g.names = append(g.names, name)
+	g.nodes[name] = &graphNode{name: name, deps: deps, run: run}
+	return nil
+}
+
+// Has 是否存在组件
+func (g *Graph) Has(name string) bool {
+	_, ok := g.nodes[name]
+	return ok
+}
+
+// Levels 拓扑排序，返回分层后的组件名称，依赖不存在或者存在循环依赖时返回错误
+func (g *Graph) Levels() ([][]string, error) {
+	var indegree = make(map[string]int, len(g.nodes))
+	var dependents = make(map[string][]string, len(g.nodes))
+	for _, name := range g.names {
+		for _, dep := range uniqueNames(g.nodes[name].deps) {
+			if _, ok := g.nodes[dep]; !ok {
+				return nil, errorx.Errorf("component %s depends on unknown component: %s", name, dep)
+			}
+			indegree[name]++
+			dependents[dep] = append(dependents[dep], name)
+		}
+	}
+	var levels [][]string
+	var current []string
+	for _, name := range g.names {
+		if indegree[name] == 0 {
+			current = append(current, name)
+		}
+	}
+	var sorted int
+	for len(current) > 0 {
+		levels = append(levels, current)
+		sorted += len(current)
+		var next []string
+		for _, name := range current {
+			for _, dependent := range dependents[name] {
+				if indegree[dependent]--; indegree[dependent] == 0 {
+					next = append(next, dependent)
+				}
+			}
+		}
+		g.sortByInsertion(next)
+		current = next
+	}
+	if sorted < len(g.names) {
+		return nil, errorx.Errorf("component dependency cycle: %s", strings.Join(g.findCycle(indegree), " -> "))
+	}
+	return levels, nil
+}
+
+// Run 按照依赖顺序运行全部组件，组件运行失败时跳过依赖它的组件，返回全部失败原因
+func (g *Graph) Run() error {
+	levels, err := g.Levels()
+	if err != nil {
+		return err
+	}
+	var failed = make(map[string]error)
+	var errs []string
+	for _, level := range levels {
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		for _, name := range level {
+			var node = g.nodes[name]
+			if dep := g.failedDependency(node, failed); dep != "" {
+				failed[name] = errorx.Errorf("dependency %s failed", dep)
+				errs = append(errs, fmt.Sprintf("component %s skipped: dependency %s failed", name, dep))
+				continue
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := runNode(node); err != nil {
+					mu.Lock()
+					failed[node.name] = err
+					errs = append(errs, fmt.Sprintf("component %s failed: %v", node.name, err))
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+	}
+	if len(errs) > 0 {
+		return errorx.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// 运行组件，panic时转换为错误
+func runNode(node *graphNode) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errorx.Errorf("panic: %v", r)
+		}
+	}()
+	if node.run != nil {
+		err = node.run()
+	}
+	return
+}
+
+func (g *Graph) failedDependency(node *graphNode, failed map[string]error) string {
+	for _, dep := range node.deps {
+		if _, ok := failed[dep]; ok {
+			return dep
+		}
+	}
+	return ""
+}
+
+func (g *Graph) sortByInsertion(names []string) {
+	var index = make(map[string]int, len(g.names))
+	for i, name := range g.names {
+		index[name] = i
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return index[names[i]] < index[names[j]]
+	})
+}
+
+// 在未完成排序的组件中查找一条循环依赖路径
+func (g *Graph) findCycle(indegree map[string]int) []string {
+	var visiting = make(map[string]int)
+	var path []string
+	var cycle []string
+	var visit func(name string) bool
+	visit = func(name string) bool {
+		if i, ok := visiting[name]; ok {
+			if i >= 0 {
+				cycle = append(append(cycle, path[i:]...), name)
+				return true
+			}
+			return false
+		}
+		visiting[name] = len(path)
+		path = append(path, name)
+		for _, dep := range g.nodes[name].deps {
+			if indegree[dep] > 0 && visit(dep) {
+				return true
+			}
+		}
+		path = path[:len(path)-1]
+		visiting[name] = -1
+		return false
+	}
+	for _, name := range g.names {
+		if indegree[name] > 0 && visit(name) {
+			break
+		}
+	}
+	return cycle
+}
+
+func uniqueNames(names []string) []string {
+	var exists = make(map[string]bool, len(names))
+	var result = make([]string, 0, len(names))
+	for _, name := range names {
+		if name != "" && !exists[name] {
+			exists[name] = true
+			result = append(result, name)
+		}
+	}
+	return result
+}
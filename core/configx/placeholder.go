@@ -0,0 +1,160 @@
+package configx
+
+import (
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/go-xuan/quanx/os/errorx"
+	"github.com/go-xuan/quanx/os/filex"
+	"github.com/go-xuan/quanx/utils/marshalx"
+)
+
+// Expand 替换字符串中的 ${NAME} 和 ${NAME:default} 占位符，环境变量未设置时使用默认值，
+// 未设置且无默认值时替换为空字符串，使用 $${ 转义
+func Expand(s string) string {
+	if !strings.Contains(s, "${") {
+		return s
+	}
+	var sb strings.Builder
+	for {
+		start := strings.Index(s, "${")
+		if start < 0 {
+			sb.WriteString(s)
+			break
+		}
+		// $${ 转义为 ${
+		if start > 0 && s[start-1] == '$' {
+			sb.WriteString(s[:start-1])
+			sb.WriteString("${")
+			s = s[start+2:]
+			continue
+		}
+		end := strings.IndexByte(s[start:], '}')
+		if end < 0 {
+			sb.WriteString(s)
+			break
+		}
+		sb.WriteString(s[:start])
+		sb.WriteString(expandPlaceholder(s[start+2 : start+end]))
+		s = s[start+end+1:]
+	}
+	return sb.String()
+}
+
+// 解析占位符 NAME:default
+func expandPlaceholder(expr string) string {
+	name, def, _ := strings.Cut(expr, ":")
+	if value, ok := os.LookupEnv(strings.TrimSpace(name)); ok {
+		return value
+	}
+	return def
+}
+
+// 字符串是否为单个完整占位符
+func isPlaceholder(s string) bool {
+	return strings.HasPrefix(s, "${") && strings.IndexByte(s, '}') == len(s)-1
+}
+
+// ExpandTree 替换配置树中全部字符串值的占位符，
+// 值为单个完整占位符时按照标量解析，例如 port: ${PORT:8080} 解析为整数
+func ExpandTree(tree any) any {
+	switch t := tree.(type) {
+	case map[string]any:
+		for key, value := range t {
+			t[key] = ExpandTree(value)
+		}
+		return t
+	case []any:
+		for i, value := range t {
+			t[i] = ExpandTree(value)
+		}
+		return t
+	case string:
+		if !strings.Contains(t, "${") {
+			return t
+		} else if isPlaceholder(t) {
+			if value := Expand(t); value != "" {
+				return ParseScalar(value)
+			}
+			return ""
+		}
+		return Expand(t)
+	default:
+		return tree
+	}
+}
+
+// ReadFile 读取本地配置文件，替换占位符并解密加密值后反序列化到v
+func ReadFile(path string, v any) error {
+	content, err := filex.ReadFile(path)
+	if err != nil {
+		return errorx.Wrap(err, "read config file failed")
+	}
+	return Unmarshal(path, content, v)
+}
+
+// Unmarshal 按照文件后缀解析配置内容，替换占位符并解密加密值后反序列化到v
+func Unmarshal(name string, content []byte, v any) error {
+	tree, err := ParseTree(name, content)
+	if err != nil {
+		return errorx.Wrap(err, "parse config content failed")
+	} else if tree == nil {
+		return nil
+	}
+	if tree, err = resolveTree(tree); err != nil {
+		return err
+	}
+	if err = decodeTree(name, tree, v); err != nil {
+		return errorx.Wrap(err, "decode config content failed")
+	}
+	return nil
+}
+
+// UnmarshalWithEnv 按照文件后缀解析配置内容，替换占位符后合并 <prefix>_<字段路径> 环境变量，解密加密值后反序列化到v，
+// 环境变量与配置树逐层合并，对象列表按下标合并，例如 QUANX_DATABASE_0_PASSWORD 仅覆盖 database[0].password
+func UnmarshalWithEnv(name string, content []byte, prefix string, v any) error {
+	tree, err := ParseTree(name, content)
+	if err != nil {
+		return errorx.Wrap(err, "parse config content failed")
+	}
+	return applyEnv(name, ExpandTree(tree), prefix, v)
+}
+
+// ApplyEnv 按照v的结构查找 <prefix>_<字段路径> 环境变量并覆盖到v，
+// 例如 prefix为QUANX时，QUANX_SERVER_PORT 对应 server.port，对象列表按下标覆盖
+func ApplyEnv(prefix string, v any) error {
+	if v == nil {
+		return nil
+	}
+	content, err := marshalx.Apply("yaml").Marshal(v)
+	if err != nil {
+		return errorx.Wrap(err, "marshal config failed")
+	}
+	tree, err := ParseTree("yaml", content)
+	if err != nil {
+		return errorx.Wrap(err, "parse config failed")
+	}
+	return applyEnv("yaml", tree, prefix, v)
+}
+
+// 环境变量配置树合并到tree后解密并反序列化到v
+func applyEnv(name string, tree any, prefix string, v any) error {
+	var source = NewEnvSource(prefix)
+	env, err := source.lookupValue(source.prefix, reflect.TypeOf(v), formatOfName(name), 0)
+	if err != nil {
+		return errorx.Wrap(err, "lookup env config failed")
+	} else if env != nil {
+		tree = mergeTree(tree, env, "", source.prefix, make(map[string]string))
+	}
+	if tree == nil {
+		return nil
+	}
+	if tree, err = DecryptTree(tree); err != nil {
+		return err
+	}
+	if err = decodeTree(name, tree, v); err != nil {
+		return errorx.Wrap(err, "decode config content failed")
+	}
+	return nil
+}
@@ -0,0 +1,332 @@
+package configx
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/go-xuan/quanx/os/errorx"
+	"github.com/go-xuan/quanx/os/filex"
+	"github.com/go-xuan/quanx/utils/marshalx"
+)
+
+// EnvPrefix 环境变量配置前缀
+const EnvPrefix = "QUANX"
+
+// Source 配置源
+type Source interface {
+	Name(reader *Reader) string                  // 配置源名称，用于标识配置来源
+	Get(reader *Reader, v any) (any, error)      // 读取配置树（map/slice/标量），配置不存在时返回nil
+	Watch(reader *Reader, onChange func()) error // 监听配置变更
+}
+
+// FileSource 本地文件配置源，读取 Reader.FilePath
+type FileSource struct {
+	dir   string // 配置文件目录
+	watch bool   // 是否监听文件变更
+}
+
+// NewFileSource 创建本地文件配置源，watch为true时通过 WatchFile() 轮询监听文件变更
+func NewFileSource(dir string, watch bool) *FileSource {
+	return &FileSource{dir: dir, watch: watch}
+}
+
+// Path 配置文件路径
+func (s *FileSource) Path(reader *Reader) string {
+	if s.dir != "" {
+		return filepath.Join(s.dir, reader.FilePath)
+	}
+	return reader.FilePath
+}
+
+func (s *FileSource) Name(reader *Reader) string {
+	return LocalKey(s.Path(reader))
+}
+
+func (s *FileSource) Get(reader *Reader, _ any) (any, error) {
+	if reader.FilePath == "" {
+		return nil, nil
+	}
+	var path = s.Path(reader)
+	if !filex.Exists(path) {
+		return nil, nil
+	}
+	content, err := filex.ReadFile(path)
+	if err != nil {
+		return nil, errorx.Wrap(err, "read config file failed")
+	}
+	return ParseTree(path, content)
+}
+
+func (s *FileSource) Watch(reader *Reader, onChange func()) error {
+	if !s.watch || reader.FilePath == "" {
+		return nil
+	}
+	var path = s.Path(reader)
+	if !filex.Exists(path) {
+		return nil
+	}
+	if err := WatchFile(path); err != nil {
+		return errorx.Wrap(err, "watch config file failed")
+	}
+	Subscribe(LocalKey(path), func([]byte) { onChange() })
+	return nil
+}
+
+// EnvSource 环境变量配置源，变量名为 QUANX_<文件名>_<字段路径>，
+// 例如database.yaml中的maxIdleConns对应 QUANX_DATABASE_MAX_IDLE_CONNS，多配置时使用下标 QUANX_DATABASE_0_HOST
+type EnvSource struct {
+	prefix string
+	lookup func(key string) (string, bool)
+}
+
+// NewEnvSource 创建环境变量配置源，prefix为空时使用 EnvPrefix
+func NewEnvSource(prefix string) *EnvSource {
+	if prefix == "" {
+		prefix = EnvPrefix
+	}
+	return &EnvSource{prefix: prefix, lookup: os.LookupEnv}
+}
+
+// Prefix 配置对应的环境变量前缀
+func (s *EnvSource) Prefix(reader *Reader) string {
+	var name = readerName(reader)
+	name = strings.TrimSuffix(filepath.Base(name), filepath.Ext(name))
+	return s.prefix + "_" + envName(name)
+}
+
+func (s *EnvSource) Name(reader *Reader) string {
+	return "env@" + s.Prefix(reader) + "_*"
+}
+
+func (s *EnvSource) Get(reader *Reader, v any) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+	var format = formatOf(reader)
+	return s.lookupValue(s.Prefix(reader), reflect.TypeOf(v), format, 0)
+}
+
+func (s *EnvSource) Watch(*Reader, func()) error {
+	return nil
+}
+
+// 按照配置结构查找环境变量，返回配置树
+func (s *EnvSource) lookupValue(key string, typ reflect.Type, format string, depth int) (any, error) {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if depth > 8 {
+		return nil, nil
+	}
+	switch typ.Kind() {
+	case reflect.Struct:
+		var tree = make(map[string]any)
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			name, ok := fieldName(field, format)
+			if !ok {
+				continue
+			}
+			value, err := s.lookupValue(key+"_"+envName(field.Name), field.Type, format, depth+1)
+			if err != nil {
+				return nil, err
+			} else if value != nil {
+				tree[name] = value
+			}
+		}
+		if len(tree) == 0 {
+			return nil, nil
+		}
+		return tree, nil
+	case reflect.Slice, reflect.Array:
+		if elem := typ.Elem(); isScalar(elem) {
+			if value, ok := s.lookup(key); ok {
+				var list []any
+				for _, item := range strings.Split(value, ",") {
+					list = append(list, envValue(strings.TrimSpace(item), elem))
+				}
+				return list, nil
+			}
+			return nil, nil
+		}
+		var list []any
+		for i := 0; ; i++ {
+			value, err := s.lookupValue(key+"_"+strconv.Itoa(i), typ.Elem(), format, depth+1)
+			if err != nil {
+				return nil, err
+			} else if value == nil {
+				break
+			}
+			list = append(list, value)
+		}
+		if len(list) == 0 {
+			return nil, nil
+		}
+		return list, nil
+	case reflect.Map, reflect.Interface, reflect.Func, reflect.Chan:
+		return nil, nil
+	default:
+		if value, ok := s.lookup(key); ok {
+			return envValue(value, typ), nil
+		}
+		return nil, nil
+	}
+}
+
+// 字符串类型字段保持原值，其他类型解析为对应类型
+func envValue(value string, typ reflect.Type) any {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() == reflect.String {
+		return value
+	}
+	return ParseScalar(value)
+}
+
+// MemorySource 内存配置源，以 Reader.FilePath 为key，主要用于测试
+type MemorySource struct {
+	mu       sync.RWMutex
+	name     string
+	data     map[string]any
+	handlers map[string][]func()
+}
+
+// NewMemorySource 创建内存配置源
+func NewMemorySource(name string) *MemorySource {
+	return &MemorySource{
+		name:     name,
+		data:     make(map[string]any),
+		handlers: make(map[string][]func()),
+	}
+}
+
+// Set 设置配置树并通知监听者
+func (s *MemorySource) Set(filePath string, tree any) {
+	s.mu.Lock()
+	s.data[filePath] = tree
+	var handlers = append([]func(){}, s.handlers[filePath]...)
+	s.mu.Unlock()
+	for _, handler := range handlers {
+		handler()
+	}
+}
+
+func (s *MemorySource) Name(reader *Reader) string {
+	return "memory@" + s.name + "@" + reader.FilePath
+}
+
+func (s *MemorySource) Get(reader *Reader, _ any) (any, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data[reader.FilePath], nil
+}
+
+func (s *MemorySource) Watch(reader *Reader, onChange func()) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[reader.FilePath] = append(s.handlers[reader.FilePath], onChange)
+	return nil
+}
+
+// ParseTree 按照文件后缀解析配置内容为配置树
+func ParseTree(name string, content []byte) (any, error) {
+	var tree any
+	switch format := marshalx.Apply(name).Name(); format {
+	case "json", "yml", "yaml", "toml":
+		if err := marshalx.Apply(name).Unmarshal(content, &tree); err != nil {
+			return nil, errorx.Wrap(err, "unmarshal config content failed")
+		}
+	default:
+		return nil, errorx.Errorf("config format is not supported: %s", format)
+	}
+	return normalize(tree), nil
+}
+
+// ParseScalar 解析标量字符串为对应类型（数字/布尔/字符串等）
+func ParseScalar(value string) any {
+	var scalar any
+	if err := yaml.Unmarshal([]byte(value), &scalar); err != nil {
+		return value
+	}
+	switch scalar.(type) {
+	case map[string]any, []any, nil:
+		return value
+	default:
+		return normalize(scalar)
+	}
+}
+
+// 驼峰字段名转换为大写下划线环境变量名
+func envName(name string) string {
+	var sb strings.Builder
+	var runes = []rune(name)
+	for i, r := range runes {
+		switch {
+		case r == '-' || r == '.' || r == ' ':
+			sb.WriteRune('_')
+			continue
+		case r >= 'A' && r <= 'Z':
+			if i > 0 && (runes[i-1] >= 'a' && runes[i-1] <= 'z' || runes[i-1] >= '0' && runes[i-1] <= '9' ||
+				i+1 < len(runes) && runes[i+1] >= 'a' && runes[i+1] <= 'z' && runes[i-1] != '_') {
+				sb.WriteRune('_')
+			}
+		}
+		sb.WriteRune(r)
+	}
+	return strings.ToUpper(sb.String())
+}
+
+// 配置格式对应的结构体标签
+func formatOf(reader *Reader) string {
+	return formatOfName(readerName(reader))
+}
+
+// 配置文件格式对应的结构体标签
+func formatOfName(name string) string {
+	switch format := marshalx.Apply(name).Name(); format {
+	case "yml":
+		return "yaml"
+	default:
+		return format
+	}
+}
+
+// 字段在配置中的名称，优先使用配置格式对应的标签
+func fieldName(field reflect.StructField, format string) (string, bool) {
+	if !field.IsExported() {
+		return "", false
+	}
+	for _, key := range []string{format, "yaml", "json"} {
+		if tag, ok := field.Tag.Lookup(key); ok {
+			name, _, _ := strings.Cut(tag, ",")
+			if name == "-" {
+				return "", false
+			} else if name != "" {
+				return name, true
+			}
+		}
+	}
+	if format == "json" {
+		return field.Name, true
+	}
+	return strings.ToLower(field.Name), true
+}
+
+func isScalar(typ reflect.Type) bool {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	switch typ.Kind() {
+	case reflect.Struct, reflect.Slice, reflect.Array, reflect.Map, reflect.Interface, reflect.Func, reflect.Chan:
+		return false
+	default:
+		return true
+	}
+}
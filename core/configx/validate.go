@@ -0,0 +1,156 @@
+package configx
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/go-xuan/quanx/types/anyx"
+)
+
+// Validator 支持自定义校验的配置器，配置器运行前调用
+type Validator interface {
+	Validate() error // 校验配置，返回不合法的配置项
+}
+
+var _validate = newValidate()
+
+func newValidate() *validator.Validate {
+	var validate = validator.New()
+	// 校验失败项使用配置中的字段名称
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		if name, ok := fieldName(field, "yaml"); ok {
+			return name
+		}
+		return field.Name
+	})
+	return validate
+}
+
+// ValidationError 配置校验错误，包含全部校验失败项
+type ValidationError struct {
+	Fields []*FieldError // 校验失败项
+}
+
+// FieldError 配置项校验失败
+type FieldError struct {
+	Field   string // 配置项路径，例如：type、0.host
+	Message string // 失败描述，例如：must be one of [mysql postgres pgsql], got "oracle"
+}
+
+func (e *FieldError) Error() string {
+	if e.Field == "" {
+		return e.Message
+	}
+	return e.Field + " " + e.Message
+}
+
+func (e *ValidationError) Error() string {
+	var items = make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		items = append(items, field.Error())
+	}
+	return strings.Join(items, "; ")
+}
+
+// Add 添加校验失败项
+func (e *ValidationError) Add(field, message string) {
+	e.Fields = append(e.Fields, &FieldError{Field: field, Message: message})
+}
+
+// Err 存在校验失败项时返回错误，否则返回nil
+func (e *ValidationError) Err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Validate 设置默认值后校验配置，包括结构体validate标签规则（required/oneof/min/max等）以及 Validator.Validate()，
+// 存在校验失败项时返回 *ValidationError
+func Validate(v any) error {
+	var result = &ValidationError{}
+	validateValue(reflect.ValueOf(v), "", result)
+	return result.Err()
+}
+
+func validateValue(value reflect.Value, path string, result *ValidationError) {
+	if !value.IsValid() {
+		return
+	}
+	var ptr = value
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return
+		}
+		ptr, value = value, value.Elem()
+	}
+	switch value.Kind() {
+	case reflect.Struct:
+		if ptr.Kind() == reflect.Ptr {
+			if err := anyx.SetDefaultValue(ptr.Interface()); err != nil {
+				result.Add(path, "set default value failed: "+err.Error())
+			}
+		}
+		if err := _validate.Struct(value.Interface()); err != nil {
+			var fieldErrors validator.ValidationErrors
+			if !errors.As(err, &fieldErrors) {
+				result.Add(path, err.Error())
+			}
+			for _, fieldError := range fieldErrors {
+				result.Add(joinPath(path, fieldPath(fieldError.Namespace())), fieldMessage(fieldError))
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			validateValue(value.Index(i), joinPath(path, strconv.Itoa(i)), result)
+		}
+	}
+	// 自定义校验
+	if ptr.CanInterface() {
+		if custom, ok := ptr.Interface().(Validator); ok {
+			if err := custom.Validate(); err != nil {
+				var invalid *ValidationError
+				if errors.As(err, &invalid) {
+					for _, field := range invalid.Fields {
+						result.Add(joinPath(path, field.Field), field.Message)
+					}
+				} else {
+					result.Add(path, err.Error())
+				}
+			}
+		}
+	}
+}
+
+// 去掉命名空间中的结构体名称，例如 Config.type 转换为 type
+func fieldPath(namespace string) string {
+	if _, path, ok := strings.Cut(namespace, "."); ok {
+		return path
+	}
+	return namespace
+}
+
+// 校验失败描述
+func fieldMessage(fieldError validator.FieldError) string {
+	switch fieldError.Tag() {
+	case "required", "required_if", "required_unless", "required_with", "required_without":
+		return "is required"
+	case "oneof":
+		return fmt.Sprintf("must be one of [%s], got %q", fieldError.Param(), fmt.Sprint(fieldError.Value()))
+	case "min", "gte":
+		return fmt.Sprintf("must be greater than or equal to %s, got %v", fieldError.Param(), fieldError.Value())
+	case "max", "lte":
+		return fmt.Sprintf("must be less than or equal to %s, got %v", fieldError.Param(), fieldError.Value())
+	case "gt":
+		return fmt.Sprintf("must be greater than %s, got %v", fieldError.Param(), fieldError.Value())
+	case "lt":
+		return fmt.Sprintf("must be less than %s, got %v", fieldError.Param(), fieldError.Value())
+	default:
+		return fmt.Sprintf("failed on the '%s' rule, got %v", fieldError.Tag(), fieldError.Value())
+	}
+}
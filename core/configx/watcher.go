@@ -0,0 +1,136 @@
+package configx
+
+import (
+	"crypto/sha256"
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/go-xuan/quanx/os/errorx"
+)
+
+// DefaultWatchInterval 本地配置文件默认轮询间隔
+const DefaultWatchInterval = 2 * time.Second
+
+var _watcher = NewFileWatcher(DefaultWatchInterval)
+
+// FileWatcher 本地配置文件监听器，轮询比较文件修改时间以及内容摘要，
+// 内容变化时通过 Publish() 发布 LocalKey(path) 变更事件
+type FileWatcher struct {
+	mu       sync.Mutex
+	interval time.Duration         // 轮询间隔
+	files    map[string]*fileState // 监听文件
+	stop     chan struct{}         // 停止信号
+}
+
+// 文件状态
+type fileState struct {
+	modTime time.Time
+	size    int64
+	hash    [sha256.Size]byte
+}
+
+func NewFileWatcher(interval time.Duration) *FileWatcher {
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	return &FileWatcher{
+		interval: interval,
+		files:    make(map[string]*fileState),
+	}
+}
+
+// Watch 添加监听文件，首次添加时启动轮询
+func (w *FileWatcher) Watch(path string) error {
+	state, _, err := readFileState(path)
+	if err != nil {
+		return errorx.Wrap(err, "read watched file failed")
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, ok := w.files[path]; !ok {
+		w.files[path] = state
+	}
+	if w.stop == nil {
+		w.stop = make(chan struct{})
+		go w.run(w.stop)
+	}
+	return nil
+}
+
+// Stop 停止轮询，已添加的监听文件保留，再次调用 Watch() 时恢复
+func (w *FileWatcher) Stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stop != nil {
+		close(w.stop)
+		w.stop = nil
+	}
+}
+
+func (w *FileWatcher) run(stop chan struct{}) {
+	var ticker = time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			w.Check()
+		}
+	}
+}
+
+// Check 检查全部监听文件，发布内容发生变化的文件
+func (w *FileWatcher) Check() {
+	w.mu.Lock()
+	var changed = make(map[string][]byte)
+	for path, state := range w.files {
+		info, err := os.Stat(path)
+		if err != nil || (info.ModTime().Equal(state.modTime) && info.Size() == state.size) {
+			continue
+		}
+		next, content, err := readFileState(path)
+		if err != nil {
+			log.WithField("path", path).Error("read watched file failed: ", err)
+			continue
+		}
+		if next.hash != state.hash {
+			changed[path] = content
+		}
+		w.files[path] = next
+	}
+	w.mu.Unlock()
+	for path, content := range changed {
+		log.WithField("path", path).Info("the local config content has changed !!!")
+		Publish(LocalKey(path), content)
+	}
+}
+
+func readFileState(path string) (*fileState, []byte, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &fileState{
+		modTime: info.ModTime(),
+		size:    info.Size(),
+		hash:    sha256.Sum256(content),
+	}, content, nil
+}
+
+// WatchFile 使用默认监听器监听本地配置文件
+func WatchFile(path string) error {
+	return _watcher.Watch(path)
+}
+
+// StopWatch 停止默认监听器
+func StopWatch() {
+	_watcher.Stop()
+}
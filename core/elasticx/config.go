@@ -59,11 +59,21 @@ func (c *Config) Execute() error {
 			}
 			_handler.configs[c.Source] = c
 			_handler.clients[c.Source] = client
+			registerHealthChecker(c.Source, c.Url(), client)
 		}
 	}
 	return nil
 }
 
+// Close 停止当前es客户端
+func (c *Config) Close() error {
+	if c.Enable && _handler != nil {
+		_handler.closeSource(c.Source)
+		log.Info("elastic-search client stopped: ", c.Format())
+	}
+	return nil
+}
+
 func (c *Config) Url() string {
 	return fmt.Sprintf("http://%s:%d", c.Host, c.Port)
 }
@@ -129,6 +139,7 @@ func (m MultiConfig) Execute() error {
 			} else {
 				_handler.clients[c.Source] = client
 				_handler.configs[c.Source] = c
+				registerHealthChecker(c.Source, c.Url(), client)
 				if i == 0 || c.Source == constx.DefaultSource {
 					_handler.client = client
 					_handler.config = c
@@ -141,3 +152,12 @@ func (m MultiConfig) Execute() error {
 	}
 	return nil
 }
+
+func (m MultiConfig) Close() error {
+	for i := len(m) - 1; i >= 0; i-- {
+		if err := m[i].Close(); err != nil {
+			return errorx.Wrap(err, "close elastic failed")
+		}
+	}
+	return nil
+}
@@ -2,15 +2,31 @@ package elasticx
 
 import (
 	"context"
+	"sort"
 
 	"github.com/olivere/elastic/v7"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/go-xuan/quanx/common/constx"
+	"github.com/go-xuan/quanx/core/healthx"
 	"github.com/go-xuan/quanx/os/errorx"
 	"github.com/go-xuan/quanx/os/taskx"
 )
 
+const healthComponent = "elastic"
+
+// 注册es健康检查
+func registerHealthChecker(source, url string, client *elastic.Client) {
+	healthx.Register(healthx.Name(healthComponent, source), func(ctx context.Context) error {
+		if _, code, err := client.Ping(url).Do(ctx); err != nil {
+			return errorx.Wrap(err, "elastic ping failed")
+		} else if code != 200 {
+			return errorx.Errorf("elastic ping status: %d", code)
+		}
+		return nil
+	})
+}
+
 var _handler *Handler
 
 func this() *Handler {
@@ -46,6 +62,34 @@ func (h *Handler) GetClient(source ...string) *elastic.Client {
 	return h.client
 }
 
+// 停止指定es客户端并移除
+func (h *Handler) closeSource(source string) {
+	if client, ok := h.clients[source]; ok {
+		client.Stop()
+		delete(h.clients, source)
+		delete(h.configs, source)
+		healthx.Unregister(healthx.Name(healthComponent, source))
+		if h.config != nil && h.config.Source == source {
+			h.resetDefault()
+		}
+	}
+}
+
+// 默认客户端被关闭后重新选择默认客户端，优先使用default，其次使用名称排序后的第一个，没有剩余时置空
+func (h *Handler) resetDefault() {
+	h.config, h.client = nil, nil
+	var sources = make([]string, 0, len(h.configs))
+	for source := range h.configs {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+	for _, source := range sources {
+		if h.config == nil || source == constx.DefaultSource {
+			h.config, h.client = h.configs[source], h.clients[source]
+		}
+	}
+}
+
 // IsInitialized 是否初始化
 func IsInitialized() bool {
 	return _handler != nil
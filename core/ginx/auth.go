@@ -48,6 +48,9 @@ func AuthCache() cachex.Client {
 func SetSessionUser(ctx *gin.Context, user AuthUser) {
 	ctx.Set(sessionUserKey, user)
 	// token续命
+	if sessionUser, ok := asSessionUser(user); ok {
+		_ = AuthCache().Expire(ctx, accessKeyPrefix+sessionUser.SessionId(), user.Duration())
+	}
 	_ = AuthCache().Expire(ctx, user.Username(), user.Duration())
 }
 
@@ -86,8 +89,16 @@ func SetSecret(secret string) {
 	tokenSecret = secret
 }
 
-// SetToken 生成并缓存token
+// SetToken 生成并缓存token，AuthUser 实现 SessionUser 接口时按照会话策略创建会话，
+// 需要刷新令牌时使用 IssueToken()
 func SetToken(ctx *gin.Context, user AuthUser) (string, error) {
+	if _, ok := user.(SessionUser); ok {
+		if pair, err := IssueToken(ctx, user); err != nil {
+			return "", err
+		} else {
+			return pair.AccessToken, nil
+		}
+	}
 	if token, err := user.NewToken(getSecret()); err != nil {
 		return "", errorx.Wrap(err, "new token error")
 	} else if err = AuthCache().Set(ctx, user.Username(), token, user.Duration()); err != nil {
@@ -97,8 +108,11 @@ func SetToken(ctx *gin.Context, user AuthUser) (string, error) {
 	}
 }
 
-// RemoveToken 移除token
+// RemoveToken 移除token，同时撤销该用户的全部会话
 func RemoveToken(ctx *gin.Context, username string) {
+	if err := RevokeAllSessions(ctx, username); err != nil {
+		Log(ctx).Error("revoke sessions failed: ", err)
+	}
 	AuthCache().Delete(ctx, username)
 }
 
@@ -111,7 +125,11 @@ func authValidateWithToken(ctx *gin.Context, user AuthUser) error {
 	if err := user.ParseToken(token, getSecret()); err != nil {
 		return errorx.Wrap(err, "parse token failed")
 	}
-	if exist := AuthCache().Exist(ctx, user.Username()); !exist {
+	if sessionUser, ok := asSessionUser(user); ok {
+		if err := validateSession(ctx, sessionUser); err != nil {
+			return err
+		}
+	} else if exist := AuthCache().Exist(ctx, user.Username()); !exist {
 		return errorx.New("token has expired")
 	}
 	SetSessionUser(ctx, user)
@@ -134,6 +152,11 @@ func authValidateWithCookie(ctx *gin.Context, user AuthUser) error {
 	if err = user.ParseToken(token, getSecret()); err != nil {
 		return errorx.Wrap(err, "parse token failed")
 	}
+	if sessionUser, ok := asSessionUser(user); ok {
+		if err = validateSession(ctx, sessionUser); err != nil {
+			return err
+		}
+	}
 	SetSessionUser(ctx, user)
 	return nil
 }
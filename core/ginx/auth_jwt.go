@@ -54,26 +54,41 @@ type JwtUser struct {
 	Ip      string `json:"ip"`      // 登录IP
 	Domain  string `json:"domain"`  // 域名
 	TTL     int    `json:"ttl"`     // 有效时长
+
+	Jti             string   `json:"jti,omitempty"`         // 会话ID
+	RoleCodes       []string `json:"roles,omitempty"`       // 角色
+	PermissionCodes []string `json:"permissions,omitempty"` // 直接授予的权限
+
+	Issuer    string           `json:"iss,omitempty"` // 签发者
+	Audience  jwt.ClaimStrings `json:"aud,omitempty"` // 受众
+	ExpiresAt *jwt.NumericDate `json:"exp,omitempty"` // 过期时间
+	IssuedAt  *jwt.NumericDate `json:"iat,omitempty"` // 签发时间
 }
 
+// Valid 校验标准声明，解析token时调用
 func (u *JwtUser) Valid() error {
-	return nil
+	return validateClaims(&jwt.RegisteredClaims{
+		Issuer:    u.Issuer,
+		Audience:  u.Audience,
+		ExpiresAt: u.ExpiresAt,
+		IssuedAt:  u.IssuedAt,
+	}, tokenIssuer, tokenAudience)
 }
 
 func (u *JwtUser) NewToken(secret string) (string, error) {
-	if token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, u).SignedString([]byte(secret)); err != nil {
-		return "", errorx.Wrap(err, "token sign failed")
-	} else {
-		return token, nil
-	}
+	var now = time.Now()
+	u.Issuer = tokenIssuer
+	u.Audience = tokenAudience
+	u.IssuedAt = jwt.NewNumericDate(now)
+	u.ExpiresAt = jwt.NewNumericDate(now.Add(u.Duration()))
+	return signToken(u, secret)
 }
 
 func (u *JwtUser) ParseToken(token, secret string) error {
-	if jwtToken, err := jwt.ParseWithClaims(token, &JwtUser{}, func(*jwt.Token) (any, error) {
-		return []byte(secret), nil
-	}); err != nil {
-		return errorx.Wrap(err, "parse token error")
-	} else if user, ok := jwtToken.Claims.(*JwtUser); ok {
+	var user = &JwtUser{}
+	if err := parseToken(token, secret, user); err != nil {
+		return err
+	} else {
 		u.Id = user.Id
 		u.Account = user.Account
 		u.Name = user.Name
@@ -81,6 +96,13 @@ func (u *JwtUser) ParseToken(token, secret string) error {
 		u.Ip = user.Ip
 		u.Domain = user.Domain
 		u.TTL = user.TTL
+		u.Jti = user.Jti
+		u.RoleCodes = user.RoleCodes
+		u.PermissionCodes = user.PermissionCodes
+		u.Issuer = user.Issuer
+		u.Audience = user.Audience
+		u.ExpiresAt = user.ExpiresAt
+		u.IssuedAt = user.IssuedAt
 	}
 	return nil
 }
@@ -96,3 +118,96 @@ func (u *JwtUser) UserId() int64 {
 func (u *JwtUser) Duration() time.Duration {
 	return time.Duration(intx.IfZero(u.TTL, 3600)) * time.Second
 }
+
+func (u *JwtUser) Roles() []string {
+	return u.RoleCodes
+}
+
+func (u *JwtUser) Permissions() []string {
+	return u.PermissionCodes
+}
+
+func (u *JwtUser) SessionId() string {
+	return u.Jti
+}
+
+func (u *JwtUser) SetSessionId(jti string) {
+	u.Jti = jti
+}
+
+// 校验过期时间以及签发时间时允许的时钟偏差
+const tokenLeeway = time.Minute
+
+var (
+	tokenIssuer   string   // token签发者，可通过 SetTokenClaims() 设置
+	tokenAudience []string // token受众，可通过 SetTokenClaims() 设置
+)
+
+// SetTokenClaims 设置签发token时写入的iss以及aud，解析token时校验iss与签发者一致、aud包含任一受众
+func SetTokenClaims(issuer string, audience ...string) {
+	tokenIssuer, tokenAudience = issuer, audience
+}
+
+// 签名token，设置了签名密钥时使用非对称签名，否则使用secret进行HS256签名
+func signToken(claims jwt.Claims, secret string) (string, error) {
+	if keys := SigningKeys(); keys != nil {
+		return keys.Sign(claims)
+	}
+	if token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret)); err != nil {
+		return "", errorx.Wrap(err, "token sign failed")
+	} else {
+		return token, nil
+	}
+}
+
+// 解析token并校验签名以及声明，签名算法需要与签名方式一致，避免算法混淆攻击
+func parseToken(token, secret string, claims jwt.Claims) error {
+	var keyfunc jwt.Keyfunc
+	var methods []string
+	if keys := SigningKeys(); keys != nil {
+		keyfunc = keys.Keyfunc
+		for _, key := range keys.available() {
+			methods = append(methods, key.Method.Alg())
+		}
+	} else {
+		keyfunc = func(*jwt.Token) (interface{}, error) {
+			return []byte(secret), nil
+		}
+		methods = []string{jwt.SigningMethodHS256.Alg()}
+	}
+	if _, err := jwt.ParseWithClaims(token, claims, keyfunc, jwt.WithValidMethods(methods)); err != nil {
+		return errorx.Wrap(err, "parse token error")
+	}
+	return nil
+}
+
+// 校验标准声明，issuer以及audience为空时不校验
+func validateClaims(claims *jwt.RegisteredClaims, issuer string, audience []string) error {
+	var now = time.Now()
+	if claims.ExpiresAt == nil {
+		return errorx.New("token has no expiration")
+	} else if now.After(claims.ExpiresAt.Add(tokenLeeway)) {
+		return errorx.New("token is expired")
+	}
+	if claims.IssuedAt != nil && claims.IssuedAt.After(now.Add(tokenLeeway)) {
+		return errorx.New("token used before issued")
+	}
+	if claims.NotBefore != nil && claims.NotBefore.After(now.Add(tokenLeeway)) {
+		return errorx.New("token is not valid yet")
+	}
+	if issuer != "" && claims.Issuer != issuer {
+		return errorx.Errorf("token issuer is invalid: %s", claims.Issuer)
+	}
+	if len(audience) > 0 {
+		var matched bool
+		for _, aud := range audience {
+			if matched = claims.VerifyAudience(aud, true); matched {
+				break
+			}
+		}
+		if !matched {
+			return errorx.Errorf("token audience is invalid: %v", claims.Audience)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,374 @@
+package ginx
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+
+	"github.com/go-xuan/quanx/os/errorx"
+	"github.com/go-xuan/quanx/utils/encryptx"
+)
+
+// 默认旧密钥保留时间，应不小于token的最大有效时长
+const defaultKeyGrace = 24 * time.Hour
+
+// 签名密钥，为空时使用 SetSecret() 设置的密钥进行HS256签名
+var tokenKeySet *KeySet
+
+// SetSigningKeys 设置token签名密钥，设置后仅接受由密钥集合中的密钥签名的token
+func SetSigningKeys(keys *KeySet) {
+	tokenKeySet = keys
+}
+
+// SigningKeys token签名密钥，未设置时返回nil
+func SigningKeys() *KeySet {
+	return tokenKeySet
+}
+
+// SigningKey 非对称签名密钥
+type SigningKey struct {
+	Kid        string            // 密钥ID，写入token头部的kid，用于校验时选择密钥
+	Method     jwt.SigningMethod // 签名算法（RS256/ES256/ES384/ES512/EdDSA）
+	PrivateKey crypto.Signer     // 私钥，仅用于校验时为空
+	PublicKey  crypto.PublicKey  // 公钥
+	retireAt   time.Time         // 轮换后停止校验的时间
+}
+
+// NewSigningKey 创建签名密钥，根据私钥类型选择签名算法，kid为空时使用JWK指纹（RFC 7638）
+func NewSigningKey(kid string, privateKey crypto.Signer) (*SigningKey, error) {
+	var method jwt.SigningMethod
+	switch key := privateKey.(type) {
+	case *rsa.PrivateKey:
+		method = jwt.SigningMethodRS256
+	case *ecdsa.PrivateKey:
+		switch key.Curve {
+		case elliptic.P256():
+			method = jwt.SigningMethodES256
+		case elliptic.P384():
+			method = jwt.SigningMethodES384
+		case elliptic.P521():
+			method = jwt.SigningMethodES512
+		default:
+			return nil, errorx.Errorf("unsupported ecdsa curve: %s", key.Curve.Params().Name)
+		}
+	case ed25519.PrivateKey:
+		method = jwt.SigningMethodEdDSA
+	default:
+		return nil, errorx.Errorf("unsupported private key type: %T", privateKey)
+	}
+	var key = &SigningKey{
+		Kid:        kid,
+		Method:     method,
+		PrivateKey: privateKey,
+		PublicKey:  privateKey.Public(),
+	}
+	if key.Kid == "" {
+		jwk, err := key.JWK()
+		if err != nil {
+			return nil, err
+		}
+		key.Kid = jwk.Thumbprint()
+	}
+	return key, nil
+}
+
+// LoadSigningKey 从PEM文件加载私钥，支持RSA（PKCS1/PKCS8）、ECDSA（SEC1/PKCS8）以及Ed25519（PKCS8）
+func LoadSigningKey(kid, path string) (*SigningKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errorx.Wrap(err, "read private key file error")
+	}
+	if key, err := jwt.ParseRSAPrivateKeyFromPEM(data); err == nil {
+		return NewSigningKey(kid, key)
+	}
+	if key, err := jwt.ParseECPrivateKeyFromPEM(data); err == nil {
+		return NewSigningKey(kid, key)
+	}
+	if key, err := jwt.ParseEdPrivateKeyFromPEM(data); err == nil {
+		if signer, ok := key.(crypto.Signer); ok {
+			return NewSigningKey(kid, signer)
+		}
+	}
+	return nil, errorx.Errorf("unsupported private key file: %s", path)
+}
+
+// RSASigningKey 使用 encryptx.RSA() 生成或者加载的RSA密钥作为签名密钥
+func RSASigningKey(kid string) (*SigningKey, error) {
+	return NewSigningKey(kid, encryptx.RSA().PrivateKey())
+}
+
+// JWK 公钥的JWK表示
+func (k *SigningKey) JWK() (*JSONWebKey, error) {
+	var jwk = &JSONWebKey{Kid: k.Kid, Use: "sig"}
+	if k.Method != nil {
+		jwk.Alg = k.Method.Alg()
+	}
+	switch key := k.PublicKey.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64URLEncode(key.N.Bytes())
+		jwk.E = base64URLEncode(big.NewInt(int64(key.E)).Bytes())
+	case *ecdsa.PublicKey:
+		var size = (key.Curve.Params().BitSize + 7) / 8
+		jwk.Kty = "EC"
+		jwk.Crv = key.Curve.Params().Name
+		jwk.X = base64URLEncode(key.X.FillBytes(make([]byte, size)))
+		jwk.Y = base64URLEncode(key.Y.FillBytes(make([]byte, size)))
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64URLEncode(key)
+	default:
+		return nil, errorx.Errorf("unsupported public key type: %T", k.PublicKey)
+	}
+	return jwk, nil
+}
+
+// KeySet 签名密钥集合，使用当前密钥签名，轮换后旧密钥在保留时间内仍可用于校验
+type KeySet struct {
+	mu     sync.RWMutex
+	grace  time.Duration
+	active *SigningKey
+	keys   map[string]*SigningKey
+}
+
+// NewKeySet 创建签名密钥集合，grace为轮换后旧密钥的保留时间，默认24小时
+func NewKeySet(active *SigningKey, grace ...time.Duration) *KeySet {
+	var set = &KeySet{
+		grace:  defaultKeyGrace,
+		active: active,
+		keys:   map[string]*SigningKey{active.Kid: active},
+	}
+	if len(grace) > 0 && grace[0] >= 0 {
+		set.grace = grace[0]
+	}
+	return set
+}
+
+// Rotate 轮换签名密钥，新签发的token使用新密钥，旧密钥在保留时间后不再用于校验
+func (s *KeySet) Rotate(key *SigningKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.active != nil && s.active.Kid != key.Kid {
+		s.active.retireAt = time.Now().Add(s.grace)
+	}
+	s.active = key
+	s.keys[key.Kid] = key
+}
+
+// Active 当前签名密钥
+func (s *KeySet) Active() *SigningKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.active
+}
+
+// Key 获取可用于校验的密钥，不存在或者已超过保留时间时返回nil
+func (s *KeySet) Key(kid string) *SigningKey {
+	s.mu.RLock()
+	var key = s.keys[kid]
+	s.mu.RUnlock()
+	if key == nil || key.retired(time.Now()) {
+		return nil
+	}
+	return key
+}
+
+// 可用于校验的全部密钥，同时清理已超过保留时间的密钥
+func (s *KeySet) available() []*SigningKey {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var now = time.Now()
+	var keys = make([]*SigningKey, 0, len(s.keys))
+	for kid, key := range s.keys {
+		if key.retired(now) {
+			delete(s.keys, kid)
+		} else {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].Kid < keys[j].Kid
+	})
+	return keys
+}
+
+func (k *SigningKey) retired(now time.Time) bool {
+	return !k.retireAt.IsZero() && !now.Before(k.retireAt)
+}
+
+// Sign 使用当前密钥签名
+func (s *KeySet) Sign(claims jwt.Claims) (string, error) {
+	var key = s.Active()
+	if key.PrivateKey == nil {
+		return "", errorx.Errorf("signing key has no private key: %s", key.Kid)
+	}
+	var token = jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.Kid
+	signed, err := token.SignedString(key.PrivateKey)
+	if err != nil {
+		return "", errorx.Wrap(err, "token sign failed")
+	}
+	return signed, nil
+}
+
+// Keyfunc 根据token头部的kid选择校验密钥，签名算法需要与密钥一致
+func (s *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	var key = s.Key(kid)
+	if key == nil {
+		return nil, errorx.Errorf("unknown signing key: %s", kid)
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, errorx.Errorf("unexpected signing method: %s", token.Method.Alg())
+	}
+	return key.PublicKey, nil
+}
+
+// JWKS 可用于校验的全部公钥
+func (s *KeySet) JWKS() (*JWKS, error) {
+	var jwks = &JWKS{Keys: []*JSONWebKey{}}
+	for _, key := range s.available() {
+		jwk, err := key.JWK()
+		if err != nil {
+			return nil, err
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+	return jwks, nil
+}
+
+// JWKSHandler JWKS接口，其他服务可以通过此接口获取公钥校验token，通常注册为 /.well-known/jwks.json
+func JWKSHandler(ctx *gin.Context) {
+	var jwks = &JWKS{Keys: []*JSONWebKey{}}
+	if keys := SigningKeys(); keys != nil {
+		var err error
+		if jwks, err = keys.JWKS(); err != nil {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, jwks)
+}
+
+// JWKS JSON Web Key Set
+type JWKS struct {
+	Keys []*JSONWebKey `json:"keys"`
+}
+
+// Key 根据kid获取公钥，不存在时返回nil
+func (j *JWKS) Key(kid string) *JSONWebKey {
+	for _, key := range j.Keys {
+		if key.Kid == kid {
+			return key
+		}
+	}
+	return nil
+}
+
+// JSONWebKey JSON Web Key，仅包含公钥参数
+type JSONWebKey struct {
+	Kty string `json:"kty"`           // 密钥类型（RSA/EC/OKP）
+	Kid string `json:"kid,omitempty"` // 密钥ID
+	Use string `json:"use,omitempty"` // 用途
+	Alg string `json:"alg,omitempty"` // 签名算法
+	N   string `json:"n,omitempty"`   // RSA模数
+	E   string `json:"e,omitempty"`   // RSA指数
+	Crv string `json:"crv,omitempty"` // 曲线
+	X   string `json:"x,omitempty"`   // EC以及OKP的X坐标
+	Y   string `json:"y,omitempty"`   // EC的Y坐标
+}
+
+// PublicKey 解析公钥
+func (k *JSONWebKey) PublicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64URLDecode(k.N)
+		if err != nil {
+			return nil, errorx.Wrap(err, "decode rsa modulus error")
+		}
+		e, err := base64URLDecode(k.E)
+		if err != nil {
+			return nil, errorx.Wrap(err, "decode rsa exponent error")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, errorx.Errorf("unsupported ecdsa curve: %s", k.Crv)
+		}
+		x, err := base64URLDecode(k.X)
+		if err != nil {
+			return nil, errorx.Wrap(err, "decode ecdsa x error")
+		}
+		y, err := base64URLDecode(k.Y)
+		if err != nil {
+			return nil, errorx.Wrap(err, "decode ecdsa y error")
+		}
+		var key = &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(key.X, key.Y) {
+			return nil, errorx.New("ecdsa point is not on curve")
+		}
+		return key, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, errorx.Errorf("unsupported okp curve: %s", k.Crv)
+		}
+		x, err := base64URLDecode(k.X)
+		if err != nil {
+			return nil, errorx.Wrap(err, "decode ed25519 key error")
+		} else if len(x) != ed25519.PublicKeySize {
+			return nil, errorx.New("invalid ed25519 key size")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, errorx.Errorf("unsupported key type: %s", k.Kty)
+	}
+}
+
+// Thumbprint JWK指纹（RFC 7638）
+func (k *JSONWebKey) Thumbprint() string {
+	var members map[string]string
+	switch k.Kty {
+	case "RSA":
+		members = map[string]string{"e": k.E, "kty": k.Kty, "n": k.N}
+	case "EC":
+		members = map[string]string{"crv": k.Crv, "kty": k.Kty, "x": k.X, "y": k.Y}
+	default:
+		members = map[string]string{"crv": k.Crv, "kty": k.Kty, "x": k.X}
+	}
+	// json编码map时按照KEY排序，符合RFC 7638要求的字典序
+	data, _ := json.Marshal(members)
+	var sum = sha256.Sum256(data)
+	return base64URLEncode(sum[:])
+}
+
+func base64URLEncode(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func base64URLDecode(data string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(data)
+}
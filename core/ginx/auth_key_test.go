@@ -0,0 +1,141 @@
+package ginx
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+func newTestSigningKey(t *testing.T, alg string) *SigningKey {
+	var signer crypto.Signer
+	var err error
+	switch alg {
+	case "RS256":
+		signer, err = rsa.GenerateKey(rand.Reader, 2048)
+	case "ES256":
+		signer, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case "EdDSA":
+		_, signer, err = ed25519.GenerateKey(rand.Reader)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := NewSigningKey("", signer)
+	if err != nil {
+		t.Fatal(err)
+	} else if key.Method.Alg() != alg {
+		t.Fatalf("expected %s, got %s", alg, key.Method.Alg())
+	}
+	return key
+}
+
+func TestSigningKeys(t *testing.T) {
+	defer SetSigningKeys(nil)
+	defer SetTokenClaims("")
+
+	hmacToken, err := (&JwtUser{Phone: "test"}).NewToken(getSecret())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, alg := range []string{"RS256", "ES256", "EdDSA"} {
+		var keys = NewKeySet(newTestSigningKey(t, alg))
+		SetSigningKeys(keys)
+		token, err := (&JwtUser{Phone: "test"}).NewToken(getSecret())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var user = &JwtUser{}
+		if err = user.ParseToken(token, getSecret()); err != nil {
+			t.Errorf("%s: %v", alg, err)
+		} else if user.Username() != "test" || user.ExpiresAt == nil {
+			t.Errorf("%s: unexpected claims %+v", alg, user)
+		}
+		// 设置签名密钥后拒绝使用共享密钥签名的token
+		if err = (&JwtUser{}).ParseToken(hmacToken, getSecret()); err == nil {
+			t.Errorf("%s: hmac token should be rejected", alg)
+		}
+	}
+
+	// 轮换后旧密钥在保留时间内仍可校验
+	var old, next = newTestSigningKey(t, "ES256"), newTestSigningKey(t, "ES256")
+	var keys = NewKeySet(old, time.Hour)
+	SetSigningKeys(keys)
+	oldToken, _ := (&JwtUser{Phone: "test"}).NewToken(getSecret())
+	keys.Rotate(next)
+	newToken, _ := (&JwtUser{Phone: "test"}).NewToken(getSecret())
+	for _, token := range []string{oldToken, newToken} {
+		if err = (&JwtUser{}).ParseToken(token, getSecret()); err != nil {
+			t.Error("token should be valid in grace period:", err)
+		}
+	}
+	keys = NewKeySet(old, 0)
+	SetSigningKeys(keys)
+	keys.Rotate(next)
+	if err = (&JwtUser{}).ParseToken(oldToken, getSecret()); err == nil {
+		t.Error("token of retired key should be rejected")
+	}
+
+	// 标准声明校验
+	var expired = &JwtUser{Phone: "test", ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Hour))}
+	token, _ := keys.Sign(expired)
+	if err = (&JwtUser{}).ParseToken(token, getSecret()); err == nil {
+		t.Error("expired token should be rejected")
+	}
+	SetTokenClaims("quanx", "app")
+	token, _ = (&JwtUser{Phone: "test"}).NewToken(getSecret())
+	if err = (&JwtUser{}).ParseToken(token, getSecret()); err != nil {
+		t.Error(err)
+	}
+	SetTokenClaims("quanx", "other")
+	if err = (&JwtUser{}).ParseToken(token, getSecret()); err == nil {
+		t.Error("token with other audience should be rejected")
+	}
+	SetTokenClaims("other", "app")
+	if err = (&JwtUser{}).ParseToken(token, getSecret()); err == nil {
+		t.Error("token with other issuer should be rejected")
+	}
+}
+
+func TestJWKSHandler(t *testing.T) {
+	defer SetSigningKeys(nil)
+	var keys = NewKeySet(newTestSigningKey(t, "RS256"), time.Hour)
+	keys.Rotate(newTestSigningKey(t, "EdDSA"))
+	SetSigningKeys(keys)
+	token, err := (&JwtUser{Phone: "test"}).NewToken(getSecret())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var router = gin.New()
+	router.GET("/.well-known/jwks.json", JWKSHandler)
+	var recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+	var jwks JWKS
+	if err = json.Unmarshal(recorder.Body.Bytes(), &jwks); err != nil {
+		t.Fatal(err)
+	} else if len(jwks.Keys) != 2 {
+		t.Fatalf("expected 2 keys, got %d", len(jwks.Keys))
+	}
+
+	// 仅使用JWKS中的公钥校验token
+	if _, err = jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
+		var jwk = jwks.Key(token.Header["kid"].(string))
+		if jwk == nil {
+			t.Fatal("kid not found in jwks")
+		}
+		return jwk.PublicKey()
+	}); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,392 @@
+package ginx
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+
+	"github.com/go-xuan/quanx/net/httpx"
+	"github.com/go-xuan/quanx/net/respx"
+	"github.com/go-xuan/quanx/os/errorx"
+)
+
+const (
+	oidcStateKeyPrefix = "oidc:"          // 登录状态，KEY为 oidc:state
+	oidcStateTTL       = 10 * time.Minute // 登录状态有效期，即用户在认证服务完成登录的最长时间
+	oidcJWKSRefresh    = 10 * time.Second // 遇到未知kid时重新获取JWKS的最小间隔
+)
+
+// ID token允许的签名算法，不接受对称签名以及none
+var oidcSigningMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+// OIDCConfig OIDC客户端配置
+type OIDCConfig struct {
+	Issuer       string               // 认证服务地址，用于服务发现以及校验ID token的iss
+	ClientId     string               // 客户端ID，校验ID token的aud
+	ClientSecret string               // 客户端密钥，为空时作为公开客户端仅使用PKCE
+	RedirectURL  string               // 回调地址，需要与认证服务注册的地址一致
+	Scopes       []string             // 授权范围，默认 openid profile email
+	Mapper       OIDCUserMapper       // ID token声明转换为鉴权用户，默认转换为 JwtUser
+	Client       httpx.ClientCategory // 请求认证服务使用的http客户端
+}
+
+// OIDCUserMapper ID token声明转换为鉴权用户
+type OIDCUserMapper func(claims *OIDCClaims) (AuthUser, error)
+
+// OIDCDiscovery OIDC服务发现文档
+type OIDCDiscovery struct {
+	Issuer                string   `json:"issuer"`
+	AuthorizationEndpoint string   `json:"authorization_endpoint"`
+	TokenEndpoint         string   `json:"token_endpoint"`
+	UserinfoEndpoint      string   `json:"userinfo_endpoint"`
+	JwksURI               string   `json:"jwks_uri"`
+	EndSessionEndpoint    string   `json:"end_session_endpoint"`
+	CodeChallengeMethods  []string `json:"code_challenge_methods_supported"`
+}
+
+// OIDCToken 授权码换取的令牌
+type OIDCToken struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+	IdToken      string `json:"id_token"`
+	Error        string `json:"error"`
+	ErrorDesc    string `json:"error_description"`
+}
+
+// OIDCClaims ID token声明
+type OIDCClaims struct {
+	jwt.RegisteredClaims
+	Nonce               string `json:"nonce"`
+	AuthorizedParty     string `json:"azp"`
+	Name                string `json:"name"`
+	PreferredUsername   string `json:"preferred_username"`
+	Email               string `json:"email"`
+	EmailVerified       bool   `json:"email_verified"`
+	PhoneNumber         string `json:"phone_number"`
+	PhoneNumberVerified bool   `json:"phone_number_verified"`
+	Picture             string `json:"picture"`
+
+	Claims map[string]interface{} `json:"-"` // 全部声明，用于读取自定义声明
+}
+
+// Valid 标准声明需要结合客户端配置校验，见 OIDCProvider.VerifyIdToken()
+func (c *OIDCClaims) Valid() error {
+	return nil
+}
+
+// 登录状态，回调时校验
+type oidcState struct {
+	Nonce    string `json:"nonce"`    // 写入ID token，防止重放
+	Verifier string `json:"verifier"` // PKCE code_verifier
+	Redirect string `json:"redirect"` // 登录成功后跳转的地址
+}
+
+// OIDCProvider OIDC认证服务（relying party），使用授权码模式以及PKCE登录
+type OIDCProvider struct {
+	config    *OIDCConfig
+	discovery *OIDCDiscovery
+	mu        sync.Mutex
+	jwks      *JWKS
+	fetchedAt time.Time
+}
+
+// NewOIDCProvider 通过服务发现创建OIDC认证服务
+func NewOIDCProvider(ctx context.Context, config *OIDCConfig) (*OIDCProvider, error) {
+	if config.Issuer == "" || config.ClientId == "" {
+		return nil, errorx.New("oidc issuer and client id are required")
+	}
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{"openid", "profile", "email"}
+	}
+	if config.Mapper == nil {
+		config.Mapper = DefaultOIDCUserMapper
+	}
+	var provider = &OIDCProvider{config: config}
+	var discovery = &OIDCDiscovery{}
+	var uri = strings.TrimSuffix(config.Issuer, "/") + "/.well-known/openid-configuration"
+	if err := provider.getJSON(ctx, uri, discovery); err != nil {
+		return nil, errorx.Wrap(err, "oidc discovery error")
+	}
+	// 发现文档中的issuer需要与配置一致，避免被其他认证服务冒充
+	if discovery.Issuer != config.Issuer {
+		return nil, errorx.Errorf("oidc issuer mismatch: %s", discovery.Issuer)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JwksURI == "" {
+		return nil, errorx.New("oidc discovery document is incomplete")
+	}
+	provider.discovery = discovery
+	return provider, nil
+}
+
+// Discovery 服务发现文档
+func (p *OIDCProvider) Discovery() *OIDCDiscovery {
+	return p.discovery
+}
+
+// AuthCodeURL 认证服务的登录地址
+func (p *OIDCProvider) AuthCodeURL(state, nonce, verifier string) string {
+	var query = url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", p.config.ClientId)
+	query.Set("redirect_uri", p.config.RedirectURL)
+	query.Set("scope", strings.Join(p.config.Scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", pkceChallenge(verifier))
+	query.Set("code_challenge_method", "S256")
+	var endpoint = p.discovery.AuthorizationEndpoint
+	if strings.Contains(endpoint, "?") {
+		return endpoint + "&" + query.Encode()
+	}
+	return endpoint + "?" + query.Encode()
+}
+
+// Exchange 使用授权码换取令牌
+func (p *OIDCProvider) Exchange(ctx context.Context, code, verifier string) (*OIDCToken, error) {
+	var form = url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.config.RedirectURL)
+	form.Set("code_verifier", verifier)
+	if p.config.ClientSecret == "" {
+		form.Set("client_id", p.config.ClientId)
+	}
+	var request = httpx.Post(p.discovery.TokenEndpoint).Context(ctx).Form(form)
+	if p.config.ClientSecret != "" {
+		var credential = url.QueryEscape(p.config.ClientId) + ":" + url.QueryEscape(p.config.ClientSecret)
+		request.SetAuthorization("Basic " + base64.StdEncoding.EncodeToString([]byte(credential)))
+	}
+	resp, err := request.Do(p.config.Client)
+	if err != nil {
+		return nil, errorx.Wrap(err, "oidc token request error")
+	}
+	var token = &OIDCToken{}
+	if err = resp.Unmarshal(token); err != nil {
+		return nil, errorx.Wrap(err, "oidc token response error")
+	} else if token.Error != "" {
+		return nil, errorx.Errorf("oidc token error: %s %s", token.Error, token.ErrorDesc)
+	} else if !resp.StatusOK() {
+		return nil, errorx.Errorf("oidc token request failed: %s", string(resp.Body()))
+	} else if token.IdToken == "" {
+		return nil, errorx.New("oidc token response has no id_token")
+	}
+	return token, nil
+}
+
+// VerifyIdToken 校验ID token的签名、iss、aud、exp以及nonce
+func (p *OIDCProvider) VerifyIdToken(ctx context.Context, idToken, nonce string) (*OIDCClaims, error) {
+	var claims = &OIDCClaims{}
+	token, err := jwt.ParseWithClaims(idToken, claims, func(token *jwt.Token) (interface{}, error) {
+		return p.publicKey(ctx, token)
+	}, jwt.WithValidMethods(oidcSigningMethods))
+	if err != nil {
+		return nil, errorx.Wrap(err, "parse id token error")
+	}
+	if err = validateClaims(&claims.RegisteredClaims, p.config.Issuer, []string{p.config.ClientId}); err != nil {
+		return nil, err
+	}
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != p.config.ClientId {
+		return nil, errorx.Errorf("id token authorized party is invalid: %s", claims.AuthorizedParty)
+	}
+	if claims.Nonce != nonce {
+		return nil, errorx.New("id token nonce is invalid")
+	}
+	if claims.Subject == "" {
+		return nil, errorx.New("id token has no subject")
+	}
+	if segments := strings.Split(token.Raw, "."); len(segments) == 3 {
+		if payload, err := jwt.DecodeSegment(segments[1]); err == nil {
+			_ = json.Unmarshal(payload, &claims.Claims)
+		}
+	}
+	return claims, nil
+}
+
+// 根据kid选择认证服务的公钥，未知kid时重新获取JWKS以支持认证服务轮换密钥
+func (p *OIDCProvider) publicKey(ctx context.Context, token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var jwk = p.lookupKey(kid)
+	if jwk == nil && time.Since(p.fetchedAt) >= oidcJWKSRefresh {
+		var jwks = &JWKS{}
+		if err := p.getJSON(ctx, p.discovery.JwksURI, jwks); err != nil {
+			return nil, errorx.Wrap(err, "get oidc jwks error")
+		}
+		p.jwks, p.fetchedAt = jwks, time.Now()
+		jwk = p.lookupKey(kid)
+	}
+	if jwk == nil {
+		return nil, errorx.Errorf("unknown oidc signing key: %s", kid)
+	} else if jwk.Alg != "" && jwk.Alg != token.Method.Alg() {
+		return nil, errorx.Errorf("unexpected signing method: %s", token.Method.Alg())
+	}
+	return jwk.PublicKey()
+}
+
+func (p *OIDCProvider) lookupKey(kid string) *JSONWebKey {
+	if p.jwks == nil {
+		return nil
+	} else if kid == "" && len(p.jwks.Keys) == 1 {
+		return p.jwks.Keys[0]
+	}
+	return p.jwks.Key(kid)
+}
+
+func (p *OIDCProvider) getJSON(ctx context.Context, uri string, v any) error {
+	resp, err := httpx.Get(uri).Context(ctx).Do(p.config.Client)
+	if err != nil {
+		return err
+	} else if !resp.StatusOK() {
+		return errorx.Errorf("request %s failed: %s", uri, string(resp.Body()))
+	}
+	return resp.Unmarshal(v)
+}
+
+// LoginHandler 跳转到认证服务登录，redirect参数为登录成功后跳转的站内地址
+func (p *OIDCProvider) LoginHandler(ctx *gin.Context) {
+	var state = &oidcState{Redirect: ctx.Query("redirect")}
+	// 仅允许站内跳转，避免开放重定向
+	if !strings.HasPrefix(state.Redirect, "/") || strings.HasPrefix(state.Redirect, "//") || strings.HasPrefix(state.Redirect, "/\\") {
+		state.Redirect = ""
+	}
+	var key, err = randomString()
+	if err == nil {
+		state.Nonce, err = randomString()
+	}
+	if err == nil {
+		state.Verifier, err = randomString()
+	}
+	if err != nil {
+		respx.Error(ctx, err.Error())
+		return
+	}
+	if err = AuthCache().Set(ctx, oidcStateKeyPrefix+key, state, oidcStateTTL); err != nil {
+		respx.Error(ctx, errorx.Wrap(err, "save oidc state error").Error())
+		return
+	}
+	// state同时写入cookie，回调时校验发起登录与完成登录的是同一浏览器
+	ctx.SetCookie(oidcStateKey, key, int(oidcStateTTL.Seconds()), "", "", false, true)
+	ctx.Redirect(http.StatusFound, p.AuthCodeURL(key, state.Nonce, state.Verifier))
+}
+
+// CallbackHandler 认证服务登录回调，校验ID token后签发本系统token并设置身份验证cookie
+func (p *OIDCProvider) CallbackHandler(ctx *gin.Context) {
+	user, redirect, err := p.callback(ctx)
+	if err != nil {
+		Log(ctx).Warn("oidc login failed: ", err)
+		respx.Forbidden(ctx, err)
+		return
+	}
+	token, err := SetToken(ctx, user)
+	if err != nil {
+		respx.Error(ctx, err.Error())
+		return
+	}
+	SetAuthCookie(ctx, user.Username(), int(user.Duration().Seconds()))
+	if ctx.IsAborted() {
+		return
+	}
+	if redirect != "" {
+		ctx.Redirect(http.StatusFound, redirect)
+		return
+	}
+	respx.Success(ctx, &OIDCLogin{Token: token, Username: user.Username()})
+}
+
+// OIDCLogin 登录结果
+type OIDCLogin struct {
+	Token    string `json:"token"`    // 本系统签发的token
+	Username string `json:"username"` // 用户名
+}
+
+func (p *OIDCProvider) callback(ctx *gin.Context) (AuthUser, string, error) {
+	if e := ctx.Query("error"); e != "" {
+		return nil, "", errorx.Errorf("oidc authorization error: %s %s", e, ctx.Query("error_description"))
+	}
+	var key, code = ctx.Query("state"), ctx.Query("code")
+	if key == "" || code == "" {
+		return nil, "", errorx.New("oidc state and code are required")
+	}
+	if cookie, err := ctx.Cookie(oidcStateKey); err != nil || cookie != key {
+		return nil, "", errorx.New("oidc state mismatch")
+	}
+	ctx.SetCookie(oidcStateKey, "", -1, "", "", false, true)
+	// 登录状态仅可使用一次
+	var state = &oidcState{}
+	var cache = AuthCache()
+	if !cache.Get(ctx, oidcStateKeyPrefix+key, state) || cache.Delete(ctx, oidcStateKeyPrefix+key) == 0 {
+		return nil, "", errorx.New("oidc state is invalid or expired")
+	}
+	token, err := p.Exchange(ctx.Request.Context(), code, state.Verifier)
+	if err != nil {
+		return nil, "", err
+	}
+	claims, err := p.VerifyIdToken(ctx.Request.Context(), token.IdToken, state.Nonce)
+	if err != nil {
+		return nil, "", err
+	}
+	user, err := p.config.Mapper(claims)
+	if err != nil {
+		return nil, "", errorx.Wrap(err, "map oidc user error")
+	}
+	return user, state.Redirect, nil
+}
+
+// NewOIDCApi 注册OIDC登录接口
+func NewOIDCApi(group *gin.RouterGroup, provider *OIDCProvider) {
+	group.GET("login", provider.LoginHandler)       // 跳转到认证服务登录
+	group.GET("callback", provider.CallbackHandler) // 认证服务登录回调
+}
+
+// OIDCIdentity 认证服务中用户的唯一标识，由issuer以及sub组成，sub仅在同一issuer内唯一
+func OIDCIdentity(claims *OIDCClaims) string {
+	return claims.Issuer + "#" + claims.Subject
+}
+
+// DefaultOIDCUserMapper 默认用户转换，用户名使用 OIDCIdentity()，
+// 账号优先使用已验证的邮箱，其次使用已验证的手机号，preferred_username 等未验证且不唯一的声明不作为用户标识
+func DefaultOIDCUserMapper(claims *OIDCClaims) (AuthUser, error) {
+	if claims.Issuer == "" || claims.Subject == "" {
+		return nil, errorx.New("id token has no issuer or subject")
+	}
+	var identity = OIDCIdentity(claims)
+	var account = identity
+	if claims.Email != "" && claims.EmailVerified {
+		account = claims.Email
+	} else if claims.PhoneNumber != "" && claims.PhoneNumberVerified {
+		account = claims.PhoneNumber
+	}
+	return &JwtUser{
+		Account: account,
+		Name:    claims.Name,
+		Phone:   identity,
+	}, nil
+}
+
+// 生成随机字符串，用于state、nonce以及PKCE code_verifier
+func randomString() (string, error) {
+	var bytes = make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", errorx.Wrap(err, "generate random string error")
+	}
+	return base64URLEncode(bytes), nil
+}
+
+// PKCE code_challenge（S256）
+func pkceChallenge(verifier string) string {
+	var sum = sha256.Sum256([]byte(verifier))
+	return base64URLEncode(sum[:])
+}
@@ -0,0 +1,190 @@
+package ginx
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+
+	"github.com/go-xuan/quanx/core/cachex"
+)
+
+// 本地模拟的OIDC认证服务
+type stubOIDCServer struct {
+	*httptest.Server
+	keys      *KeySet
+	challenge string // 授权请求的code_challenge
+	nonce     string // 授权请求的nonce
+}
+
+func newStubOIDCServer(t *testing.T) *stubOIDCServer {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := NewSigningKey("stub", privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stub = &stubOIDCServer{keys: NewKeySet(key)}
+	var mux = http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(&OIDCDiscovery{
+			Issuer:                stub.URL,
+			AuthorizationEndpoint: stub.URL + "/authorize",
+			TokenEndpoint:         stub.URL + "/token",
+			JwksURI:               stub.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		jwks, _ := stub.keys.JWKS()
+		_ = json.NewEncoder(w).Encode(jwks)
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		var id, secret, _ = r.BasicAuth()
+		if id != "app" || secret != "secret" || r.FormValue("code") != "code" ||
+			pkceChallenge(r.FormValue("code_verifier")) != stub.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(&OIDCToken{Error: "invalid_grant"})
+			return
+		}
+		var now = time.Now()
+		idToken, _ := stub.keys.Sign(&OIDCClaims{
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer:    stub.URL,
+				Subject:   "10001",
+				Audience:  jwt.ClaimStrings{"app"},
+				IssuedAt:  jwt.NewNumericDate(now),
+				ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+			},
+			Nonce:             stub.nonce,
+			Name:              "Test",
+			PreferredUsername: "test",
+			Email:             "test@example.com",
+		})
+		_ = json.NewEncoder(w).Encode(&OIDCToken{AccessToken: "access", TokenType: "Bearer", IdToken: idToken})
+	})
+	stub.Server = httptest.NewServer(mux)
+	return stub
+}
+
+func TestOIDCProvider(t *testing.T) {
+	authCacheClient = (&cachex.Config{Type: cachex.CacheTypeLocal, Source: "auth", Prefix: "auth", Marshal: "json"}).InitClient()
+	defer func() { authCacheClient = nil }()
+	var stub = newStubOIDCServer(t)
+	defer stub.Close()
+
+	if _, err := NewOIDCProvider(context.Background(), &OIDCConfig{Issuer: stub.URL + "/other", ClientId: "app"}); err == nil {
+		t.Error("discovery of other issuer should fail")
+	}
+	provider, err := NewOIDCProvider(context.Background(), &OIDCConfig{
+		Issuer:       stub.URL,
+		ClientId:     "app",
+		ClientSecret: "secret",
+		RedirectURL:  "http://localhost/oidc/callback",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var router = gin.New()
+	NewOIDCApi(router.Group("oidc"), provider)
+
+	// 跳转到认证服务登录
+	var recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/oidc/login?redirect=/home", nil))
+	if recorder.Code != http.StatusFound {
+		t.Fatalf("expected redirect, got %d", recorder.Code)
+	}
+	location, _ := url.Parse(recorder.Header().Get("Location"))
+	var query = location.Query()
+	if query.Get("code_challenge_method") != "S256" || query.Get("client_id") != "app" {
+		t.Errorf("unexpected authorization url: %s", location)
+	}
+	stub.challenge, stub.nonce = query.Get("code_challenge"), query.Get("nonce")
+	var stateCookie = recorder.Result().Cookies()[0]
+
+	var callback = func(state string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		var recorder = httptest.NewRecorder()
+		var req = httptest.NewRequest(http.MethodGet, "/oidc/callback?code=code&state="+url.QueryEscape(state), nil)
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		router.ServeHTTP(recorder, req)
+		return recorder
+	}
+	// 未携带登录时写入的state cookie
+	if recorder = callback(query.Get("state"), nil); recorder.Code != http.StatusForbidden {
+		t.Errorf("callback without state cookie: expected 403, got %d", recorder.Code)
+	}
+	if recorder = callback(query.Get("state"), stateCookie); recorder.Code != http.StatusFound {
+		t.Fatalf("callback: expected redirect, got %d %s", recorder.Code, recorder.Body.String())
+	} else if recorder.Header().Get("Location") != "/home" {
+		t.Errorf("unexpected redirect: %s", recorder.Header().Get("Location"))
+	}
+	// state仅可使用一次
+	if recorder = callback(query.Get("state"), stateCookie); recorder.Code != http.StatusForbidden {
+		t.Errorf("replayed callback: expected 403, got %d", recorder.Code)
+	}
+	// 用户名使用issuer以及sub，不使用未验证的preferred_username以及email
+	if sessions, _ := ListSessions(newSessionTestContext(""), stub.URL+"#10001"); len(sessions) != 1 {
+		t.Errorf("expected 1 session, got %d", len(sessions))
+	}
+	if sessions, _ := ListSessions(newSessionTestContext(""), "test"); len(sessions) != 0 {
+		t.Errorf("unverified preferred_username should not be used as username, got %d sessions", len(sessions))
+	}
+
+	// ID token的nonce不一致
+	stub.nonce = "other"
+	if _, err = provider.VerifyIdToken(context.Background(), mustExchange(t, provider, stub), "nonce"); err == nil {
+		t.Error("id token with other nonce should be rejected")
+	}
+}
+
+func mustExchange(t *testing.T, provider *OIDCProvider, stub *stubOIDCServer) string {
+	var verifier = "verifier"
+	stub.challenge = pkceChallenge(verifier)
+	token, err := provider.Exchange(context.Background(), "code", verifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token.IdToken
+}
+
+func TestDefaultOIDCUserMapper(t *testing.T) {
+	var claims = &OIDCClaims{
+		RegisteredClaims: jwt.RegisteredClaims{Issuer: "https://idp", Subject: "10001"},
+		Email:            "test@example.com",
+		PhoneNumber:      "13800000000",
+	}
+	var mapped = func() *JwtUser {
+		user, err := DefaultOIDCUserMapper(claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return user.(*JwtUser)
+	}
+	// 邮箱以及手机号未验证时仅使用issuer以及sub
+	if user := mapped(); user.Username() != "https://idp#10001" || user.Account != "https://idp#10001" {
+		t.Errorf("unexpected user: %+v", user)
+	}
+	claims.PhoneNumberVerified = true
+	if user := mapped(); user.Username() != "https://idp#10001" || user.Account != "13800000000" {
+		t.Errorf("unexpected user: %+v", user)
+	}
+	claims.EmailVerified = true
+	if user := mapped(); user.Username() != "https://idp#10001" || user.Account != "test@example.com" {
+		t.Errorf("unexpected user: %+v", user)
+	}
+	claims.Subject = ""
+	if _, err := DefaultOIDCUserMapper(claims); err == nil {
+		t.Error("claims without subject should be rejected")
+	}
+}
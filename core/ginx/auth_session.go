@@ -0,0 +1,414 @@
+package ginx
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/go-xuan/quanx/net/respx"
+	"github.com/go-xuan/quanx/os/errorx"
+	"github.com/go-xuan/quanx/types/slicex"
+	"github.com/go-xuan/quanx/utils/idx"
+)
+
+const (
+	accessKeyPrefix      = "access:"        // 访问令牌，值为用户名，过期时间为访问令牌有效期，每次请求续期
+	sessionKeyPrefix     = "session:"       // 会话，KEY为 session:用户名:会话ID，过期时间为刷新令牌有效期
+	sessionsKeyPrefix    = "sessions:"      // 用户的会话索引，值为按照登录顺序排列的会话ID
+	sessionsLockPrefix   = "sessions-lock:" // 会话索引的更新锁
+	refreshKeyPrefix     = "refresh:"       // 刷新令牌，KEY为刷新令牌的摘要，值为会话
+	refreshUsedKeyPrefix = "refresh-used:"  // 已使用的刷新令牌摘要
+	revokedKeyPrefix     = "revoked:"       // 已撤销的会话ID黑名单
+)
+
+// 会话索引更新锁的过期时间以及获取锁的重试间隔、重试次数
+const (
+	sessionsLockTTL     = 5 * time.Second
+	sessionsLockBackoff = 10 * time.Millisecond
+	sessionsLockRetries = 300
+)
+
+// 默认会话策略：单会话，刷新令牌有效期7天
+var sessionPolicy = SessionPolicy{RefreshTTL: 7 * 24 * time.Hour}
+
+// SessionPolicy 会话策略
+type SessionPolicy struct {
+	Multi       bool          // 是否允许多端同时登录，false时新的登录撤销该用户的其他全部会话
+	MaxSessions int           // 多端登录时的最大会话数，超出时撤销最早的会话，0表示不限制
+	RefreshTTL  time.Duration // 刷新令牌有效期
+}
+
+// SetSessionPolicy 设置会话策略
+func SetSessionPolicy(policy SessionPolicy) {
+	if policy.RefreshTTL <= 0 {
+		policy.RefreshTTL = 7 * 24 * time.Hour
+	}
+	sessionPolicy = policy
+}
+
+// SessionUser 支持多端会话的鉴权用户，会话ID（即JTI）写入访问令牌，
+// AuthUser 未实现此接口时每个用户仅保存一个token
+type SessionUser interface {
+	AuthUser
+	SessionId() string       // 会话ID
+	SetSessionId(jti string) // 设置会话ID
+}
+
+// TokenPair 访问令牌以及刷新令牌
+type TokenPair struct {
+	AccessToken      string `json:"accessToken"`      // 访问令牌
+	RefreshToken     string `json:"refreshToken"`     // 刷新令牌，仅可使用一次，刷新后返回新的刷新令牌
+	ExpiresIn        int64  `json:"expiresIn"`        // 访问令牌有效期（秒）
+	RefreshExpiresIn int64  `json:"refreshExpiresIn"` // 刷新令牌有效期（秒）
+	SessionId        string `json:"sessionId"`        // 会话ID
+}
+
+// Session 登录会话
+type Session struct {
+	Id          string `json:"id"`          // 会话ID
+	Username    string `json:"username"`    // 用户名
+	Device      string `json:"device"`      // 登录设备
+	ClientIp    string `json:"clientIp"`    // 登录IP
+	CreatedAt   int64  `json:"createdAt"`   // 登录时间（秒）
+	RefreshedAt int64  `json:"refreshedAt"` // 最近刷新时间（秒）
+}
+
+// 缓存中保存的会话
+type sessionData struct {
+	Session Session `json:"session"`
+	User    []byte  `json:"user"`    // 用户信息，刷新时用于重新生成访问令牌
+	Refresh string  `json:"refresh"` // 当前刷新令牌的摘要
+}
+
+func sessionKey(username, jti string) string {
+	return sessionKeyPrefix + username + ":" + jti
+}
+
+// 刷新令牌仅保存摘要，避免缓存数据泄露后被直接使用
+func refreshKey(token string) string {
+	var sum = sha256.Sum256([]byte(token))
+	return refreshKeyPrefix + hex.EncodeToString(sum[:])
+}
+
+// 生成随机的刷新令牌
+func newRefreshToken() (string, error) {
+	var bytes = make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", errorx.Wrap(err, "generate refresh token error")
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+// 登录设备，优先使用请求头中的设备ID，其次使用User-Agent
+func requestDevice(ctx *gin.Context) string {
+	if device := ctx.GetHeader(deviceHeaderKey); device != "" {
+		return device
+	}
+	return ctx.Request.UserAgent()
+}
+
+// IssueToken 登录成功后创建会话并签发访问令牌以及刷新令牌，AuthUser 需要实现 SessionUser 接口，
+// 单会话策略下撤销该用户的其他全部会话
+func IssueToken(ctx *gin.Context, user AuthUser) (*TokenPair, error) {
+	sessionUser, ok := user.(SessionUser)
+	if !ok {
+		return nil, errorx.Errorf("auth user does not support session: %T", user)
+	}
+	var username = user.Username()
+	if !sessionPolicy.Multi {
+		if err := RevokeAllSessions(ctx, username); err != nil {
+			return nil, err
+		}
+	}
+	sessionUser.SetSessionId(idx.UUID())
+	var now = time.Now().Unix()
+	var data = &sessionData{Session: Session{
+		Id:          sessionUser.SessionId(),
+		Username:    username,
+		Device:      requestDevice(ctx),
+		ClientIp:    ClientIP(ctx),
+		CreatedAt:   now,
+		RefreshedAt: now,
+	}}
+	pair, err := issueTokenPair(ctx, sessionUser, data)
+	if err != nil {
+		return nil, err
+	}
+	if err = addSessionIndex(ctx, username, sessionUser.SessionId()); err != nil {
+		return nil, err
+	}
+	if sessionPolicy.Multi && sessionPolicy.MaxSessions > 0 {
+		if err = limitSessions(ctx, username, sessionUser.SessionId(), sessionPolicy.MaxSessions); err != nil {
+			return nil, err
+		}
+	}
+	return pair, nil
+}
+
+// 签发访问令牌以及刷新令牌，并保存会话
+func issueTokenPair(ctx *gin.Context, user SessionUser, data *sessionData) (*TokenPair, error) {
+	var cache = AuthCache()
+	var jti = user.SessionId()
+	token, err := user.NewToken(getSecret())
+	if err != nil {
+		return nil, errorx.Wrap(err, "new token error")
+	}
+	refresh, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	if data.User, err = json.Marshal(user); err != nil {
+		return nil, errorx.Wrap(err, "marshal auth user error")
+	}
+	data.Refresh = refreshKey(refresh)
+	var refreshTTL = sessionPolicy.RefreshTTL
+	if err = cache.Set(ctx, sessionKey(data.Session.Username, jti), data, refreshTTL); err != nil {
+		return nil, errorx.Wrap(err, "save session to cache error")
+	}
+	if err = cache.Set(ctx, data.Refresh, &data.Session, refreshTTL); err != nil {
+		return nil, errorx.Wrap(err, "save refresh token to cache error")
+	}
+	if err = cache.Set(ctx, accessKeyPrefix+jti, data.Session.Username, user.Duration()); err != nil {
+		return nil, errorx.Wrap(err, "save token to cache error")
+	}
+	// 兼容cookie鉴权，保存用户最近一次签发的访问令牌
+	if err = cache.Set(ctx, data.Session.Username, token, user.Duration()); err != nil {
+		return nil, errorx.Wrap(err, "save token to cache error")
+	}
+	return &TokenPair{
+		AccessToken:      token,
+		RefreshToken:     refresh,
+		ExpiresIn:        int64(user.Duration().Seconds()),
+		RefreshExpiresIn: int64(refreshTTL.Seconds()),
+		SessionId:        jti,
+	}, nil
+}
+
+// RefreshToken 使用刷新令牌签发新的访问令牌以及刷新令牌，旧的刷新令牌立即失效，
+// user用于接收会话中保存的用户信息，类型需要与登录时一致
+func RefreshToken(ctx *gin.Context, refreshToken string, user SessionUser) (*TokenPair, error) {
+	var cache = AuthCache()
+	var key = refreshKey(refreshToken)
+	var session Session
+	if !cache.Get(ctx, key, &session) {
+		return nil, errorx.New("refresh token is invalid or expired")
+	}
+	// 刷新令牌仅可使用一次，通过SetNX原子地标记为已使用，并发使用时仅一个请求成功
+	if ok, err := cache.SetNX(ctx, refreshUsedKeyPrefix+strings.TrimPrefix(key, refreshKeyPrefix), true, sessionPolicy.RefreshTTL); err != nil {
+		return nil, errorx.Wrap(err, "mark refresh token used error")
+	} else if !ok {
+		return nil, errorx.New("refresh token has been used")
+	}
+	cache.Delete(ctx, key)
+	if cache.Exist(ctx, revokedKeyPrefix+session.Id) {
+		return nil, errorx.New("session has been revoked")
+	}
+	var data sessionData
+	if !cache.Get(ctx, sessionKey(session.Username, session.Id), &data) || data.Refresh != key {
+		return nil, errorx.New("session is invalid or expired")
+	}
+	if err := json.Unmarshal(data.User, user); err != nil {
+		return nil, errorx.Wrap(err, "unmarshal auth user error")
+	}
+	user.SetSessionId(session.Id)
+	data.Session.RefreshedAt = time.Now().Unix()
+	return issueTokenPair(ctx, user, &data)
+}
+
+// RefreshHandler 刷新令牌接口，请求体为 {"refreshToken": "..."}，newUser返回用于接收用户信息的空对象
+func RefreshHandler(newUser func() SessionUser) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var form struct {
+			RefreshToken string `json:"refreshToken" binding:"required"`
+		}
+		if err := ctx.ShouldBindJSON(&form); err != nil {
+			respx.ParamError(ctx, err)
+			return
+		}
+		if pair, err := RefreshToken(ctx, form.RefreshToken, newUser()); err != nil {
+			respx.Forbidden(ctx, err)
+		} else {
+			respx.Success(ctx, pair)
+		}
+	}
+}
+
+// ListSessions 获取用户的全部会话，按照登录时间排序
+func ListSessions(ctx *gin.Context, username string) ([]*Session, error) {
+	var cache = AuthCache()
+	var jtis []string
+	cache.Get(ctx, sessionsKeyPrefix+username, &jtis)
+	var sessions = make([]*Session, 0, len(jtis))
+	for _, jti := range jtis {
+		var data sessionData
+		if cache.Get(ctx, sessionKey(username, jti), &data) {
+			sessions = append(sessions, &data.Session)
+		}
+	}
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return sessions[i].CreatedAt < sessions[j].CreatedAt
+	})
+	return sessions, nil
+}
+
+// RevokeSession 撤销会话，会话ID加入黑名单，访问令牌以及刷新令牌立即失效
+func RevokeSession(ctx *gin.Context, username, jti string) error {
+	if err := revokeSession(ctx, username, jti); err != nil {
+		return err
+	}
+	return removeSessionIndex(ctx, username, jti)
+}
+
+func revokeSession(ctx *gin.Context, username, jti string) error {
+	var cache = AuthCache()
+	var data sessionData
+	var key = sessionKey(username, jti)
+	if !cache.Get(ctx, key, &data) {
+		return nil
+	}
+	if err := cache.Set(ctx, revokedKeyPrefix+jti, true, sessionPolicy.RefreshTTL); err != nil {
+		return errorx.Wrap(err, "save revoked session error")
+	}
+	cache.Delete(ctx, key, data.Refresh, accessKeyPrefix+jti)
+	return nil
+}
+
+// RevokeAllSessions 撤销用户的全部会话
+func RevokeAllSessions(ctx *gin.Context, username string) error {
+	sessions, err := ListSessions(ctx, username)
+	if err != nil {
+		return err
+	}
+	var jtis = make([]string, 0, len(sessions))
+	for _, session := range sessions {
+		if err = revokeSession(ctx, username, session.Id); err != nil {
+			return err
+		}
+		jtis = append(jtis, session.Id)
+	}
+	if err = removeSessionIndex(ctx, username, jtis...); err != nil {
+		return err
+	}
+	AuthCache().Delete(ctx, username)
+	return nil
+}
+
+// 会话ID加入用户的会话索引，同时移除已过期的会话
+func addSessionIndex(ctx *gin.Context, username, jti string) error {
+	return updateSessionIndex(ctx, username, func(jtis []string) []string {
+		return append(jtis, jti)
+	})
+}
+
+// 从用户的会话索引中移除会话ID
+func removeSessionIndex(ctx *gin.Context, username string, removed ...string) error {
+	return updateSessionIndex(ctx, username, func(jtis []string) []string {
+		var result = jtis[:0]
+		for _, jti := range jtis {
+			if !slicex.Contains(removed, jti) {
+				result = append(result, jti)
+			}
+		}
+		return result
+	})
+}
+
+// 持有会话索引更新锁时修改用户的会话索引，已过期的会话在修改前移除
+func updateSessionIndex(ctx *gin.Context, username string, update func([]string) []string) error {
+	unlock, err := lockSessionIndex(ctx, username)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	var cache = AuthCache()
+	var key = sessionsKeyPrefix + username
+	var jtis, alive []string
+	cache.Get(ctx, key, &jtis)
+	for _, jti := range jtis {
+		if cache.Exist(ctx, sessionKey(username, jti)) {
+			alive = append(alive, jti)
+		}
+	}
+	if alive = update(alive); len(alive) == 0 {
+		cache.Delete(ctx, key)
+		return nil
+	}
+	if err = cache.Set(ctx, key, alive, sessionPolicy.RefreshTTL); err != nil {
+		return errorx.Wrap(err, "save session index error")
+	}
+	return nil
+}
+
+// 获取会话索引更新锁，基于缓存的SetNX实现，适用于全部缓存类型，返回释放锁的函数
+func lockSessionIndex(ctx *gin.Context, username string) (func(), error) {
+	var cache = AuthCache()
+	var key, token = sessionsLockPrefix + username, idx.UUID()
+	for i := 0; i < sessionsLockRetries; i++ {
+		if ok, err := cache.SetNX(ctx, key, token, sessionsLockTTL); err != nil {
+			return nil, errorx.Wrap(err, "lock session index error")
+		} else if ok {
+			return func() {
+				// 锁已过期并被其他请求获取时不释放
+				var holder string
+				if cache.Get(ctx, key, &holder) && holder == token {
+					cache.Delete(ctx, key)
+				}
+			}, nil
+		}
+		time.Sleep(sessionsLockBackoff)
+	}
+	return nil, errorx.New("lock session index timeout")
+}
+
+// 会话数超出上限时撤销最早的会话，当前会话保留
+func limitSessions(ctx *gin.Context, username, current string, max int) error {
+	sessions, err := ListSessions(ctx, username)
+	if err != nil {
+		return err
+	}
+	var exceed = len(sessions) - max
+	for i := 0; i < len(sessions) && exceed > 0; i++ {
+		if sessions[i].Id == current {
+			continue
+		}
+		if err = RevokeSession(ctx, username, sessions[i].Id); err != nil {
+			return err
+		}
+		exceed--
+	}
+	return nil
+}
+
+// 校验会话是否有效
+func validateSession(ctx *gin.Context, user SessionUser) error {
+	var cache = AuthCache()
+	var jti = user.SessionId()
+	if cache.Exist(ctx, revokedKeyPrefix+jti) {
+		return errorx.New("token has been revoked")
+	}
+	var username string
+	if !cache.Get(ctx, accessKeyPrefix+jti, &username) || username != user.Username() {
+		return errorx.New("token has expired")
+	}
+	return nil
+}
+
+// 是否为携带会话ID的用户
+func asSessionUser(user AuthUser) (SessionUser, bool) {
+	var sessionUser, ok = user.(SessionUser)
+	if ok && sessionUser.SessionId() != "" {
+		return sessionUser, true
+	}
+	return nil, false
+}
+
+// IsRevoked 会话是否已被撤销
+func IsRevoked(ctx *gin.Context, jti string) bool {
+	return AuthCache().Exist(ctx, revokedKeyPrefix+jti)
+}
@@ -0,0 +1,153 @@
+package ginx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/go-xuan/quanx/core/cachex"
+)
+
+func newSessionTestContext(device string) *gin.Context {
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	ctx.Request.Header.Set(deviceHeaderKey, device)
+	return ctx
+}
+
+func validateToken(token string) error {
+	var ctx = newSessionTestContext("")
+	ctx.Request.Header.Set(tokenHeaderKey, token)
+	return authValidateWithToken(ctx, &JwtUser{})
+}
+
+func TestSession(t *testing.T) {
+	authCacheClient = (&cachex.Config{Type: cachex.CacheTypeLocal, Source: "auth", Prefix: "auth", Marshal: "json"}).InitClient()
+	defer func() { authCacheClient = nil }()
+	defer SetSessionPolicy(SessionPolicy{})
+
+	SetSessionPolicy(SessionPolicy{Multi: true, MaxSessions: 2})
+	var ctx = newSessionTestContext("pc")
+	pc, err := IssueToken(ctx, &JwtUser{Phone: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mobile, err := IssueToken(newSessionTestContext("mobile"), &JwtUser{Phone: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = validateToken(pc.AccessToken); err != nil {
+		t.Error("pc token should be valid:", err)
+	}
+	if err = validateToken(mobile.AccessToken); err != nil {
+		t.Error("mobile token should be valid:", err)
+	}
+	if sessions, _ := ListSessions(ctx, "test"); len(sessions) != 2 {
+		t.Errorf("expected 2 sessions, got %d", len(sessions))
+	}
+
+	// 刷新令牌仅可使用一次
+	var user = &JwtUser{}
+	refreshed, err := RefreshToken(ctx, pc.RefreshToken, user)
+	if err != nil {
+		t.Fatal(err)
+	} else if refreshed.SessionId != pc.SessionId || user.Username() != "test" {
+		t.Errorf("refresh should keep the session, got %s", refreshed.SessionId)
+	}
+	if _, err = RefreshToken(ctx, pc.RefreshToken, &JwtUser{}); err == nil {
+		t.Error("used refresh token should be rejected")
+	}
+
+	// 撤销会话后访问令牌以及刷新令牌立即失效
+	if err = RevokeSession(ctx, "test", mobile.SessionId); err != nil {
+		t.Fatal(err)
+	}
+	if err = validateToken(mobile.AccessToken); err == nil {
+		t.Error("revoked token should be rejected")
+	}
+	if _, err = RefreshToken(ctx, mobile.RefreshToken, &JwtUser{}); err == nil {
+		t.Error("refresh token of revoked session should be rejected")
+	}
+
+	// 超出最大会话数时撤销最早的会话
+	if _, err = IssueToken(newSessionTestContext("pad"), &JwtUser{Phone: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = IssueToken(newSessionTestContext("tv"), &JwtUser{Phone: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	if sessions, _ := ListSessions(ctx, "test"); len(sessions) != 2 {
+		t.Errorf("expected 2 sessions, got %d", len(sessions))
+	}
+
+	// 单会话策略下新的登录撤销其他全部会话
+	SetSessionPolicy(SessionPolicy{})
+	single, err := IssueToken(ctx, &JwtUser{Phone: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sessions, _ := ListSessions(ctx, "test"); len(sessions) != 1 || sessions[0].Id != single.SessionId {
+		t.Errorf("expected only the latest session, got %d", len(sessions))
+	}
+	if err = validateToken(refreshed.AccessToken); err == nil {
+		t.Error("token of other session should be rejected")
+	}
+	if err = validateToken(single.AccessToken); err != nil {
+		t.Error("latest token should be valid:", err)
+	}
+}
+
+func TestSessionConcurrency(t *testing.T) {
+	authCacheClient = (&cachex.Config{Type: cachex.CacheTypeLocal, Source: "auth", Prefix: "auth", Marshal: "json"}).InitClient()
+	defer func() { authCacheClient = nil }()
+	defer SetSessionPolicy(SessionPolicy{})
+	SetSessionPolicy(SessionPolicy{Multi: true})
+
+	// 并发登录时会话索引不丢失
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := IssueToken(newSessionTestContext(strconv.Itoa(i)), &JwtUser{Phone: "test"}); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if sessions, _ := ListSessions(newSessionTestContext(""), "test"); len(sessions) != 10 {
+		t.Fatalf("expected 10 sessions, got %d", len(sessions))
+	}
+
+	// 并发使用同一刷新令牌时仅一个请求成功
+	pair, err := IssueToken(newSessionTestContext("pc"), &JwtUser{Phone: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var succeeded int32
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := RefreshToken(newSessionTestContext("pc"), pair.RefreshToken, &JwtUser{}); err == nil {
+				atomic.AddInt32(&succeeded, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	if succeeded != 1 {
+		t.Fatalf("refresh token should be used once, succeeded %d times", succeeded)
+	}
+
+	if err = RevokeAllSessions(newSessionTestContext(""), "test"); err != nil {
+		t.Fatal(err)
+	}
+	if sessions, _ := ListSessions(newSessionTestContext(""), "test"); len(sessions) != 0 {
+		t.Fatalf("expected no sessions, got %d", len(sessions))
+	}
+}
@@ -0,0 +1,245 @@
+package ginx
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/go-xuan/quanx/net/respx"
+	"github.com/go-xuan/quanx/os/errorx"
+)
+
+// 角色解析结果默认缓存时间
+const defaultRoleCacheTTL = time.Minute
+
+// 默认授权器，使用 SetPolicyStore() 设置策略存储
+var defaultAuthorizer = NewAuthorizer(NewMemoryPolicyStore())
+
+// RoleUser 拥有角色以及权限的鉴权用户，AuthUser 实现此接口后可以使用 RequirePermission()/RequireRole() 授权
+type RoleUser interface {
+	Roles() []string       // 角色
+	Permissions() []string // 直接授予的权限，与角色权限合并
+}
+
+// Role 角色
+type Role struct {
+	Code        string   `json:"code"`        // 角色编码
+	Parents     []string `json:"parents"`     // 继承的角色，拥有继承角色的全部权限
+	Permissions []string `json:"permissions"` // 权限，支持通配符，例如 order:* 表示订单的全部权限，* 表示全部权限
+}
+
+// PolicyStore 授权策略存储
+type PolicyStore interface {
+	GetRole(ctx context.Context, code string) (*Role, error) // 获取角色，不存在时返回nil
+}
+
+// 角色解析结果，包含继承的全部角色以及权限
+type resolvedRole struct {
+	roles       map[string]bool
+	permissions []string
+	expireAt    time.Time
+}
+
+// Authorizer 授权器，按照角色继承关系解析用户的全部角色以及权限，解析结果按照角色缓存
+type Authorizer struct {
+	store PolicyStore
+	ttl   time.Duration
+	mu    sync.RWMutex
+	cache map[string]*resolvedRole
+}
+
+// NewAuthorizer 创建授权器，ttl为角色解析结果的缓存时间，默认1分钟
+func NewAuthorizer(store PolicyStore, ttl ...time.Duration) *Authorizer {
+	var authorizer = &Authorizer{
+		store: store,
+		ttl:   defaultRoleCacheTTL,
+		cache: make(map[string]*resolvedRole),
+	}
+	if len(ttl) > 0 && ttl[0] > 0 {
+		authorizer.ttl = ttl[0]
+	}
+	return authorizer
+}
+
+// SetPolicyStore 设置默认授权器的策略存储
+func SetPolicyStore(store PolicyStore, ttl ...time.Duration) {
+	defaultAuthorizer = NewAuthorizer(store, ttl...)
+}
+
+// DefaultAuthorizer 默认授权器
+func DefaultAuthorizer() *Authorizer {
+	return defaultAuthorizer
+}
+
+// Invalidate 清空角色解析缓存，角色或者权限变更后调用
+func (a *Authorizer) Invalidate() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.cache = make(map[string]*resolvedRole)
+}
+
+// 解析角色，包含继承的全部角色以及权限
+func (a *Authorizer) resolve(ctx context.Context, code string) (*resolvedRole, error) {
+	a.mu.RLock()
+	var cached, ok = a.cache[code]
+	a.mu.RUnlock()
+	if ok && time.Now().Before(cached.expireAt) {
+		return cached, nil
+	}
+	var resolved = &resolvedRole{roles: make(map[string]bool), expireAt: time.Now().Add(a.ttl)}
+	var queue = []string{code}
+	for len(queue) > 0 {
+		var current = queue[0]
+		queue = queue[1:]
+		// 跳过已解析的角色，避免循环继承
+		if resolved.roles[current] {
+			continue
+		}
+		role, err := a.store.GetRole(ctx, current)
+		if err != nil {
+			return nil, errorx.Wrap(err, "get role error")
+		} else if role == nil {
+			continue
+		}
+		resolved.roles[current] = true
+		resolved.permissions = append(resolved.permissions, role.Permissions...)
+		queue = append(queue, role.Parents...)
+	}
+	a.mu.Lock()
+	a.cache[code] = resolved
+	a.mu.Unlock()
+	return resolved, nil
+}
+
+// Resolve 解析用户的全部角色（包含继承的角色）以及权限
+func (a *Authorizer) Resolve(ctx context.Context, user AuthUser) (map[string]bool, []string, error) {
+	var roles = make(map[string]bool)
+	var permissions []string
+	var roleUser, ok = user.(RoleUser)
+	if !ok {
+		return roles, permissions, nil
+	}
+	permissions = append(permissions, roleUser.Permissions()...)
+	for _, code := range roleUser.Roles() {
+		resolved, err := a.resolve(ctx, code)
+		if err != nil {
+			return nil, nil, err
+		}
+		for role := range resolved.roles {
+			roles[role] = true
+		}
+		permissions = append(permissions, resolved.permissions...)
+	}
+	return roles, permissions, nil
+}
+
+// HasPermission 用户是否拥有全部权限
+func (a *Authorizer) HasPermission(ctx context.Context, user AuthUser, required ...string) (bool, error) {
+	_, permissions, err := a.Resolve(ctx, user)
+	if err != nil {
+		return false, err
+	}
+	for _, r := range required {
+		var granted bool
+		for _, p := range permissions {
+			if granted = matchPermission(p, r); granted {
+				break
+			}
+		}
+		if !granted {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// HasRole 用户是否拥有任一角色，包含继承的角色
+func (a *Authorizer) HasRole(ctx context.Context, user AuthUser, required ...string) (bool, error) {
+	roles, _, err := a.Resolve(ctx, user)
+	if err != nil {
+		return false, err
+	}
+	for _, r := range required {
+		if roles[r] {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// RequirePermission 权限校验中间件，需要在鉴权中间件之后使用，用户需要拥有全部权限
+func (a *Authorizer) RequirePermission(permissions ...string) gin.HandlerFunc {
+	return a.require(func(ctx context.Context, user AuthUser) (bool, error) {
+		return a.HasPermission(ctx, user, permissions...)
+	}, "permission denied: "+strings.Join(permissions, ","))
+}
+
+// RequireRole 角色校验中间件，需要在鉴权中间件之后使用，用户需要拥有任一角色
+func (a *Authorizer) RequireRole(roles ...string) gin.HandlerFunc {
+	return a.require(func(ctx context.Context, user AuthUser) (bool, error) {
+		return a.HasRole(ctx, user, roles...)
+	}, "role required: "+strings.Join(roles, ","))
+}
+
+func (a *Authorizer) require(check func(context.Context, AuthUser) (bool, error), denied string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var user = GetSessionUser(ctx)
+		if user == nil {
+			respx.Forbidden(ctx, errorx.New("user is not authenticated"))
+			ctx.Abort()
+			return
+		}
+		if ok, err := check(ctx.Request.Context(), user); err != nil {
+			respx.Error(ctx, err.Error())
+			ctx.Abort()
+		} else if !ok {
+			Log(ctx).Warn(denied)
+			respx.Forbidden(ctx, errorx.New(denied))
+			ctx.Abort()
+		} else {
+			ctx.Next()
+		}
+	}
+}
+
+// Router 包装路由注册方法，注册的全部路由需要拥有指定权限，可用于 Engine.AddGinRouter()
+func (a *Authorizer) Router(router func(*gin.RouterGroup), permissions ...string) func(*gin.RouterGroup) {
+	return func(group *gin.RouterGroup) {
+		router(group.Group("", a.RequirePermission(permissions...)))
+	}
+}
+
+// RequirePermission 使用默认授权器的权限校验中间件
+func RequirePermission(permissions ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		DefaultAuthorizer().RequirePermission(permissions...)(ctx)
+	}
+}
+
+// RequireRole 使用默认授权器的角色校验中间件
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		DefaultAuthorizer().RequireRole(roles...)(ctx)
+	}
+}
+
+// PermissionRouter 使用默认授权器包装路由注册方法，注册的全部路由需要拥有指定权限
+func PermissionRouter(router func(*gin.RouterGroup), permissions ...string) func(*gin.RouterGroup) {
+	return func(group *gin.RouterGroup) {
+		router(group.Group("", RequirePermission(permissions...)))
+	}
+}
+
+// 权限匹配，granted为 * 时匹配全部权限，以 :* 结尾时匹配相同前缀的权限
+func matchPermission(granted, required string) bool {
+	if granted == "*" || granted == required {
+		return true
+	}
+	if strings.HasSuffix(granted, ":*") {
+		return strings.HasPrefix(required, granted[:len(granted)-1])
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+package ginx
+
+import (
+	"context"
+	"sync"
+
+	"gorm.io/gorm"
+
+	"github.com/go-xuan/quanx/os/errorx"
+)
+
+// MemoryPolicyStore 本地内存授权策略存储
+type MemoryPolicyStore struct {
+	mu    sync.RWMutex
+	roles map[string]*Role
+}
+
+// NewMemoryPolicyStore 创建本地内存授权策略存储
+func NewMemoryPolicyStore(roles ...*Role) *MemoryPolicyStore {
+	var store = &MemoryPolicyStore{roles: make(map[string]*Role)}
+	store.AddRole(roles...)
+	return store
+}
+
+// AddRole 添加角色，角色编码已存在时替换
+func (s *MemoryPolicyStore) AddRole(roles ...*Role) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, role := range roles {
+		s.roles[role.Code] = role
+	}
+}
+
+// RemoveRole 移除角色
+func (s *MemoryPolicyStore) RemoveRole(codes ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, code := range codes {
+		delete(s.roles, code)
+	}
+}
+
+func (s *MemoryPolicyStore) GetRole(_ context.Context, code string) (*Role, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.roles[code], nil
+}
+
+// AuthRole 角色表
+type AuthRole struct {
+	Code string `json:"code" gorm:"type:varchar(100); primary_key; comment:角色编码;"`
+	Name string `json:"name" gorm:"type:varchar(100); comment:角色名称;"`
+}
+
+func (AuthRole) TableName() string {
+	return "auth_role"
+}
+
+func (AuthRole) TableComment() string {
+	return "角色表"
+}
+
+// AuthRoleInherit 角色继承关系表
+type AuthRoleInherit struct {
+	RoleCode   string `json:"roleCode" gorm:"type:varchar(100); primary_key; comment:角色编码;"`
+	ParentCode string `json:"parentCode" gorm:"type:varchar(100); primary_key; comment:继承的角色编码;"`
+}
+
+func (AuthRoleInherit) TableName() string {
+	return "auth_role_inherit"
+}
+
+func (AuthRoleInherit) TableComment() string {
+	return "角色继承关系表"
+}
+
+// AuthRolePermission 角色权限表
+type AuthRolePermission struct {
+	RoleCode   string `json:"roleCode" gorm:"type:varchar(100); primary_key; comment:角色编码;"`
+	Permission string `json:"permission" gorm:"type:varchar(200); primary_key; comment:权限;"`
+}
+
+func (AuthRolePermission) TableName() string {
+	return "auth_role_permission"
+}
+
+func (AuthRolePermission) TableComment() string {
+	return "角色权限表"
+}
+
+// GormPolicyStore 数据库授权策略存储，表结构可通过 Engine.AddTable() 初始化
+type GormPolicyStore struct {
+	DB *gorm.DB
+}
+
+// NewGormPolicyStore 创建数据库授权策略存储
+func NewGormPolicyStore(db *gorm.DB) *GormPolicyStore {
+	return &GormPolicyStore{DB: db}
+}
+
+// Tables 授权策略相关的表结构
+func (s *GormPolicyStore) Tables() []interface{} {
+	return []interface{}{&AuthRole{}, &AuthRoleInherit{}, &AuthRolePermission{}}
+}
+
+func (s *GormPolicyStore) GetRole(ctx context.Context, code string) (*Role, error) {
+	var db = s.DB.WithContext(ctx)
+	var count int64
+	if err := db.Model(&AuthRole{}).Where("code = ?", code).Count(&count).Error; err != nil {
+		return nil, errorx.Wrap(err, "query role error")
+	} else if count == 0 {
+		return nil, nil
+	}
+	var role = &Role{Code: code}
+	if err := db.Model(&AuthRoleInherit{}).Where("role_code = ?", code).Pluck("parent_code", &role.Parents).Error; err != nil {
+		return nil, errorx.Wrap(err, "query role inherit error")
+	}
+	if err := db.Model(&AuthRolePermission{}).Where("role_code = ?", code).Pluck("permission", &role.Permissions).Error; err != nil {
+		return nil, errorx.Wrap(err, "query role permission error")
+	}
+	return role, nil
+}
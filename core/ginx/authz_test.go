@@ -0,0 +1,51 @@
+package ginx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAuthorizer(t *testing.T) {
+	var authorizer = NewAuthorizer(NewMemoryPolicyStore(
+		&Role{Code: "viewer", Permissions: []string{"order:read"}},
+		&Role{Code: "editor", Parents: []string{"viewer"}, Permissions: []string{"order:write"}},
+		&Role{Code: "admin", Parents: []string{"editor", "admin"}, Permissions: []string{"user:*"}},
+	))
+	var router = gin.New()
+	router.Use(func(ctx *gin.Context) {
+		if role := ctx.GetHeader("Role"); role != "" {
+			ctx.Set(sessionUserKey, &JwtUser{Phone: "test", RoleCodes: []string{role}, PermissionCodes: []string{"report:export"}})
+		}
+	})
+	authorizer.Router(func(group *gin.RouterGroup) {
+		group.GET("/order", func(ctx *gin.Context) {})
+	}, "order:write")(&router.RouterGroup)
+	router.GET("/user", authorizer.RequirePermission("user:delete", "report:export"), func(ctx *gin.Context) {})
+	router.GET("/editor", authorizer.RequireRole("editor"), func(ctx *gin.Context) {})
+
+	var cases = []struct {
+		path, role string
+		code       int
+	}{
+		{"/order", "", http.StatusForbidden},
+		{"/order", "viewer", http.StatusForbidden},
+		{"/order", "editor", http.StatusOK},
+		{"/order", "admin", http.StatusOK},
+		{"/user", "editor", http.StatusForbidden},
+		{"/user", "admin", http.StatusOK},
+		{"/editor", "viewer", http.StatusForbidden},
+		{"/editor", "admin", http.StatusOK},
+	}
+	for _, c := range cases {
+		var recorder = httptest.NewRecorder()
+		var req = httptest.NewRequest(http.MethodGet, c.path, nil)
+		req.Header.Set("Role", c.role)
+		router.ServeHTTP(recorder, req)
+		if recorder.Code != c.code {
+			t.Errorf("%s with role %q: expected %d, got %d", c.path, c.role, c.code, recorder.Code)
+		}
+	}
+}
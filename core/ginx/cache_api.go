@@ -0,0 +1,166 @@
+package ginx
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/go-xuan/quanx/core/cachex"
+	"github.com/go-xuan/quanx/net/respx"
+	"github.com/go-xuan/quanx/os/errorx"
+)
+
+// 缓存管理接口查询KEY的默认数量上限
+const cacheKeysLimit = 1000
+
+// NewCacheApi 注册缓存管理接口，handler为空时使用缓存默认句柄，未指定鉴权中间件时使用token鉴权
+func NewCacheApi(group *gin.RouterGroup, handler *cachex.Handler, auth ...gin.HandlerFunc) {
+	if len(auth) == 0 {
+		auth = []gin.HandlerFunc{AuthValidate().Token}
+	}
+	// 鉴权中间件仅作用于缓存管理接口，不影响调用方路由组中的其他路由
+	group = group.Group("", auth...)
+	var api = &CacheApi{handler: handler}
+	group.GET("sources", api.Sources)  // 缓存源以及统计
+	group.GET("keys", api.Keys)        // 查询前缀下的KEY
+	group.GET("get", api.Get)          // 查看KEY
+	group.DELETE("delete", api.Delete) // 删除KEY
+	group.POST("flush", api.Flush)     // 清空前缀下的KEY
+}
+
+// CacheApi 缓存管理接口
+type CacheApi struct {
+	handler *cachex.Handler
+}
+
+// CacheKeyForm 缓存KEY参数
+type CacheKeyForm struct {
+	Source string `form:"source" binding:"required"` // 缓存源名称
+	Key    string `form:"key" binding:"required"`    // 缓存KEY（不含缓存前缀）
+}
+
+// CachePrefixForm 缓存KEY前缀参数
+type CachePrefixForm struct {
+	Source string `form:"source" binding:"required"` // 缓存源名称
+	Prefix string `form:"prefix"`                    // KEY前缀（不含缓存前缀），为空时表示全部
+	Limit  int    `form:"limit"`                     // 查询数量上限
+}
+
+// CacheValue 缓存值
+type CacheValue struct {
+	Key   string `json:"key"`   // 缓存KEY
+	Value string `json:"value"` // 序列化后的缓存值
+	TTL   int64  `json:"ttl"`   // 剩余过期时间（毫秒），-1表示永不过期
+}
+
+func (a *CacheApi) cacheHandler() *cachex.Handler {
+	if a.handler != nil {
+		return a.handler
+	}
+	return cachex.Default()
+}
+
+// 获取缓存源对应的客户端
+func (a *CacheApi) client(source string) (cachex.Client, error) {
+	if client := a.cacheHandler().Client(source); client != nil {
+		return client, nil
+	}
+	return nil, errorx.Errorf("cache source not found: %s", source)
+}
+
+func (a *CacheApi) Sources(ctx *gin.Context) {
+	respx.Success(ctx, a.cacheHandler().Stats())
+}
+
+func (a *CacheApi) Keys(ctx *gin.Context) {
+	var form CachePrefixForm
+	if err := ctx.ShouldBindQuery(&form); err != nil {
+		respx.ParamError(ctx, err)
+		return
+	}
+	client, err := a.client(form.Source)
+	if err != nil {
+		respx.ParamError(ctx, err)
+		return
+	}
+	keys, err := client.Keys(ctx, prefixPattern(form.Prefix))
+	if err != nil {
+		respx.Error(ctx, err.Error())
+		return
+	}
+	var limit = form.Limit
+	if limit <= 0 {
+		limit = cacheKeysLimit
+	}
+	if len(keys) > limit {
+		keys = keys[:limit]
+	}
+	respx.Success(ctx, keys)
+}
+
+func (a *CacheApi) Get(ctx *gin.Context) {
+	var form CacheKeyForm
+	if err := ctx.ShouldBindQuery(&form); err != nil {
+		respx.ParamError(ctx, err)
+		return
+	}
+	client, err := a.client(form.Source)
+	if err != nil {
+		respx.ParamError(ctx, err)
+		return
+	}
+	ttl, err := client.TTL(ctx, form.Key)
+	if err != nil {
+		respx.Error(ctx, err.Error())
+		return
+	}
+	var value = &CacheValue{Key: form.Key, Value: client.GetString(ctx, form.Key), TTL: -1}
+	if ttl != cachex.NoExpiration {
+		value.TTL = ttl.Milliseconds()
+	}
+	respx.Success(ctx, value)
+}
+
+func (a *CacheApi) Delete(ctx *gin.Context) {
+	var form CacheKeyForm
+	if err := ctx.ShouldBindQuery(&form); err != nil {
+		respx.ParamError(ctx, err)
+		return
+	}
+	client, err := a.client(form.Source)
+	if err != nil {
+		respx.ParamError(ctx, err)
+		return
+	}
+	respx.Success(ctx, client.Delete(ctx, form.Key))
+}
+
+func (a *CacheApi) Flush(ctx *gin.Context) {
+	var form CachePrefixForm
+	if err := ctx.ShouldBindQuery(&form); err != nil {
+		respx.ParamError(ctx, err)
+		return
+	}
+	client, err := a.client(form.Source)
+	if err != nil {
+		respx.ParamError(ctx, err)
+		return
+	}
+	count, err := client.DeletePattern(ctx, prefixPattern(form.Prefix))
+	Log(ctx).WithField("source", form.Source).Infof("flush cache prefix %q, deleted %d keys", form.Prefix, count)
+	respx.Response(ctx, count, err)
+}
+
+// 前缀转换为匹配模式，转义前缀中的通配符
+func prefixPattern(prefix string) string {
+	var sb = strings.Builder{}
+	for _, c := range prefix {
+		switch c {
+		case '*', '?', '[', ']', '\\':
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(c)
+	}
+	sb.WriteString("*")
+	return sb.String()
+}
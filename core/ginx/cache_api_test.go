@@ -0,0 +1,71 @@
+package ginx
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/go-xuan/quanx/core/cachex"
+)
+
+func TestCacheApi(t *testing.T) {
+	var handler = cachex.NewHandler(nil)
+	if err := handler.Execute(&cachex.Config{Type: cachex.CacheTypeLocal, Source: "default", Prefix: "api", Marshal: "json"}); err != nil {
+		t.Fatal(err)
+	}
+	var client = handler.GetClient()
+	_ = client.Set(context.TODO(), "user:1", "a", time.Minute)
+	_ = client.Set(context.TODO(), "user:2", "b", time.Minute)
+	_ = client.Set(context.TODO(), "order:1", "c", time.Minute)
+
+	var router = gin.New()
+	var group = router.Group("/cache")
+	NewCacheApi(group, handler, func(ctx *gin.Context) {
+		if ctx.GetHeader("Authorization") == "" {
+			ctx.AbortWithStatus(http.StatusForbidden)
+		}
+	})
+	// 鉴权中间件不作用于路由组中的其他路由
+	group.GET("public", func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+	var request = func(method, url string, auth bool) *httptest.ResponseRecorder {
+		var req = httptest.NewRequest(method, url, nil)
+		if auth {
+			req.Header.Set("Authorization", "token")
+		}
+		var recorder = httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		return recorder
+	}
+	if recorder := request(http.MethodGet, "/cache/sources", false); recorder.Code != http.StatusForbidden {
+		t.Fatalf("unauthorized request should be rejected, got %d", recorder.Code)
+	}
+	if recorder := request(http.MethodGet, "/cache/keys?source=default&prefix=user:", true); !strings.Contains(recorder.Body.String(), `"user:1","user:2"`) {
+		t.Fatalf("unexpected keys response: %s", recorder.Body.String())
+	}
+	if recorder := request(http.MethodGet, "/cache/public", false); recorder.Code != http.StatusOK {
+		t.Fatalf("auth should not leak onto other routes, got %d", recorder.Code)
+	}
+	// 修改数据的接口不接受GET请求
+	if recorder := request(http.MethodGet, "/cache/flush?source=default&prefix=user:", true); recorder.Code != http.StatusNotFound {
+		t.Fatalf("flush should not accept GET, got %d", recorder.Code)
+	}
+	if recorder := request(http.MethodPost, "/cache/flush?source=default&prefix=user:", true); recorder.Code != http.StatusOK {
+		t.Fatalf("unexpected flush response: %s", recorder.Body.String())
+	}
+	if client.Exist(context.TODO(), "user:1") || !client.Exist(context.TODO(), "order:1") {
+		t.Fatal("flush should only delete keys under the prefix")
+	}
+	if recorder := request(http.MethodDelete, "/cache/delete?source=default&key=order:1", true); recorder.Code != http.StatusOK {
+		t.Fatalf("unexpected delete response: %s", recorder.Body.String())
+	} else if client.Exist(context.TODO(), "order:1") {
+		t.Fatal("key should be deleted")
+	}
+	if recorder := request(http.MethodGet, "/cache/sources", true); !strings.Contains(recorder.Body.String(), `"deletes":3`) {
+		t.Fatalf("unexpected sources response: %s", recorder.Body.String())
+	}
+}
@@ -1,9 +1,11 @@
 package ginx
 
 const (
-	tokenHeaderKey = "Authorization"
-	sessionUserKey = "gin_session_user"
-	cookieUserKey  = "gin_cookie_user"
-	clientIpKey    = "gin_client_ip"
-	traceIdKey     = "gin_trace_id"
+	tokenHeaderKey  = "Authorization"
+	deviceHeaderKey = "X-Device-Id"
+	sessionUserKey  = "gin_session_user"
+	cookieUserKey   = "gin_cookie_user"
+	oidcStateKey    = "gin_oidc_state"
+	clientIpKey     = "gin_client_ip"
+	traceIdKey      = "gin_trace_id"
 )
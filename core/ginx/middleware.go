@@ -1,11 +1,24 @@
 package ginx
 
 import (
+	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 	log "github.com/sirupsen/logrus"
+
+	"github.com/go-xuan/quanx/core/metricx"
+	"github.com/go-xuan/quanx/core/tracex"
+)
+
+var (
+	httpRequestsTotal = metricx.NewCounter("http_requests_total",
+		"Total number of http requests", "method", "path", "status")
+	httpRequestDuration = metricx.NewHistogram("http_request_duration_seconds",
+		"Http request latency in seconds", nil, "method", "path")
+	httpRequestsInFlight = metricx.NewGauge("http_requests_in_flight",
+		"Number of http requests currently being served")
 )
 
 // DefaultLogFormatter gin请求日志格式化
@@ -22,6 +35,19 @@ func DefaultLogFormatter(ctx *gin.Context) {
 	)
 }
 
+// Metrics gin请求指标采集，记录请求数、耗时以及处理中的请求数
+func Metrics(ctx *gin.Context) {
+	start := time.Now()
+	httpRequestsInFlight.Add(1)
+	defer httpRequestsInFlight.Add(-1)
+	// 处理请求
+	ctx.Next()
+	path := routePath(ctx)
+	method := ctx.Request.Method
+	httpRequestsTotal.With(method, path, strconv.Itoa(ctx.Writer.Status())).Inc()
+	httpRequestDuration.With(method, path).Observe(time.Since(start).Seconds())
+}
+
 // JsonLogFormatter gin请求日志格式化
 func JsonLogFormatter(ctx *gin.Context) {
 	start := time.Now()
@@ -37,7 +63,7 @@ func JsonLogFormatter(ctx *gin.Context) {
 
 // Log 日志包装
 func Log(ctx *gin.Context) *log.Entry {
-	entry := log.WithField("traceId", TraceId(ctx)).WithField("clientIp", ClientIP(ctx))
+	entry := log.WithField("traceId", TraceId(ctx)).WithField("spanId", SpanId(ctx)).WithField("clientIp", ClientIP(ctx))
 	if user := GetSessionUser(ctx); user != nil {
 		entry = entry.WithField("userId", user.UserId()).WithField("userName", user.Username())
 	}
@@ -56,10 +82,27 @@ func ClientIP(ctx *gin.Context) string {
 	return clientIp
 }
 
-// Trace traceId
+// Trace 链路追踪，解析请求头中的traceparent并开启服务端span，span存入请求上下文
 func Trace(ctx *gin.Context) {
-	ctx.Set(traceIdKey, uuid.NewString())
+	var request = ctx.Request
+	spanCtx, span := tracex.StartSpan(tracex.Extract(request.Context(), request.Header),
+		request.Method+" "+routePath(ctx), tracex.KindServer)
+	span.SetAttribute("http.method", request.Method).
+		SetAttribute("http.target", request.URL.Path).
+		SetAttribute("http.client_ip", ctx.ClientIP())
+	ctx.Request = request.WithContext(spanCtx)
+	ctx.Set(traceIdKey, span.TraceId)
+	ctx.Header(tracex.TraceparentHeader, span.Context().Traceparent())
+	defer span.End()
 	ctx.Next()
+	var status = ctx.Writer.Status()
+	span.SetAttribute("http.status_code", status)
+	if status >= http.StatusInternalServerError {
+		span.SetStatus(tracex.StatusError)
+	}
+	if len(ctx.Errors) > 0 {
+		span.SetError(ctx.Errors.Last())
+	}
 }
 
 // TraceId 获取traceId
@@ -69,3 +112,16 @@ func TraceId(ctx *gin.Context) string {
 	}
 	return ""
 }
+
+// SpanId 获取当前请求的spanId
+func SpanId(ctx *gin.Context) string {
+	return tracex.SpanId(ctx.Request.Context())
+}
+
+// 路由模板，未匹配路由时使用unmatched，避免标签及span名称基数过大
+func routePath(ctx *gin.Context) string {
+	if path := ctx.FullPath(); path != "" {
+		return path
+	}
+	return "unmatched"
+}
@@ -0,0 +1,120 @@
+package ginx
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/go-xuan/quanx/net/respx"
+	"github.com/go-xuan/quanx/os/errorx"
+)
+
+const (
+	TokenBucket   = "token_bucket"   // 令牌桶，允许一定的突发流量
+	SlidingWindow = "sliding_window" // 滑动窗口，任意时间窗口内的请求数不超过限制
+)
+
+// RateLimitRule 限流规则
+type RateLimitRule struct {
+	Name      string        // 规则名称，作为限流KEY的前缀，不同规则使用不同名称避免相互影响
+	Algorithm string        // 限流算法（token_bucket/sliding_window），默认令牌桶
+	Limit     int           // 每个周期允许的请求数，令牌桶为每个周期补充的令牌数
+	Period    time.Duration // 周期，默认1秒
+	Burst     int           // 令牌桶容量，即允许的最大突发请求数，默认等于Limit
+}
+
+// 设置默认值
+func (r *RateLimitRule) normalize() {
+	if r.Name == "" {
+		r.Name = "default"
+	}
+	if r.Algorithm == "" {
+		r.Algorithm = TokenBucket
+	}
+	if r.Period <= 0 {
+		r.Period = time.Second
+	}
+	if r.Burst <= 0 {
+		r.Burst = r.Limit
+	}
+}
+
+// RateLimitResult 限流结果
+type RateLimitResult struct {
+	Allowed    bool          // 是否放行
+	Limit      int           // 请求数上限
+	Remaining  int           // 剩余可用请求数
+	RetryAfter time.Duration // 被拒绝时距离下次可用的时间
+	ResetAfter time.Duration // 距离完全恢复的时间
+}
+
+// RateLimiter 限流器，redis实现用于多实例共享限额，本地内存实现用于单实例
+type RateLimiter interface {
+	Allow(ctx context.Context, key string, rule *RateLimitRule) (*RateLimitResult, error)
+}
+
+// RateLimitKeyFunc 从请求中提取限流KEY，返回空字符串时不限流
+type RateLimitKeyFunc func(ctx *gin.Context) string
+
+// RateLimitByIP 按照客户端IP限流
+func RateLimitByIP(ctx *gin.Context) string {
+	return ClientIP(ctx)
+}
+
+// RateLimitByUser 按照会话用户限流，需要在鉴权中间件之后使用，未登录时按照客户端IP限流
+func RateLimitByUser(ctx *gin.Context) string {
+	if user := GetSessionUser(ctx); user != nil {
+		return "user:" + user.Username()
+	}
+	return "ip:" + ClientIP(ctx)
+}
+
+// RateLimitByRoute 按照路由限流，所有客户端共享限额，用于保护下游系统
+func RateLimitByRoute(ctx *gin.Context) string {
+	return ctx.Request.Method + " " + routePath(ctx)
+}
+
+// RateLimit 限流中间件，响应头返回 X-RateLimit-Limit/X-RateLimit-Remaining/X-RateLimit-Reset，
+// 超出限制时返回429以及 Retry-After，限流器异常时放行
+func RateLimit(limiter RateLimiter, rule RateLimitRule, key RateLimitKeyFunc) gin.HandlerFunc {
+	rule.normalize()
+	if rule.Limit <= 0 {
+		log.WithField("rule", rule.Name).Error("rate limit is disabled, cause: the limit must be positive")
+		return func(ctx *gin.Context) { ctx.Next() }
+	}
+	if key == nil {
+		key = RateLimitByIP
+	}
+	return func(ctx *gin.Context) {
+		var k = key(ctx)
+		if k == "" {
+			ctx.Next()
+			return
+		}
+		result, err := limiter.Allow(ctx.Request.Context(), rule.Name+":"+k, &rule)
+		if err != nil {
+			Log(ctx).WithField("rule", rule.Name).Warn("rate limit failed, request allowed: ", err)
+			ctx.Next()
+			return
+		}
+		ctx.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+		ctx.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		ctx.Header("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(result.ResetAfter)))
+		if !result.Allowed {
+			ctx.Header("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+			respx.TooManyRequests(ctx, errorx.New("rate limit exceeded"))
+			ctx.Abort()
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// 时间向上取整为秒
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,102 @@
+package ginx
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// 本地内存限流器清理闲置KEY的间隔
+const memoryLimiterSweepInterval = time.Minute
+
+// 本地内存限流状态
+type memoryLimitState struct {
+	tokens   float64     // 令牌桶剩余令牌数
+	updated  time.Time   // 令牌桶最近更新时间
+	requests []time.Time // 滑动窗口内的请求时间
+	expireAt time.Time   // 闲置超过此时间后清理
+}
+
+// MemoryRateLimiter 本地内存限流器，仅对当前实例生效
+type MemoryRateLimiter struct {
+	mu     sync.Mutex
+	states map[string]*memoryLimitState
+	swept  time.Time
+}
+
+// NewMemoryRateLimiter 创建本地内存限流器
+func NewMemoryRateLimiter() *MemoryRateLimiter {
+	return &MemoryRateLimiter{
+		states: make(map[string]*memoryLimitState),
+		swept:  time.Now(),
+	}
+}
+
+func (l *MemoryRateLimiter) Allow(_ context.Context, key string, rule *RateLimitRule) (*RateLimitResult, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var now = time.Now()
+	l.sweep(now)
+	var state, ok = l.states[key]
+	if !ok {
+		state = &memoryLimitState{tokens: float64(rule.Burst), updated: now}
+		l.states[key] = state
+	}
+	if rule.Algorithm == SlidingWindow {
+		return state.slidingWindow(now, rule), nil
+	}
+	return state.tokenBucket(now, rule), nil
+}
+
+// 清理闲置的KEY
+func (l *MemoryRateLimiter) sweep(now time.Time) {
+	if now.Sub(l.swept) < memoryLimiterSweepInterval {
+		return
+	}
+	l.swept = now
+	for key, state := range l.states {
+		if now.After(state.expireAt) {
+			delete(l.states, key)
+		}
+	}
+}
+
+func (s *memoryLimitState) tokenBucket(now time.Time, rule *RateLimitRule) *RateLimitResult {
+	// 每纳秒补充的令牌数
+	var rate = float64(rule.Limit) / float64(rule.Period)
+	var capacity = float64(rule.Burst)
+	s.tokens = math.Min(capacity, s.tokens+float64(now.Sub(s.updated))*rate)
+	s.updated = now
+	var result = &RateLimitResult{Limit: rule.Burst}
+	if s.tokens >= 1 {
+		s.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration(math.Ceil((1 - s.tokens) / rate))
+	}
+	result.Remaining = int(s.tokens)
+	result.ResetAfter = time.Duration(math.Ceil((capacity - s.tokens) / rate))
+	s.expireAt = now.Add(result.ResetAfter)
+	return result
+}
+
+func (s *memoryLimitState) slidingWindow(now time.Time, rule *RateLimitRule) *RateLimitResult {
+	var start = now.Add(-rule.Period)
+	var i = 0
+	for i < len(s.requests) && !s.requests[i].After(start) {
+		i++
+	}
+	s.requests = s.requests[i:]
+	var result = &RateLimitResult{Limit: rule.Limit}
+	if len(s.requests) < rule.Limit {
+		s.requests = append(s.requests, now)
+		result.Allowed = true
+		result.Remaining = rule.Limit - len(s.requests)
+	} else {
+		result.RetryAfter = s.requests[0].Add(rule.Period).Sub(now)
+	}
+	result.ResetAfter = s.requests[len(s.requests)-1].Add(rule.Period).Sub(now)
+	s.expireAt = now.Add(result.ResetAfter)
+	return result
+}
@@ -0,0 +1,102 @@
+package ginx
+
+import (
+	"context"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/go-xuan/quanx/core/redisx"
+	"github.com/go-xuan/quanx/os/errorx"
+)
+
+// 令牌桶：按照距离上次请求的时间补充令牌，使用redis服务端时间避免各实例时钟不一致，
+// 返回 {是否放行, 剩余令牌数, 重试等待毫秒数, 完全恢复毫秒数}
+var tokenBucketScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1]) or capacity
+local ts = tonumber(state[2]) or now
+tokens = math.min(capacity, tokens + math.max(0, now - ts) * rate)
+local allowed, retry = 0, 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry = math.ceil((1 - tokens) / rate)
+end
+local reset = math.ceil((capacity - tokens) / rate)
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], reset + 1000)
+return {allowed, math.floor(tokens), retry, reset}`)
+
+// 滑动窗口：有序集合记录窗口内每次请求的时间，
+// 返回 {是否放行, 剩余请求数, 重试等待毫秒数, 完全恢复毫秒数}
+var slidingWindowScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+local count = redis.call('ZCARD', KEYS[1])
+if count < limit then
+	redis.call('ZADD', KEYS[1], now, now .. ':' .. ARGV[3])
+	redis.call('PEXPIRE', KEYS[1], window)
+	return {1, limit - count - 1, 0, window}
+end
+local oldest = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+local newest = redis.call('ZRANGE', KEYS[1], -1, -1, 'WITHSCORES')
+return {0, 0, tonumber(oldest[2]) + window - now, tonumber(newest[2]) + window - now}`)
+
+// RedisRateLimiter 基于redis的限流器，多个实例共享限额
+type RedisRateLimiter struct {
+	redis  *redisx.Handler // redis句柄，为空时使用redisx默认句柄
+	source string          // redis数据源
+}
+
+// NewRedisRateLimiter 创建redis限流器，redis为空时使用redisx默认句柄，source为空时使用默认数据源
+func NewRedisRateLimiter(redis *redisx.Handler, source ...string) *RedisRateLimiter {
+	var limiter = &RedisRateLimiter{redis: redis}
+	if len(source) > 0 {
+		limiter.source = source[0]
+	}
+	return limiter
+}
+
+func (l *RedisRateLimiter) client() redis.UniversalClient {
+	if l.redis != nil {
+		return l.redis.GetClient(l.source)
+	}
+	return redisx.GetClient(l.source)
+}
+
+func (l *RedisRateLimiter) Allow(ctx context.Context, key string, rule *RateLimitRule) (*RateLimitResult, error) {
+	var keys = []string{"ratelimit:" + key}
+	var period = rule.Period.Milliseconds()
+	var values []int64
+	var err error
+	var result = &RateLimitResult{Limit: rule.Limit}
+	if rule.Algorithm == SlidingWindow {
+		values, err = slidingWindowScript.Run(ctx, l.client(), keys, rule.Limit, period, strconv.FormatInt(rand.Int63(), 36)).Int64Slice()
+	} else {
+		// 每毫秒补充的令牌数
+		var rate = float64(rule.Limit) / float64(period)
+		values, err = tokenBucketScript.Run(ctx, l.client(), keys, rule.Burst, strconv.FormatFloat(rate, 'f', -1, 64)).Int64Slice()
+		result.Limit = rule.Burst
+	}
+	if err != nil {
+		return nil, errorx.Wrap(err, "redis rate limit error")
+	} else if len(values) != 4 {
+		return nil, errorx.Errorf("unexpected rate limit result: %v", values)
+	}
+	result.Allowed = values[0] == 1
+	result.Remaining = int(values[1])
+	result.RetryAfter = time.Duration(values[2]) * time.Millisecond
+	result.ResetAfter = time.Duration(values[3]) * time.Millisecond
+	return result, nil
+}
@@ -0,0 +1,43 @@
+package ginx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRateLimit(t *testing.T) {
+	var limiter = NewMemoryRateLimiter()
+	var router = gin.New()
+	router.GET("/bucket", RateLimit(limiter, RateLimitRule{Name: "bucket", Limit: 2, Period: time.Second}, RateLimitByIP), func(ctx *gin.Context) {})
+	router.GET("/window", RateLimit(limiter, RateLimitRule{Name: "window", Algorithm: SlidingWindow, Limit: 2, Period: 200 * time.Millisecond}, RateLimitByRoute), func(ctx *gin.Context) {})
+	var request = func(path string) *httptest.ResponseRecorder {
+		var recorder = httptest.NewRecorder()
+		var req = httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		router.ServeHTTP(recorder, req)
+		return recorder
+	}
+	for _, path := range []string{"/bucket", "/window"} {
+		for i := 0; i < 2; i++ {
+			if recorder := request(path); recorder.Code != http.StatusOK {
+				t.Fatalf("%s request %d should be allowed, got %d", path, i, recorder.Code)
+			}
+		}
+		var recorder = request(path)
+		if recorder.Code != http.StatusTooManyRequests {
+			t.Fatalf("%s request should be rejected, got %d", path, recorder.Code)
+		}
+		if recorder.Header().Get("X-RateLimit-Limit") != "2" || recorder.Header().Get("X-RateLimit-Remaining") != "0" || recorder.Header().Get("Retry-After") != "1" {
+			t.Fatalf("%s unexpected headers: %v", path, recorder.Header())
+		}
+	}
+	// 滑动窗口过后恢复
+	time.Sleep(250 * time.Millisecond)
+	if recorder := request("/window"); recorder.Code != http.StatusOK {
+		t.Fatalf("window request should be allowed after the window, got %d", recorder.Code)
+	}
+}
@@ -6,32 +6,29 @@ import (
 	"strings"
 	"time"
 
-	log "github.com/sirupsen/logrus"
 	"gorm.io/driver/mysql"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/schema"
 
-	"github.com/go-xuan/quanx/common/constx"
 	"github.com/go-xuan/quanx/core/configx"
 	"github.com/go-xuan/quanx/os/errorx"
-	"github.com/go-xuan/quanx/types/anyx"
 )
 
 type Config struct {
-	Source          string `json:"source" yaml:"source" default:"default"`              // 数据源名称
-	Enable          bool   `json:"enable" yaml:"enable"`                                // 数据源启用
-	Type            string `json:"type" yaml:"type"`                                    // 数据库类型
-	Host            string `json:"host" yaml:"host" default:"localhost"`                // 数据库Host
-	Port            int    `json:"port" yaml:"port"`                                    // 数据库端口
-	Username        string `json:"username" yaml:"username"`                            // 用户名
-	Password        string `json:"password" yaml:"password"`                            // 密码
-	Database        string `json:"database" yaml:"database"`                            // 数据库名
-	Schema          string `json:"schema" yaml:"schema"`                                // schema模式名
-	Debug           bool   `json:"debug" yaml:"debug" default:"false"`                  // 开启debug（打印SQL以及初始化模型建表）
-	MaxIdleConns    int    `json:"maxIdleConns" yaml:"maxIdleConns" default:"10"`       // 最大空闲连接
-	MaxOpenConns    int    `json:"maxOpenConns" yaml:"maxOpenConns" default:"10"`       // 最大打开连接
-	ConnMaxLifetime int    `json:"connMaxLifetime" yaml:"connMaxLifetime" default:"10"` // 连接存活时间(分钟)
+	Source          string `json:"source" yaml:"source" default:"default"`                               // 数据源名称
+	Enable          bool   `json:"enable" yaml:"enable"`                                                 // 数据源启用
+	Type            string `json:"type" yaml:"type" validate:"omitempty,oneof=mysql postgres pgsql"`     // 数据库类型
+	Host            string `json:"host" yaml:"host" default:"localhost"`                                 // 数据库Host
+	Port            int    `json:"port" yaml:"port" validate:"min=0,max=65535"`                          // 数据库端口
+	Username        string `json:"username" yaml:"username"`                                             // 用户名
+	Password        string `json:"password" yaml:"password"`                                             // 密码
+	Database        string `json:"database" yaml:"database"`                                             // 数据库名
+	Schema          string `json:"schema" yaml:"schema"`                                                 // schema模式名
+	Debug           bool   `json:"debug" yaml:"debug" default:"false"`                                   // 开启debug（打印SQL以及初始化模型建表）
+	MaxIdleConns    int    `json:"maxIdleConns" yaml:"maxIdleConns" default:"10" validate:"min=0"`       // 最大空闲连接
+	MaxOpenConns    int    `json:"maxOpenConns" yaml:"maxOpenConns" default:"10" validate:"min=0"`       // 最大打开连接
+	ConnMaxLifetime int    `json:"connMaxLifetime" yaml:"connMaxLifetime" default:"10" validate:"min=0"` // 连接存活时间(分钟)
 }
 
 func (c *Config) Format() string {
@@ -43,36 +40,40 @@ func (c *Config) Reader() *configx.Reader {
 	return &configx.Reader{
 		FilePath:    "database.yaml",
 		NacosDataId: "database.yaml",
-		Listen:      false,
+		Listen:      true,
 	}
 }
 
+// Validate 校验启用的数据源必须设置数据库类型以及数据库名
+func (c *Config) Validate() error {
+	if !c.Enable {
+		return nil
+	}
+	var err = &configx.ValidationError{}
+	if c.Type == "" {
+		err.Add("type", "is required")
+	}
+	if c.Database == "" {
+		err.Add("database", "is required")
+	}
+	return err.Err()
+}
+
 func (c *Config) Execute() error {
-	if c.Enable {
-		if err := anyx.SetDefaultValue(c); err != nil {
-			return errorx.Wrap(err, "set default value error")
-		}
-		if db, err := c.NewGormDB(); err != nil {
-			return errorx.Wrap(err, "new gorm db error")
-		} else {
-			if _handler == nil {
-				_handler = &Handler{
-					multi: false, config: c, db: db,
-					configs: make(map[string]*Config),
-					dbs:     make(map[string]*gorm.DB),
-				}
-			} else {
-				_handler.multi = true
-				if c.Source == constx.DefaultSource {
-					_handler.config = c
-					_handler.db = db
-				}
-			}
-			_handler.configs[c.Source] = c
-			_handler.dbs[c.Source] = db
-		}
+	return Default().execute(c)
+}
+
+// Close 关闭当前数据源连接
+func (c *Config) Close() error {
+	if _handler == nil {
+		return nil
 	}
-	return nil
+	return _handler.close(c)
+}
+
+// Reload 重新加载数据源，新连接就绪后替换旧连接，旧连接延迟关闭
+func (c *Config) Reload() error {
+	return Default().reload(c)
 }
 
 // NewGormDB 创建数据库连接
@@ -87,7 +88,14 @@ func (c *Config) NewGormDB() (*gorm.DB, error) {
 		sqlDB.SetMaxIdleConns(c.MaxIdleConns)
 		sqlDB.SetMaxOpenConns(c.MaxOpenConns)
 		sqlDB.SetConnMaxLifetime(time.Duration(c.ConnMaxLifetime) * time.Second)
-		
+		if err = db.Use(&metricsPlugin{source: c.Source}); err != nil {
+			return nil, errorx.Wrap(err, "use gorm metrics plugin failed")
+		}
+		if err = db.Use(&tracePlugin{source: c.Source, dbType: c.Type}); err != nil {
+			return nil, errorx.Wrap(err, "use gorm trace plugin failed")
+		}
+		registerPoolMetrics(c.Source, sqlDB)
+
 		if c.Debug {
 			// 是否打印SQL
 			db = db.Debug()
@@ -161,40 +169,34 @@ func (MultiConfig) Reader() *configx.Reader {
 	return &configx.Reader{
 		FilePath:    "database.yaml",
 		NacosDataId: "database.yaml",
-		Listen:      false,
+		Listen:      true,
 	}
 }
 
-func (m MultiConfig) Execute() error {
-	if len(m) == 0 {
-		return errorx.New("database not connected! cause: database.yaml is invalid")
-	}
-	if _handler == nil {
-		_handler = &Handler{
-			dbs:     make(map[string]*gorm.DB),
-			configs: make(map[string]*Config),
-		}
-	}
-	_handler.multi = true
-	for i, c := range m {
-		if c.Enable {
-			if err := anyx.SetDefaultValue(c); err != nil {
-				return errorx.Wrap(err, "set default value error")
-			}
-			db, err := c.NewGormDB()
-			if err != nil {
-				return errorx.Wrap(err, "new gorm.Config failed")
-			}
-			_handler.dbs[c.Source] = db
-			_handler.configs[c.Source] = c
-			if i == 0 || c.Source == constx.DefaultSource {
-				_handler.db = db
-				_handler.config = c
-			}
+// Validate 校验数据源名称不能重复
+func (m MultiConfig) Validate() error {
+	var sources = make(map[string]bool)
+	for _, c := range m {
+		if c.Enable && sources[c.Source] {
+			return errorx.Errorf("source is duplicated: %s", c.Source)
 		}
-	}
-	if len(_handler.configs) == 0 {
-		log.Error("database not connected! cause: database.yaml is empty or no enabled source")
+		sources[c.Source] = true
 	}
 	return nil
 }
+
+func (m MultiConfig) Execute() error {
+	return Default().executeMulti(m)
+}
+
+func (m MultiConfig) Close() error {
+	if _handler == nil {
+		return nil
+	}
+	return _handler.closeMulti(m)
+}
+
+// Reload 重新加载多数据源，已移除的数据源将被关闭
+func (m MultiConfig) Reload() error {
+	return Default().reloadMulti(m)
+}
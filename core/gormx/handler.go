@@ -1,11 +1,37 @@
 package gormx
 
 import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
 	"github.com/go-xuan/quanx/common/constx"
+	"github.com/go-xuan/quanx/core/configx"
+	"github.com/go-xuan/quanx/core/healthx"
 	"github.com/go-xuan/quanx/os/errorx"
-	"gorm.io/gorm"
+	"github.com/go-xuan/quanx/types/anyx"
 )
 
+const healthComponent = "database"
+
+// 注册数据源健康检查
+func (h *Handler) registerHealthChecker(source string, db *gorm.DB) {
+	h.health.Register(healthx.Name(healthComponent, source), func(ctx context.Context) error {
+		if sqlDB, err := db.DB(); err != nil {
+			return errorx.Wrap(err, "get sql.DB failed")
+		} else {
+			return sqlDB.PingContext(ctx)
+		}
+	})
+}
+
+// 热加载后旧连接延迟关闭的等待时间，确保执行中的语句完成
+const drainDelay = 30 * time.Second
+
 var _handler *Handler
 
 func this() *Handler {
@@ -15,32 +41,179 @@ func this() *Handler {
 	return _handler
 }
 
-func addDB(source string, config *Config, db *gorm.DB) {
+// Default 包级别默认数据库句柄，DB()/GetConfig()等包级别函数以及 Config.Execute() 均在此句柄上操作，首次调用时创建
+func Default() *Handler {
 	if _handler == nil {
-		_handler = &Handler{
-			multi:   false,
-			config:  config,
-			db:      db,
-			configs: make(map[string]*Config),
-			dbs:     make(map[string]*gorm.DB),
-		}
-	} else {
-		_handler.multi = true
+		_handler = NewHandler(nil)
 	}
-	_handler.configs[source] = config
-	_handler.dbs[source] = db
+	return _handler
 }
 
-// Handler 数据库连接句柄
+// SetDefault 替换包级别默认数据库句柄，替换后包级别函数使用新句柄上的数据源
+func SetDefault(h *Handler) {
+	_handler = h
+}
+
+// Handler 数据库连接句柄，按照数据源名称持有gorm连接，每个Engine使用各自的句柄以及健康检查注册表
 type Handler struct {
+	mu      sync.RWMutex
 	multi   bool
 	config  *Config
 	db      *gorm.DB
 	configs map[string]*Config
 	dbs     map[string]*gorm.DB
+	health  *healthx.Registry // 数据源健康检查注册表
+}
+
+// NewHandler 创建数据库连接句柄，health为数据源健康检查注册表，为空时使用healthx默认注册表
+func NewHandler(health *healthx.Registry) *Handler {
+	if health == nil {
+		health = healthx.Default()
+	}
+	return &Handler{
+		configs: make(map[string]*Config),
+		dbs:     make(map[string]*gorm.DB),
+		health:  health,
+	}
+}
+
+// IsInitialized 是否已连接任一数据源
+func (h *Handler) IsInitialized() bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.dbs) > 0
+}
+
+// Accept 是否为单数据源 *Config 或者多数据源 MultiConfig
+func (h *Handler) Accept(conf configx.Configurator) bool {
+	return configx.Accepts[*Config, MultiConfig](conf)
+}
+
+// Execute 连接配置中启用的数据源，多数据源时首个数据源或者default数据源作为默认数据源
+func (h *Handler) Execute(conf configx.Configurator) error {
+	return configx.Dispatch(conf, h.execute, h.executeMulti)
+}
+
+// Reload 重新连接变更的数据源并移除已删除的数据源，被替换的旧连接延迟关闭
+func (h *Handler) Reload(conf configx.Configurator) error {
+	return configx.Dispatch(conf, h.reload, h.reloadMulti)
+}
+
+// Shutdown 按照配置逆序关闭数据源连接
+func (h *Handler) Shutdown(conf configx.Configurator) error {
+	return configx.Dispatch(conf, h.close, h.closeMulti)
+}
+
+func (h *Handler) execute(c *Config) error {
+	if !c.Enable {
+		return nil
+	}
+	if err := anyx.SetDefaultValue(c); err != nil {
+		return errorx.Wrap(err, "set default value error")
+	}
+	db, err := c.NewGormDB()
+	if err != nil {
+		return errorx.Wrap(err, "new gorm db error")
+	}
+	drainDB(c.Source, h.swapSource(c, db, c.Source == constx.DefaultSource))
+	h.registerHealthChecker(c.Source, db)
+	return nil
+}
+
+func (h *Handler) executeMulti(m MultiConfig) error {
+	if len(m) == 0 {
+		return errorx.New("database not connected! cause: database.yaml is invalid")
+	}
+	h.mu.Lock()
+	h.multi = true
+	h.mu.Unlock()
+	for i, c := range m {
+		if c.Enable {
+			if err := anyx.SetDefaultValue(c); err != nil {
+				return errorx.Wrap(err, "set default value error")
+			}
+			db, err := c.NewGormDB()
+			if err != nil {
+				return errorx.Wrap(err, "new gorm.Config failed")
+			}
+			drainDB(c.Source, h.swapSource(c, db, i == 0 || c.Source == constx.DefaultSource))
+			h.registerHealthChecker(c.Source, db)
+		}
+	}
+	if !h.IsInitialized() {
+		log.Error("database not connected! cause: database.yaml is empty or no enabled source")
+	}
+	return nil
+}
+
+func (h *Handler) close(c *Config) error {
+	if c.Enable {
+		if err := h.closeSource(c.Source); err != nil {
+			return errorx.Wrap(err, "close gorm db error")
+		}
+		log.Info("database connection closed: ", c.Format())
+	}
+	return nil
+}
+
+func (h *Handler) closeMulti(m MultiConfig) error {
+	for i := len(m) - 1; i >= 0; i-- {
+		if err := h.close(m[i]); err != nil {
+			return errorx.Wrap(err, "close database failed")
+		}
+	}
+	return nil
+}
+
+func (h *Handler) reload(c *Config) error {
+	if !h.IsInitialized() {
+		return h.execute(c)
+	}
+	return h.reloadSource(c, c.Source == constx.DefaultSource)
+}
+
+func (h *Handler) reloadSource(c *Config, isDefault bool) error {
+	if !c.Enable {
+		return h.closeSource(c.Source)
+	}
+	if err := anyx.SetDefaultValue(c); err != nil {
+		return errorx.Wrap(err, "set default value error")
+	}
+	db, err := c.NewGormDB()
+	if err != nil {
+		return errorx.Wrap(err, "new gorm db error")
+	}
+	old := h.swapSource(c, db, isDefault)
+	h.registerHealthChecker(c.Source, db)
+	drainDB(c.Source, old)
+	log.Info("database reload success: ", c.Format())
+	return nil
+}
+
+func (h *Handler) reloadMulti(m MultiConfig) error {
+	if !h.IsInitialized() {
+		return h.executeMulti(m)
+	}
+	var sources = make(map[string]bool)
+	for i, c := range m {
+		if err := h.reloadSource(c, i == 0 || c.Source == constx.DefaultSource); err != nil {
+			return errorx.Wrap(err, "reload database failed")
+		}
+		sources[c.Source] = true
+	}
+	for _, source := range h.Sources() {
+		if !sources[source] {
+			if err := h.closeSource(source); err != nil {
+				return errorx.Wrap(err, "close removed database failed")
+			}
+		}
+	}
+	return nil
 }
 
 func (h *Handler) DB(source ...string) *gorm.DB {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
 	if h.multi && len(source) > 0 && source[0] != constx.DefaultSource {
 		if db, ok := h.dbs[source[0]]; ok {
 			return db
@@ -50,6 +223,8 @@ func (h *Handler) DB(source ...string) *gorm.DB {
 }
 
 func (h *Handler) GetConfig(source ...string) *Config {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
 	if h.multi && len(source) > 0 && source[0] != constx.DefaultSource {
 		if conf, ok := h.configs[source[0]]; ok {
 			return conf
@@ -67,7 +242,72 @@ func (h *Handler) Close(source ...string) error {
 	return nil
 }
 
+// 关闭指定数据源并移除
+func (h *Handler) closeSource(source string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if db, ok := h.dbs[source]; ok {
+		if sqlDB, err := db.DB(); err != nil {
+			return errorx.Wrap(err, "get sql.DB failed")
+		} else if err = sqlDB.Close(); err != nil {
+			return errorx.Wrap(err, "close sql.DB failed")
+		}
+		delete(h.dbs, source)
+		delete(h.configs, source)
+		h.health.Unregister(healthx.Name(healthComponent, source))
+		if h.config != nil && h.config.Source == source {
+			h.resetDefault()
+		}
+	}
+	return nil
+}
+
+// 默认数据源被关闭后重新选择默认数据源，优先使用default，其次使用名称排序后的第一个，没有剩余时置空
+func (h *Handler) resetDefault() {
+	h.config, h.db = nil, nil
+	var sources = make([]string, 0, len(h.configs))
+	for source := range h.configs {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+	for _, source := range sources {
+		if h.config == nil || source == constx.DefaultSource {
+			h.config, h.db = h.configs[source], h.dbs[source]
+		}
+	}
+}
+
+// 替换数据源连接，返回被替换的旧连接
+func (h *Handler) swapSource(config *Config, db *gorm.DB, isDefault bool) *gorm.DB {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var old = h.dbs[config.Source]
+	h.dbs[config.Source] = db
+	h.configs[config.Source] = config
+	if isDefault || h.config == nil || h.config.Source == config.Source {
+		h.config, h.db = config, db
+	}
+	h.multi = h.multi || len(h.dbs) > 1
+	return old
+}
+
+// 延迟关闭被替换的旧连接
+func drainDB(source string, db *gorm.DB) {
+	if db == nil {
+		return
+	}
+	time.AfterFunc(drainDelay, func() {
+		if sqlDB, err := db.DB(); err == nil {
+			if err = sqlDB.Close(); err != nil {
+				log.WithField("source", source).Error("close replaced sql.DB failed: ", err)
+			}
+		}
+	})
+}
+
 func (h *Handler) Sources() []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
 	var sources []string
 	for source := range h.configs {
 		sources = append(sources, source)
@@ -75,9 +315,9 @@ func (h *Handler) Sources() []string {
 	return sources
 }
 
-// IsInitialized 是否初始化
+// IsInitialized 默认句柄是否已连接任一数据源
 func IsInitialized() bool {
-	return _handler != nil
+	return _handler != nil && _handler.IsInitialized()
 }
 
 // GetConfig 获取数据库配置
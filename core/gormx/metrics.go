@@ -0,0 +1,96 @@
+package gormx
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/go-xuan/quanx/core/metricx"
+)
+
+const metricsStartKey = "quanx:metrics_start"
+
+var (
+	gormQueryDuration = metricx.NewHistogram("gorm_query_duration_seconds",
+		"Gorm statement latency in seconds", nil, "source", "operation")
+	gormQueryErrors = metricx.NewCounter("gorm_query_errors_total",
+		"Total number of failed gorm statements", "source", "operation")
+	gormPoolConnections = metricx.NewGauge("gorm_pool_connections",
+		"Number of database pool connections by state", "source", "state")
+	gormPoolWaitCount = metricx.NewGauge("gorm_pool_wait_count",
+		"Total number of connections waited for", "source")
+)
+
+// metricsPlugin gorm指标采集插件，记录各类语句的耗时以及失败次数
+type metricsPlugin struct {
+	source string
+}
+
+func (p *metricsPlugin) Name() string {
+	return "quanx:metrics"
+}
+
+func (p *metricsPlugin) Initialize(db *gorm.DB) error {
+	return registerAroundCallbacks(db, "quanx:metrics", func(string) func(*gorm.DB) { return p.before }, p.after)
+}
+
+// 为各类语句注册前置以及后置回调
+func registerAroundCallbacks(db *gorm.DB, prefix string, before, after func(operation string) func(*gorm.DB)) error {
+	var callback = db.Callback()
+	var errs = []error{
+		callback.Create().Before("gorm:create").Register(prefix+"_before_create", before("create")),
+		callback.Create().After("gorm:create").Register(prefix+"_after_create", after("create")),
+		callback.Query().Before("gorm:query").Register(prefix+"_before_query", before("query")),
+		callback.Query().After("gorm:query").Register(prefix+"_after_query", after("query")),
+		callback.Update().Before("gorm:update").Register(prefix+"_before_update", before("update")),
+		callback.Update().After("gorm:update").Register(prefix+"_after_update", after("update")),
+		callback.Delete().Before("gorm:delete").Register(prefix+"_before_delete", before("delete")),
+		callback.Delete().After("gorm:delete").Register(prefix+"_after_delete", after("delete")),
+		callback.Row().Before("gorm:row").Register(prefix+"_before_row", before("row")),
+		callback.Row().After("gorm:row").Register(prefix+"_after_row", after("row")),
+		callback.Raw().Before("gorm:raw").Register(prefix+"_before_raw", before("raw")),
+		callback.Raw().After("gorm:raw").Register(prefix+"_after_raw", after("raw")),
+	}
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *metricsPlugin) before(db *gorm.DB) {
+	db.InstanceSet(metricsStartKey, time.Now())
+}
+
+func (p *metricsPlugin) after(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		if value, ok := db.InstanceGet(metricsStartKey); ok {
+			if start, ok := value.(time.Time); ok {
+				gormQueryDuration.With(p.source, operation).Observe(time.Since(start).Seconds())
+			}
+		}
+		if err := db.Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			gormQueryErrors.With(p.source, operation).Inc()
+		}
+	}
+}
+
+// 注册连接池指标，采集时读取连接池状态
+func registerPoolMetrics(source string, sqlDB *sql.DB) {
+	for state, fn := range map[string]func(stats sql.DBStats) int{
+		"open":  func(stats sql.DBStats) int { return stats.OpenConnections },
+		"inUse": func(stats sql.DBStats) int { return stats.InUse },
+		"idle":  func(stats sql.DBStats) int { return stats.Idle },
+	} {
+		var get = fn
+		gormPoolConnections.With(source, state).Func(func() float64 {
+			return float64(get(sqlDB.Stats()))
+		})
+	}
+	gormPoolWaitCount.With(source).Func(func() float64 {
+		return float64(sqlDB.Stats().WaitCount)
+	})
+}
@@ -0,0 +1,52 @@
+package gormx
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+
+	"github.com/go-xuan/quanx/core/tracex"
+)
+
+const traceSpanKey = "quanx:trace_span"
+
+// tracePlugin gorm链路追踪插件，语句上下文中存在链路信息时开启子span
+type tracePlugin struct {
+	source string
+	dbType string
+}
+
+func (p *tracePlugin) Name() string {
+	return "quanx:trace"
+}
+
+func (p *tracePlugin) Initialize(db *gorm.DB) error {
+	return registerAroundCallbacks(db, "quanx:trace", p.before, p.after)
+}
+
+func (p *tracePlugin) before(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		if ctx := db.Statement.Context; tracex.SpanContextFromContext(ctx).IsValid() {
+			spanCtx, span := tracex.StartSpan(ctx, "gorm."+operation, tracex.KindClient)
+			span.SetAttribute("db.system", p.dbType).SetAttribute("db.source", p.source)
+			db.Statement.Context = spanCtx
+			db.InstanceSet(traceSpanKey, span)
+		}
+	}
+}
+
+func (p *tracePlugin) after(string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		if value, ok := db.InstanceGet(traceSpanKey); ok {
+			if span, ok := value.(*tracex.Span); ok {
+				span.SetAttribute("db.table", db.Statement.Table).
+					SetAttribute("db.statement", db.Statement.SQL.String()).
+					SetAttribute("db.rows_affected", db.RowsAffected)
+				if err := db.Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+					span.SetError(err)
+				}
+				span.End()
+			}
+		}
+	}
+}
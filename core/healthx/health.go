@@ -0,0 +1,189 @@
+package healthx
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/go-xuan/quanx/net/respx"
+	"github.com/go-xuan/quanx/os/errorx"
+)
+
+// 健康状态
+const (
+	StatusUp   = "up"   // 正常
+	StatusDown = "down" // 异常
+)
+
+var _registry = NewRegistry()
+
+// Checker 健康检查函数，返回nil表示健康
+type Checker func(ctx context.Context) error
+
+// Result 单项检查结果
+type Result struct {
+	Name    string `json:"name"`            // 检查项名称（组件:数据源）
+	Status  string `json:"status"`          // 检查状态（up/down）
+	Latency int64  `json:"latency"`         // 检查耗时（毫秒）
+	Error   string `json:"error,omitempty"` // 异常信息
+}
+
+// Report 健康检查报告
+type Report struct {
+	Status string    `json:"status"` // 整体状态（up/down）
+	Ready  bool      `json:"ready"`  // 服务是否就绪
+	Checks []*Result `json:"checks"` // 各项检查结果
+}
+
+// Registry 健康检查注册表
+type Registry struct {
+	mu       sync.RWMutex
+	checkers map[string]Checker // 检查项
+	timeout  time.Duration      // 单项检查超时时间
+	ready    bool               // 服务是否就绪
+}
+
+// NewRegistry 创建健康检查注册表，同一进程中运行多个相互隔离的服务实例时，每个实例使用各自的注册表
+func NewRegistry() *Registry {
+	return &Registry{checkers: make(map[string]Checker), timeout: 3 * time.Second}
+}
+
+// Register 注册健康检查项，同名检查项将被覆盖
+func (r *Registry) Register(name string, checker Checker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checkers[name] = checker
+}
+
+// Unregister 移除健康检查项
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.checkers, name)
+}
+
+// SetReady 设置服务就绪状态
+func (r *Registry) SetReady(ready bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ready = ready
+}
+
+// SetTimeout 设置单项检查超时时间
+func (r *Registry) SetTimeout(timeout time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.timeout = timeout
+}
+
+// Check 并发执行全部检查项
+func (r *Registry) Check(ctx context.Context) *Report {
+	r.mu.RLock()
+	var names = make([]string, 0, len(r.checkers))
+	var checkers = make(map[string]Checker, len(r.checkers))
+	for name, checker := range r.checkers {
+		names = append(names, name)
+		checkers[name] = checker
+	}
+	var timeout, ready = r.timeout, r.ready
+	r.mu.RUnlock()
+	sort.Strings(names)
+
+	var report = &Report{Status: StatusUp, Ready: ready, Checks: make([]*Result, len(names))}
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			report.Checks[i] = runChecker(ctx, name, checkers[name], timeout)
+		}(i, name)
+	}
+	wg.Wait()
+	for _, result := range report.Checks {
+		if result.Status != StatusUp {
+			report.Status = StatusDown
+		}
+	}
+	return report
+}
+
+func runChecker(ctx context.Context, name string, checker Checker, timeout time.Duration) (result *Result) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	var start = time.Now()
+	result = &Result{Name: name, Status: StatusUp}
+	defer func() {
+		if err := recover(); err != nil {
+			result.Status = StatusDown
+			result.Error = errorx.New(err).Error()
+		}
+		result.Latency = time.Since(start).Milliseconds()
+	}()
+	if err := checker(ctx); err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+	return
+}
+
+// Default 默认健康检查注册表
+func Default() *Registry {
+	return _registry
+}
+
+// Register 注册健康检查项
+func Register(name string, checker Checker) {
+	_registry.Register(name, checker)
+}
+
+// Unregister 移除健康检查项
+func Unregister(name string) {
+	_registry.Unregister(name)
+}
+
+// SetReady 设置服务就绪状态
+func SetReady(ready bool) {
+	_registry.SetReady(ready)
+}
+
+// Check 执行全部检查项
+func Check(ctx context.Context) *Report {
+	return _registry.Check(ctx)
+}
+
+// Liveness 存活探针，进程能够响应请求即返回200，不执行依赖组件的检查项，
+// 避免依赖组件短暂异常时健康的实例被重启，依赖组件的状态通过 Readiness 探针反映
+func (r *Registry) Liveness(ctx *gin.Context) {
+	respx.Custom(ctx, http.StatusOK, respx.NewResponseData(respx.SuccessCode, &Report{Status: StatusUp, Checks: []*Result{}}))
+}
+
+// Readiness 就绪探针，服务未就绪或者任一检查项异常时返回503
+func (r *Registry) Readiness(ctx *gin.Context) {
+	if report := r.Check(ctx); report.Ready && report.Status == StatusUp {
+		respx.Custom(ctx, http.StatusOK, respx.NewResponseData(respx.SuccessCode, report))
+	} else {
+		respx.Custom(ctx, http.StatusServiceUnavailable, respx.NewResponseData(respx.FailedCode, report))
+	}
+}
+
+// Liveness 默认注册表的存活探针
+func Liveness(ctx *gin.Context) {
+	_registry.Liveness(ctx)
+}
+
+// Readiness 默认注册表的就绪探针
+func Readiness(ctx *gin.Context) {
+	_registry.Readiness(ctx)
+}
+
+// Name 检查项名称
+func Name(component, source string) string {
+	if source == "" {
+		return component
+	}
+	return component + ":" + source
+}
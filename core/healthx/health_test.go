@@ -0,0 +1,52 @@
+package healthx
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/go-xuan/quanx/os/errorx"
+)
+
+func TestHealth(t *testing.T) {
+	Register(Name("test", "up"), func(ctx context.Context) error {
+		return nil
+	})
+	var downChecked int32
+	Register(Name("test", "down"), func(ctx context.Context) error {
+		atomic.AddInt32(&downChecked, 1)
+		return errorx.New("connection refused")
+	})
+	if report := Check(context.TODO()); report.Status != StatusDown || len(report.Checks) != 2 {
+		t.Fatal("unexpected report: ", report)
+	}
+
+	engine := gin.New()
+	engine.GET("/healthz", Liveness)
+	engine.GET("/readyz", Readiness)
+	SetReady(true)
+	atomic.StoreInt32(&downChecked, 0)
+	for url, code := range map[string]int{"/healthz": http.StatusOK, "/readyz": http.StatusServiceUnavailable} {
+		recorder := httptest.NewRecorder()
+		engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, url, nil))
+		if recorder.Code != code {
+			t.Errorf("%s status = %d, want %d", url, recorder.Code, code)
+		}
+	}
+
+	// 存活探针不执行检查项
+	if checked := atomic.LoadInt32(&downChecked); checked != 1 {
+		t.Errorf("checker ran %d times, want only once by /readyz", checked)
+	}
+
+	Unregister(Name("test", "down"))
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if recorder.Code != http.StatusOK {
+		t.Errorf("/readyz status = %d, want %d", recorder.Code, http.StatusOK)
+	}
+}
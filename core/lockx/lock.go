@@ -0,0 +1,251 @@
+package lockx
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/go-xuan/quanx/os/errorx"
+	"github.com/go-xuan/quanx/utils/idx"
+)
+
+var (
+	ErrNotObtained = errors.New("lock: not obtained") // 锁已被其他持有者占用，重试直到ctx结束仍未获取到
+	ErrNotHeld     = errors.New("lock: not held")     // 锁已过期或者已被其他持有者获取
+)
+
+const (
+	defaultTTL        = 30 * time.Second        // 默认租约时间
+	defaultMinBackoff = 50 * time.Millisecond   // 默认最小重试间隔
+	defaultMaxBackoff = 1000 * time.Millisecond // 默认最大重试间隔
+)
+
+// Locker 分布式锁，redis实现用于多实例之间互斥，本地内存实现用于单实例以及测试
+type Locker interface {
+	// Obtain 获取锁，锁被占用时按照退避策略重试直到获取成功或者ctx结束
+	Obtain(ctx context.Context, key string, opts ...Option) (*Lock, error)
+}
+
+// 锁的存储后端
+type backend interface {
+	// 获取锁，同一持有者重复获取时重入次数加一，返回fencing token，未获取到时返回0
+	acquire(ctx context.Context, key, owner string, ttl time.Duration) (int64, error)
+	// 释放锁，重入次数减一，减为0时删除，返回是否仍由持有者持有
+	release(ctx context.Context, key, owner string) (bool, error)
+	// 续期，返回是否仍由持有者持有
+	renew(ctx context.Context, key, owner string, ttl time.Duration) (bool, error)
+}
+
+// Option 获取锁选项
+type Option func(*options)
+
+type options struct {
+	ttl        time.Duration // 租约时间
+	owner      string        // 持有者标识
+	minBackoff time.Duration // 最小重试间隔
+	maxBackoff time.Duration // 最大重试间隔
+	noRetry    bool          // 仅尝试一次
+	noWatchdog bool          // 不自动续期
+}
+
+// WithTTL 租约时间，默认30秒，启用自动续期时每隔三分之一租约时间续期一次
+func WithTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.ttl = ttl
+	}
+}
+
+// WithOwner 持有者标识，同一持有者可以重复获取同一把锁（可重入），
+// 默认每次获取使用随机标识，即不可重入
+func WithOwner(owner string) Option {
+	return func(o *options) {
+		o.owner = owner
+	}
+}
+
+// WithBackoff 重试间隔，每次重试间隔翻倍直到最大值，并增加随机抖动避免多个实例同时重试
+func WithBackoff(min, max time.Duration) Option {
+	return func(o *options) {
+		o.minBackoff, o.maxBackoff = min, max
+	}
+}
+
+// WithoutRetry 仅尝试一次，锁被占用时立即返回 ErrNotObtained
+func WithoutRetry() Option {
+	return func(o *options) {
+		o.noRetry = true
+	}
+}
+
+// WithoutWatchdog 不自动续期，租约到期后锁自动释放
+func WithoutWatchdog() Option {
+	return func(o *options) {
+		o.noWatchdog = true
+	}
+}
+
+func newOptions(opts []Option) *options {
+	var o = &options{
+		ttl:        defaultTTL,
+		minBackoff: defaultMinBackoff,
+		maxBackoff: defaultMaxBackoff,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.owner == "" {
+		o.owner = idx.UUID()
+	}
+	if o.minBackoff <= 0 {
+		o.minBackoff = defaultMinBackoff
+	}
+	if o.maxBackoff < o.minBackoff {
+		o.maxBackoff = o.minBackoff
+	}
+	return o
+}
+
+// 获取锁，锁被占用时按照退避策略重试
+func obtain(ctx context.Context, b backend, key string, opts []Option) (*Lock, error) {
+	var o = newOptions(opts)
+	if o.ttl <= 0 {
+		return nil, errorx.New("lock ttl must be positive")
+	}
+	var backoff = o.minBackoff
+	for {
+		fence, err := b.acquire(ctx, key, o.owner, o.ttl)
+		if err != nil {
+			return nil, errorx.Wrap(err, "acquire lock error")
+		} else if fence > 0 {
+			return newLock(b, key, fence, o), nil
+		} else if o.noRetry {
+			return nil, ErrNotObtained
+		}
+		// 随机抖动范围[backoff/2, backoff)
+		var wait = backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+		var timer = time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ErrNotObtained
+		case <-timer.C:
+		}
+		if backoff *= 2; backoff > o.maxBackoff {
+			backoff = o.maxBackoff
+		}
+	}
+}
+
+// Lock 已获取的锁
+type Lock struct {
+	backend backend
+	key     string
+	owner   string
+	fence   int64
+	ttl     time.Duration
+	cancel  context.CancelFunc // 停止自动续期
+	lost    chan struct{}      // 锁释放或者丢失时关闭
+	once    sync.Once
+}
+
+func newLock(b backend, key string, fence int64, o *options) *Lock {
+	ctx, cancel := context.WithCancel(context.Background())
+	var lock = &Lock{
+		backend: b,
+		key:     key,
+		owner:   o.owner,
+		fence:   fence,
+		ttl:     o.ttl,
+		cancel:  cancel,
+		lost:    make(chan struct{}),
+	}
+	if !o.noWatchdog {
+		go lock.watchdog(ctx)
+	}
+	return lock
+}
+
+// Key 锁名称
+func (l *Lock) Key() string {
+	return l.key
+}
+
+// Token 持有者标识，释放以及续期时校验
+func (l *Lock) Token() string {
+	return l.owner
+}
+
+// Fence fencing token，同一把锁每次被新的持有者获取时单调递增，重入时保持不变，
+// 写入共享资源时携带此值，资源方拒绝小于已见最大值的写入，避免锁过期后旧持有者的写入覆盖新数据
+func (l *Lock) Fence() int64 {
+	return l.fence
+}
+
+// Lost 锁释放或者丢失（续期失败、租约过期）时关闭
+func (l *Lock) Lost() <-chan struct{} {
+	return l.lost
+}
+
+// Refresh 手动续期
+func (l *Lock) Refresh(ctx context.Context, ttl time.Duration) error {
+	ok, err := l.backend.renew(ctx, l.key, l.owner, ttl)
+	if err != nil {
+		return errorx.Wrap(err, "renew lock error")
+	} else if !ok {
+		l.stop()
+		return ErrNotHeld
+	}
+	return nil
+}
+
+// Release 释放锁，重入获取的锁需要释放相同次数后才会被其他持有者获取
+func (l *Lock) Release(ctx context.Context) error {
+	l.stop()
+	ok, err := l.backend.release(ctx, l.key, l.owner)
+	if err != nil {
+		return errorx.Wrap(err, "release lock error")
+	} else if !ok {
+		return ErrNotHeld
+	}
+	return nil
+}
+
+// 停止自动续期并通知锁已释放
+func (l *Lock) stop() {
+	l.once.Do(func() {
+		l.cancel()
+		close(l.lost)
+	})
+}
+
+// 自动续期，每隔三分之一租约时间续期一次，确认锁已丢失或者超过租约时间未能续期成功时停止
+func (l *Lock) watchdog(ctx context.Context) {
+	var logger = log.WithField("lock", l.key)
+	var ticker = time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+	var renewed = time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			ok, err := l.backend.renew(ctx, l.key, l.owner, l.ttl)
+			if err == nil && ok {
+				renewed = time.Now()
+				continue
+			} else if ctx.Err() != nil {
+				return
+			} else if err != nil && time.Since(renewed) < l.ttl {
+				logger.Warn("renew lock failed, will retry: ", err)
+				continue
+			}
+			logger.Error("lock lost")
+			l.stop()
+			return
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package lockx
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-xuan/quanx/core/redisx"
+)
+
+func testLocker(t *testing.T, locker Locker) {
+	ctx := context.TODO()
+	first, err := locker.Obtain(ctx, "order", WithOwner("a"), WithTTL(300*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 同一持有者可重入，fencing token不变
+	reentrant, err := locker.Obtain(ctx, "order", WithOwner("a"), WithoutRetry())
+	if err != nil || reentrant.Fence() != first.Fence() {
+		t.Fatalf("reentrant obtain failed: %v", err)
+	}
+	if _, err = locker.Obtain(ctx, "order", WithOwner("b"), WithoutRetry()); !errors.Is(err, ErrNotObtained) {
+		t.Fatalf("expected not obtained, got %v", err)
+	}
+	// 自动续期，超过租约时间仍然持有
+	time.Sleep(500 * time.Millisecond)
+	if err = reentrant.Release(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = locker.Obtain(ctx, "order", WithOwner("b"), WithoutRetry()); !errors.Is(err, ErrNotObtained) {
+		t.Fatal("lock should be held until released as many times as obtained")
+	}
+	// 等待期间释放，重试获取成功并且fencing token递增
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		_ = first.Release(ctx)
+	}()
+	timeout, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	second, err := locker.Obtain(timeout, "order", WithOwner("b"), WithBackoff(10*time.Millisecond, 50*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Fence() <= first.Fence() {
+		t.Fatalf("fencing token should increase: %d <= %d", second.Fence(), first.Fence())
+	}
+	select {
+	case <-first.Lost():
+	default:
+		t.Fatal("released lock should be marked as lost")
+	}
+	if err = first.Release(ctx); !errors.Is(err, ErrNotHeld) {
+		t.Fatalf("expected not held, got %v", err)
+	}
+	_ = second.Release(ctx)
+
+	// 不自动续期时租约到期后被其他持有者获取
+	expired, _ := locker.Obtain(ctx, "order", WithTTL(50*time.Millisecond), WithoutWatchdog())
+	time.Sleep(100 * time.Millisecond)
+	third, err := locker.Obtain(ctx, "order", WithoutRetry())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = expired.Release(ctx); !errors.Is(err, ErrNotHeld) {
+		t.Fatalf("expired lock should not release others, got %v", err)
+	}
+	_ = third.Release(ctx)
+}
+
+func TestMemoryLocker(t *testing.T) {
+	testLocker(t, NewMemoryLocker())
+}
+
+func TestRedisLocker(t *testing.T) {
+	var redis = redisx.NewHandler(nil)
+	if err := redis.Execute(&redisx.Config{
+		Source:   "default",
+		Enable:   true,
+		Host:     "localhost",
+		Port:     6379,
+		Password: "Init@1234",
+		Database: 1,
+	}); err != nil {
+		t.Skip("redis is not available: ", err)
+	}
+	testLocker(t, NewRedisLocker(redis))
+}
@@ -0,0 +1,85 @@
+package lockx
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// 本地内存锁条目
+type memoryEntry struct {
+	owner    string
+	count    int
+	fence    int64
+	expireAt time.Time
+}
+
+// MemoryLocker 基于本地内存的锁，与 RedisLocker 行为一致，仅在当前进程内互斥，适用于单实例部署以及测试
+type MemoryLocker struct {
+	mu      sync.Mutex
+	entries map[string]*memoryEntry
+	fences  map[string]int64
+}
+
+// NewMemoryLocker 创建本地内存锁
+func NewMemoryLocker() *MemoryLocker {
+	return &MemoryLocker{
+		entries: make(map[string]*memoryEntry),
+		fences:  make(map[string]int64),
+	}
+}
+
+func (l *MemoryLocker) Obtain(ctx context.Context, key string, opts ...Option) (*Lock, error) {
+	return obtain(ctx, l, key, opts)
+}
+
+// 获取未过期的锁条目
+func (l *MemoryLocker) entry(key string) *memoryEntry {
+	if entry, ok := l.entries[key]; ok {
+		if time.Now().Before(entry.expireAt) {
+			return entry
+		}
+		delete(l.entries, key)
+	}
+	return nil
+}
+
+func (l *MemoryLocker) acquire(_ context.Context, key, owner string, ttl time.Duration) (int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var entry = l.entry(key)
+	if entry == nil {
+		l.fences[key]++
+		entry = &memoryEntry{owner: owner, fence: l.fences[key]}
+		l.entries[key] = entry
+	} else if entry.owner != owner {
+		return 0, nil
+	}
+	entry.count++
+	entry.expireAt = time.Now().Add(ttl)
+	return entry.fence, nil
+}
+
+func (l *MemoryLocker) release(_ context.Context, key, owner string) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var entry = l.entry(key)
+	if entry == nil || entry.owner != owner {
+		return false, nil
+	}
+	if entry.count--; entry.count <= 0 {
+		delete(l.entries, key)
+	}
+	return true, nil
+}
+
+func (l *MemoryLocker) renew(_ context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var entry = l.entry(key)
+	if entry == nil || entry.owner != owner {
+		return false, nil
+	}
+	entry.expireAt = time.Now().Add(ttl)
+	return true, nil
+}
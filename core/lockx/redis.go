@@ -0,0 +1,94 @@
+package lockx
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/go-xuan/quanx/core/redisx"
+)
+
+// 获取锁：锁不存在时递增fencing token并写入持有者，持有者相同时重入次数加一，
+// 锁以及fencing token使用相同的hash tag，集群模式下位于同一槽位
+var acquireScript = redis.NewScript(`
+local owner = redis.call('HGET', KEYS[1], 'owner')
+if owner == false then
+	local fence = redis.call('INCR', KEYS[2])
+	redis.call('HSET', KEYS[1], 'owner', ARGV[1], 'count', 1, 'fence', fence)
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+	return fence
+elseif owner == ARGV[1] then
+	redis.call('HINCRBY', KEYS[1], 'count', 1)
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+	return tonumber(redis.call('HGET', KEYS[1], 'fence'))
+end
+return 0`)
+
+// 释放锁：校验持有者后重入次数减一，减为0时删除
+var releaseScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], 'owner') ~= ARGV[1] then
+	return 0
+end
+if redis.call('HINCRBY', KEYS[1], 'count', -1) <= 0 then
+	redis.call('DEL', KEYS[1])
+end
+return 1`)
+
+// 续期：校验持有者后重置过期时间
+var renewScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], 'owner') ~= ARGV[1] then
+	return 0
+end
+return redis.call('PEXPIRE', KEYS[1], ARGV[2])`)
+
+// RedisLocker 基于redis的分布式锁
+type RedisLocker struct {
+	redis  *redisx.Handler // redis句柄，为空时使用redisx默认句柄
+	source string          // redis数据源
+}
+
+// NewRedisLocker 创建redis分布式锁，redis为空时使用redisx默认句柄，source为空时使用默认数据源
+func NewRedisLocker(redis *redisx.Handler, source ...string) *RedisLocker {
+	var locker = &RedisLocker{redis: redis}
+	if len(source) > 0 {
+		locker.source = source[0]
+	}
+	return locker
+}
+
+func (l *RedisLocker) Obtain(ctx context.Context, key string, opts ...Option) (*Lock, error) {
+	return obtain(ctx, l, key, opts)
+}
+
+func (l *RedisLocker) client() redis.UniversalClient {
+	if l.redis != nil {
+		return l.redis.GetClient(l.source)
+	}
+	return redisx.GetClient(l.source)
+}
+
+// 锁以及fencing token的redis KEY
+func redisKeys(key string) []string {
+	var tag = "lock:{" + key + "}"
+	return []string{tag, tag + ":fence"}
+}
+
+func (l *RedisLocker) acquire(ctx context.Context, key, owner string, ttl time.Duration) (int64, error) {
+	return acquireScript.Run(ctx, l.client(), redisKeys(key), owner, ttl.Milliseconds()).Int64()
+}
+
+func (l *RedisLocker) release(ctx context.Context, key, owner string) (bool, error) {
+	result, err := releaseScript.Run(ctx, l.client(), redisKeys(key)[:1], owner).Int64()
+	return result == 1, err
+}
+
+func (l *RedisLocker) renew(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	result, err := renewScript.Run(ctx, l.client(), redisKeys(key)[:1], owner, ttl.Milliseconds()).Int64()
+	return result == 1, err
+}
+
+// Obtain 使用redisx默认句柄获取分布式锁
+func Obtain(ctx context.Context, key string, opts ...Option) (*Lock, error) {
+	return NewRedisLocker(nil).Obtain(ctx, key, opts...)
+}
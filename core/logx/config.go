@@ -30,17 +30,20 @@ const (
 	logWriterSource = "log"
 )
 
+// 当前生效的日志hook，热加载时替换
+var _hook *Hook
+
 // Config 日志配置
 type Config struct {
-	Name       string            `json:"name" yaml:"name" default:"app"`                                 // 日志文件名
-	Level      string            `json:"level" yaml:"level" default:"info"`                              // 默认日志级别
-	Formatter  string            `json:"formatter" yaml:"formatter" default:"json"`                      // 日志格式
-	Writer     string            `json:"writer" yaml:"writer" default:"file"`                            // 默认日志输出
-	Writers    map[string]string `json:"writers" yaml:"writers"`                                         // 日志级别日志输出
-	TimeFormat string            `json:"timeFormat" yaml:"timeFormat" default:"2006-01-02 15:04:05.999"` // 时间格式化
-	Color      bool              `json:"color" yaml:"color" default:"false"`                             // 使用颜色
-	Caller     bool              `json:"caller" yaml:"caller" default:"false"`                           // caller开关
-	File       *FileWriterConfig `json:"file" yaml:"file"`                                               // 日志输出到文件
+	Name       string            `json:"name" yaml:"name" default:"app"`                                                                                           // 日志文件名
+	Level      string            `json:"level" yaml:"level" default:"info"`                                                                                        // 默认日志级别
+	Formatter  string            `json:"formatter" yaml:"formatter" default:"json" validate:"oneof=json text"`                                                     // 日志格式
+	Writer     string            `json:"writer" yaml:"writer" default:"file" validate:"oneof=default file mongo es"`                                               // 默认日志输出
+	Writers    map[string]string `json:"writers" yaml:"writers" validate:"dive,keys,oneof=trace debug info error fatal panic,endkeys,oneof=default file mongo es"` // 日志级别日志输出
+	TimeFormat string            `json:"timeFormat" yaml:"timeFormat" default:"2006-01-02 15:04:05.999"`                                                           // 时间格式化
+	Color      bool              `json:"color" yaml:"color" default:"false"`                                                                                       // 使用颜色
+	Caller     bool              `json:"caller" yaml:"caller" default:"false"`                                                                                     // caller开关
+	File       *FileWriterConfig `json:"file" yaml:"file"`                                                                                                         // 日志输出到文件
 }
 
 func (c *Config) Format() string {
@@ -48,10 +51,46 @@ func (c *Config) Format() string {
 		c.Level, c.Formatter, c.Writer)
 }
 
+// Validate 校验日志级别，日志级别不区分大小写
+func (c *Config) Validate() error {
+	var err = &configx.ValidationError{}
+	switch strings.ToLower(c.Level) {
+	case TraceLevel, DebugLevel, InfoLevel, ErrorLevel, FatalLevel, PanicLevel:
+	default:
+		err.Add("level", fmt.Sprintf("must be one of [trace debug info error fatal panic], got %q", c.Level))
+	}
+	return err.Err()
+}
+
+// DependsOn 日志输出到mongo或者elastic search时，依赖对应组件先完成初始化
+func (c *Config) DependsOn() []string {
+	var writers = []string{c.Writer}
+	for _, writer := range c.Writers {
+		writers = append(writers, writer)
+	}
+	var deps []string
+	var exists = make(map[string]bool)
+	for _, writer := range writers {
+		var dep string
+		switch writer {
+		case mongoWriterType:
+			dep = "mongo"
+		case eSOutWriterType:
+			dep = "elastic"
+		}
+		if dep != "" && !exists[dep] {
+			exists[dep] = true
+			deps = append(deps, dep)
+		}
+	}
+	return deps
+}
+
 func (*Config) Reader() *configx.Reader {
 	return &configx.Reader{
 		FilePath:    "log.yaml",
 		NacosDataId: "log.yaml",
+		Listen:      true,
 	}
 }
 
@@ -60,13 +99,27 @@ func (c *Config) Execute() error {
 		return errorx.Wrap(err, "set default value error")
 	}
 	c.initFile()
-	log.AddHook(c.NewHook())           // 添加hook
+	setHook(c.NewHook())               // 添加hook
 	log.SetFormatter(c.LogFormatter()) // 设置formatter
 	log.SetLevel(c.GetLogrusLevel())   // 设置默认日志级别
 	log.SetReportCaller(c.Caller)
 	return nil
 }
 
+// Reload 重新加载日志配置，使用新的hook替换当前hook，其他hook保持不变
+func (c *Config) Reload() error {
+	if err := anyx.SetDefaultValue(c); err != nil {
+		return errorx.Wrap(err, "set default value error")
+	}
+	c.initFile()
+	setHook(c.NewHook())
+	log.SetFormatter(c.LogFormatter())
+	log.SetLevel(c.GetLogrusLevel())
+	log.SetReportCaller(c.Caller)
+	log.Info("log config reload success: ", c.Format())
+	return nil
+}
+
 func (c *Config) initFile() {
 	var needFile bool
 	if c.Writer == fileWriterType {
@@ -81,7 +134,9 @@ func (c *Config) initFile() {
 	if needFile && c.File == nil {
 		c.File = &FileWriterConfig{Name: c.Name}
 	}
-	_ = anyx.SetDefaultValue(c.File)
+	if c.File != nil {
+		_ = anyx.SetDefaultValue(c.File)
+	}
 }
 
 func (c *Config) LogFormatter() log.Formatter {
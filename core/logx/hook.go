@@ -93,6 +93,27 @@ func (hook *Hook) SetWriters(writers map[log.Level]io.Writer) {
 	}
 }
 
+var hookMutex sync.Mutex
+
+// 注册hook并移除当前hook，logrus仅在添加hook时读取 Levels()，因此日志级别变更后需要重新注册，
+// 其他来源添加的hook保持不变
+func setHook(hook *Hook) {
+	hookMutex.Lock()
+	defer hookMutex.Unlock()
+	var logger = log.StandardLogger()
+	var hooks = make(log.LevelHooks)
+	for level, levelHooks := range logger.Hooks {
+		for _, h := range levelHooks {
+			if h != _hook {
+				hooks[level] = append(hooks[level], h)
+			}
+		}
+	}
+	hooks.Add(hook)
+	logger.ReplaceHooks(hooks)
+	_hook = hook
+}
+
 // 输出到ioWriter
 func (hook *Hook) Write(entry *log.Entry) error {
 	if hook.writers != nil {
@@ -0,0 +1,59 @@
+package logx
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func newTestHook(writers map[log.Level]io.Writer) *Hook {
+	var hook = newHook()
+	hook.SetFormatter(nil)
+	hook.InitWriters(writers)
+	return hook
+}
+
+func TestSetHook(t *testing.T) {
+	var logger = log.StandardLogger()
+	var origin = logger.ReplaceHooks(make(log.LevelHooks))
+	var level, output = logger.GetLevel(), logger.Out
+	defer func() {
+		logger.ReplaceHooks(origin)
+		logger.SetLevel(level)
+		logger.SetOutput(output)
+		_hook = nil
+	}()
+	logger.SetLevel(log.DebugLevel)
+	logger.SetOutput(io.Discard)
+
+	// 其他来源添加的hook在重新加载后保持不变
+	var other = newTestHook(map[log.Level]io.Writer{log.WarnLevel: &bytes.Buffer{}})
+	log.AddHook(other)
+
+	var before = &bytes.Buffer{}
+	setHook(newTestHook(map[log.Level]io.Writer{log.ErrorLevel: before}))
+	log.Info("info before reload")
+	log.Error("error before reload")
+	if strings.Contains(before.String(), "info before reload") || !strings.Contains(before.String(), "error before reload") {
+		t.Fatalf("unexpected output before reload: %q", before.String())
+	}
+
+	// 重新加载后按照新的日志级别分发
+	var after = &bytes.Buffer{}
+	setHook(newTestHook(map[log.Level]io.Writer{log.InfoLevel: after, log.ErrorLevel: after}))
+	before.Reset()
+	log.Info("info after reload")
+	log.Error("error after reload")
+	if !strings.Contains(after.String(), "info after reload") || !strings.Contains(after.String(), "error after reload") {
+		t.Fatalf("reloaded levels should be dispatched: %q", after.String())
+	}
+	if before.Len() > 0 {
+		t.Fatalf("replaced hook should not receive entries: %q", before.String())
+	}
+	if hooks := logger.Hooks[log.WarnLevel]; len(hooks) != 1 || hooks[0] != other {
+		t.Fatal("other hooks should be kept after reload")
+	}
+}
@@ -2,17 +2,23 @@ package logx
 
 import (
 	"context"
-	
+
 	"github.com/gin-gonic/gin"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/go-xuan/quanx/core/ginx"
+	"github.com/go-xuan/quanx/core/tracex"
 )
 
 func GinCtx(ctx *gin.Context) *log.Entry {
 	return ginx.Log(ctx)
 }
 
+// Ctx 上下文日志，上下文中存在链路信息时附加traceId和spanId
 func Ctx(ctx context.Context) *log.Entry {
-	return log.WithContext(ctx)
+	var entry = log.WithContext(ctx)
+	if sc := tracex.SpanContextFromContext(ctx); sc.IsValid() {
+		entry = entry.WithField("traceId", sc.TraceId).WithField("spanId", sc.SpanId)
+	}
+	return entry
 }
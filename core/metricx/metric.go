@@ -0,0 +1,222 @@
+package metricx
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// 指标类型
+const (
+	counterType   = "counter"
+	gaugeType     = "gauge"
+	histogramType = "histogram"
+)
+
+// DefaultBuckets 默认直方图分桶（单位：秒）
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// metric 指标公共部分
+type metric struct {
+	mu     sync.Mutex
+	name   string   // 指标名
+	help   string   // 指标说明
+	kind   string   // 指标类型
+	labels []string // 标签名
+}
+
+func (m *metric) Name() string {
+	return m.name
+}
+
+// 标签值拼接为序列key
+func seriesKey(values []string) string {
+	return strings.Join(values, "\xff")
+}
+
+// 补齐标签值数量
+func fixLabelValues(labels, values []string) []string {
+	if len(values) == len(labels) {
+		return values
+	}
+	var fixed = make([]string, len(labels))
+	copy(fixed, values)
+	return fixed
+}
+
+// Counter 计数器，只增不减
+type Counter struct {
+	metric
+	series map[string]*counterSeries
+}
+
+type counterSeries struct {
+	values []string
+	value  float64
+}
+
+// With 指定标签值
+func (c *Counter) With(values ...string) *CounterChild {
+	return &CounterChild{counter: c, values: fixLabelValues(c.labels, values)}
+}
+
+// Inc 无标签计数器+1
+func (c *Counter) Inc() {
+	c.add(nil, 1)
+}
+
+// Add 无标签计数器累加
+func (c *Counter) Add(v float64) {
+	c.add(nil, v)
+}
+
+func (c *Counter) add(values []string, v float64) {
+	if v < 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var key = seriesKey(values)
+	if s, ok := c.series[key]; ok {
+		s.value += v
+	} else {
+		c.series[key] = &counterSeries{values: values, value: v}
+	}
+}
+
+// CounterChild 指定标签值的计数器
+type CounterChild struct {
+	counter *Counter
+	values  []string
+}
+
+func (c *CounterChild) Inc() {
+	c.counter.add(c.values, 1)
+}
+
+func (c *CounterChild) Add(v float64) {
+	c.counter.add(c.values, v)
+}
+
+// Gauge 仪表盘，可增可减，或者在采集时通过函数取值
+type Gauge struct {
+	metric
+	series map[string]*gaugeSeries
+}
+
+type gaugeSeries struct {
+	values []string
+	value  float64
+	fn     func() float64
+}
+
+// With 指定标签值
+func (g *Gauge) With(values ...string) *GaugeChild {
+	return &GaugeChild{gauge: g, values: fixLabelValues(g.labels, values)}
+}
+
+// Set 设置无标签仪表盘值
+func (g *Gauge) Set(v float64) {
+	g.update(nil, func(s *gaugeSeries) { s.value = v })
+}
+
+// Add 无标签仪表盘累加
+func (g *Gauge) Add(v float64) {
+	g.update(nil, func(s *gaugeSeries) { s.value += v })
+}
+
+func (g *Gauge) update(values []string, f func(s *gaugeSeries)) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	var key = seriesKey(values)
+	s, ok := g.series[key]
+	if !ok {
+		s = &gaugeSeries{values: values}
+		g.series[key] = s
+	}
+	f(s)
+}
+
+// GaugeChild 指定标签值的仪表盘
+type GaugeChild struct {
+	gauge  *Gauge
+	values []string
+}
+
+func (g *GaugeChild) Set(v float64) {
+	g.gauge.update(g.values, func(s *gaugeSeries) { s.value, s.fn = v, nil })
+}
+
+func (g *GaugeChild) Add(v float64) {
+	g.gauge.update(g.values, func(s *gaugeSeries) { s.value += v })
+}
+
+func (g *GaugeChild) Inc() {
+	g.Add(1)
+}
+
+func (g *GaugeChild) Dec() {
+	g.Add(-1)
+}
+
+// Func 设置取值函数，采集时调用，同标签值重复设置将覆盖
+func (g *GaugeChild) Func(fn func() float64) {
+	g.gauge.update(g.values, func(s *gaugeSeries) { s.fn = fn })
+}
+
+// Delete 删除当前标签值的序列
+func (g *GaugeChild) Delete() {
+	g.gauge.mu.Lock()
+	defer g.gauge.mu.Unlock()
+	delete(g.gauge.series, seriesKey(g.values))
+}
+
+// Histogram 直方图
+type Histogram struct {
+	metric
+	buckets []float64
+	series  map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	values []string
+	counts []uint64 // 各分桶计数（非累计）
+	count  uint64
+	sum    float64
+}
+
+// With 指定标签值
+func (h *Histogram) With(values ...string) *HistogramChild {
+	return &HistogramChild{histogram: h, values: fixLabelValues(h.labels, values)}
+}
+
+// Observe 无标签直方图记录观测值
+func (h *Histogram) Observe(v float64) {
+	h.observe(nil, v)
+}
+
+func (h *Histogram) observe(values []string, v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var key = seriesKey(values)
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogramSeries{values: values, counts: make([]uint64, len(h.buckets))}
+		h.series[key] = s
+	}
+	if i := sort.SearchFloat64s(h.buckets, v); i < len(h.buckets) {
+		s.counts[i]++
+	}
+	s.count++
+	s.sum += v
+}
+
+// HistogramChild 指定标签值的直方图
+type HistogramChild struct {
+	histogram *Histogram
+	values    []string
+}
+
+func (h *HistogramChild) Observe(v float64) {
+	h.histogram.observe(h.values, v)
+}
@@ -0,0 +1,42 @@
+package metricx
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry()
+	registry.NewCounter("test_total", "test counter", "method").With("GET").Add(2)
+	registry.NewGauge("test_gauge", "test gauge").Set(3)
+	registry.NewHistogram("test_seconds", "test histogram", []float64{0.1, 1}, "path").With(`/a"b`).Observe(0.5)
+	registry.NewCounter("test_total", "test counter", "method").With("GET").Inc()
+
+	var buf bytes.Buffer
+	if err := registry.WriteText(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var text = buf.String()
+	for _, line := range []string{
+		"# TYPE test_total counter",
+		`test_total{method="GET"} 3`,
+		"test_gauge 3",
+		`test_seconds_bucket{path="/a\"b",le="0.1"} 0`,
+		`test_seconds_bucket{path="/a\"b",le="1"} 1`,
+		`test_seconds_bucket{path="/a\"b",le="+Inf"} 1`,
+		`test_seconds_sum{path="/a\"b"} 0.5`,
+		`test_seconds_count{path="/a\"b"} 1`,
+	} {
+		if !strings.Contains(text, line+"\n") {
+			t.Errorf("missing line %q in:\n%s", line, text)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic on type mismatch")
+		}
+	}()
+	registry.NewGauge("test_total", "test gauge")
+}
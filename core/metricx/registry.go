@@ -0,0 +1,261 @@
+package metricx
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ContentType prometheus文本格式
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+var _registry = NewRegistry()
+
+// collector 指标采集器
+type collector interface {
+	Name() string
+	write(w io.Writer)
+}
+
+// Registry 指标注册表
+type Registry struct {
+	mu         sync.RWMutex
+	collectors map[string]collector
+}
+
+func NewRegistry() *Registry {
+	return &Registry{collectors: make(map[string]collector)}
+}
+
+// 注册指标，同名指标已存在时返回已注册指标
+func (r *Registry) register(name string, newCollector func() collector) collector {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if c, ok := r.collectors[name]; ok {
+		return c
+	}
+	var c = newCollector()
+	r.collectors[name] = c
+	return c
+}
+
+// NewCounter 注册计数器
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	c := r.register(name, func() collector {
+		return &Counter{
+			metric: metric{name: name, help: help, kind: counterType, labels: labels},
+			series: make(map[string]*counterSeries),
+		}
+	})
+	if counter, ok := c.(*Counter); ok {
+		return counter
+	}
+	panic(fmt.Sprintf("metric %s has already registered with another type", name))
+}
+
+// NewGauge 注册仪表盘
+func (r *Registry) NewGauge(name, help string, labels ...string) *Gauge {
+	c := r.register(name, func() collector {
+		return &Gauge{
+			metric: metric{name: name, help: help, kind: gaugeType, labels: labels},
+			series: make(map[string]*gaugeSeries),
+		}
+	})
+	if gauge, ok := c.(*Gauge); ok {
+		return gauge
+	}
+	panic(fmt.Sprintf("metric %s has already registered with another type", name))
+}
+
+// NewHistogram 注册直方图，buckets为空时使用 DefaultBuckets
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	c := r.register(name, func() collector {
+		var sorted = append([]float64{}, buckets...)
+		sort.Float64s(sorted)
+		return &Histogram{
+			metric:  metric{name: name, help: help, kind: histogramType, labels: labels},
+			buckets: sorted,
+			series:  make(map[string]*histogramSeries),
+		}
+	})
+	if histogram, ok := c.(*Histogram); ok {
+		return histogram
+	}
+	panic(fmt.Sprintf("metric %s has already registered with another type", name))
+}
+
+// WriteText 以prometheus文本格式输出全部指标
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.RLock()
+	var names = make([]string, 0, len(r.collectors))
+	for name := range r.collectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var collectors = make([]collector, 0, len(names))
+	for _, name := range names {
+		collectors = append(collectors, r.collectors[name])
+	}
+	r.mu.RUnlock()
+
+	var buf = &bytes.Buffer{}
+	for _, c := range collectors {
+		c.write(buf)
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func (m *metric) writeHeader(w io.Writer) {
+	_, _ = fmt.Fprintf(w, "# HELP %s %s\n", m.name, escapeHelp(m.help))
+	_, _ = fmt.Fprintf(w, "# TYPE %s %s\n", m.name, m.kind)
+}
+
+func (c *Counter) write(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.writeHeader(w)
+	for _, key := range sortedKeys(c.series) {
+		s := c.series[key]
+		writeSample(w, c.name, c.labels, s.values, "", "", s.value)
+	}
+}
+
+func (g *Gauge) write(w io.Writer) {
+	g.mu.Lock()
+	var samples = make([]*gaugeSeries, 0, len(g.series))
+	for _, key := range sortedKeys(g.series) {
+		samples = append(samples, g.series[key])
+	}
+	g.mu.Unlock()
+	g.writeHeader(w)
+	for _, s := range samples {
+		var value = s.value
+		if s.fn != nil {
+			value = s.fn()
+		}
+		writeSample(w, g.name, g.labels, s.values, "", "", value)
+	}
+}
+
+func (h *Histogram) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.writeHeader(w)
+	for _, key := range sortedKeys(h.series) {
+		s := h.series[key]
+		var cumulative uint64
+		for i, upper := range h.buckets {
+			cumulative += s.counts[i]
+			writeSample(w, h.name+"_bucket", h.labels, s.values, "le", formatFloat(upper), float64(cumulative))
+		}
+		writeSample(w, h.name+"_bucket", h.labels, s.values, "le", "+Inf", float64(s.count))
+		writeSample(w, h.name+"_sum", h.labels, s.values, "", "", s.sum)
+		writeSample(w, h.name+"_count", h.labels, s.values, "", "", float64(s.count))
+	}
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	var keys = make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// 输出单条样本，extraName/extraValue用于直方图的le标签
+func writeSample(w io.Writer, name string, labels, values []string, extraName, extraValue string, value float64) {
+	var sb = strings.Builder{}
+	sb.WriteString(name)
+	if len(labels) > 0 || extraName != "" {
+		sb.WriteString("{")
+		for i, label := range labels {
+			if i > 0 {
+				sb.WriteString(",")
+			}
+			var v string
+			if i < len(values) {
+				v = values[i]
+			}
+			sb.WriteString(label + `="` + escapeLabelValue(v) + `"`)
+		}
+		if extraName != "" {
+			if len(labels) > 0 {
+				sb.WriteString(",")
+			}
+			sb.WriteString(extraName + `="` + extraValue + `"`)
+		}
+		sb.WriteString("}")
+	}
+	sb.WriteString(" ")
+	sb.WriteString(formatFloat(value))
+	sb.WriteString("\n")
+	_, _ = io.WriteString(w, sb.String())
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+var (
+	labelValueReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+	helpReplacer       = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabelValue(v string) string {
+	return labelValueReplacer.Replace(v)
+}
+
+func escapeHelp(v string) string {
+	return helpReplacer.Replace(v)
+}
+
+// Default 默认指标注册表
+func Default() *Registry {
+	return _registry
+}
+
+// NewCounter 在默认注册表中注册计数器
+func NewCounter(name, help string, labels ...string) *Counter {
+	return _registry.NewCounter(name, help, labels...)
+}
+
+// NewGauge 在默认注册表中注册仪表盘
+func NewGauge(name, help string, labels ...string) *Gauge {
+	return _registry.NewGauge(name, help, labels...)
+}
+
+// NewHistogram 在默认注册表中注册直方图
+func NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	return _registry.NewHistogram(name, help, buckets, labels...)
+}
+
+// Handler 指标采集接口，输出prometheus文本格式
+func Handler(ctx *gin.Context) {
+	ctx.Header("Content-Type", ContentType)
+	ctx.Status(http.StatusOK)
+	if err := _registry.WriteText(ctx.Writer); err != nil {
+		_ = ctx.Error(err)
+	}
+}
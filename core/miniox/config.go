@@ -11,6 +11,7 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"github.com/go-xuan/quanx/core/configx"
+	"github.com/go-xuan/quanx/core/healthx"
 	"github.com/go-xuan/quanx/os/errorx"
 	"github.com/go-xuan/quanx/types/timex"
 )
@@ -45,6 +46,7 @@ func (m *Config) Execute() error {
 		return errorx.Wrap(err, "new minio client failed")
 	} else {
 		_handler = &Handler{config: m, client: client}
+		healthx.Register(healthComponent, _handler.Ping)
 		log.Info("minio connect success: ", m.Format())
 		return nil
 	}
@@ -13,7 +13,10 @@ import (
 	"github.com/go-xuan/quanx/os/filex"
 )
 
-const Region = "cn-north-1"
+const (
+	Region          = "cn-north-1"
+	healthComponent = "minio"
+)
 
 var _handler *Handler
 
@@ -38,6 +41,18 @@ func (h *Handler) GetClient() *minio.Client {
 	return h.client
 }
 
+// Ping 检查minio服务是否可用
+func (h *Handler) Ping(ctx context.Context) error {
+	if bucket := h.config.BucketName; bucket != "" {
+		if _, err := h.client.BucketExists(ctx, bucket); err != nil {
+			return errorx.Wrap(err, "check bucket exists error")
+		}
+	} else if _, err := h.client.ListBuckets(ctx); err != nil {
+		return errorx.Wrap(err, "list buckets error")
+	}
+	return nil
+}
+
 // GetConfig 获取配置
 func GetConfig() *Config {
 	return this().GetConfig()
@@ -68,11 +68,25 @@ func (c *Config) Execute() error {
 			}
 			_handler.configs[c.Source] = c
 			_handler.clients[c.Source] = client
+			registerHealthChecker(c.Source, client)
 		}
 	}
 	return nil
 }
 
+// Close 断开当前mongo客户端
+func (c *Config) Close() error {
+	if c.Enable && _handler != nil {
+		ctx, cancel := context.WithTimeout(context.TODO(), time.Second*10)
+		defer cancel()
+		if err := _handler.closeSource(ctx, c.Source); err != nil {
+			return errorx.Wrap(err, "close mongo client error")
+		}
+		log.Info("mongo client closed: ", c.Format())
+	}
+	return nil
+}
+
 func (c *Config) NewClient() (*mongo.Client, error) {
 	ctx, cancel := context.WithTimeout(context.TODO(), time.Second*10)
 	defer cancel()
@@ -154,6 +168,7 @@ func (m MultiConfig) Execute() error {
 			} else {
 				_handler.clients[c.Source] = client
 				_handler.configs[c.Source] = c
+				registerHealthChecker(c.Source, client)
 				if i == 0 || c.Source == constx.DefaultSource {
 					_handler.client = client
 					_handler.config = c
@@ -166,3 +181,12 @@ func (m MultiConfig) Execute() error {
 	}
 	return nil
 }
+
+func (m MultiConfig) Close() error {
+	for i := len(m) - 1; i >= 0; i-- {
+		if err := m[i].Close(); err != nil {
+			return errorx.Wrap(err, "close mongo failed")
+		}
+	}
+	return nil
+}
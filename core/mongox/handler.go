@@ -1,11 +1,26 @@
 package mongox
 
 import (
+	"context"
+	"sort"
+
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
 
 	"github.com/go-xuan/quanx/common/constx"
+	"github.com/go-xuan/quanx/core/healthx"
+	"github.com/go-xuan/quanx/os/errorx"
 )
 
+const healthComponent = "mongo"
+
+// 注册mongo健康检查
+func registerHealthChecker(source string, client *mongo.Client) {
+	healthx.Register(healthx.Name(healthComponent, source), func(ctx context.Context) error {
+		return client.Ping(ctx, readpref.PrimaryPreferred())
+	})
+}
+
 var _handler *Handler
 
 func this() *Handler {
@@ -41,6 +56,37 @@ func (h *Handler) GetClient(source ...string) *mongo.Client {
 	return h.client
 }
 
+// 断开指定mongo客户端并移除
+func (h *Handler) closeSource(ctx context.Context, source string) error {
+	if client, ok := h.clients[source]; ok {
+		if err := client.Disconnect(ctx); err != nil {
+			return errorx.Wrap(err, "disconnect mongo client failed")
+		}
+		delete(h.clients, source)
+		delete(h.configs, source)
+		healthx.Unregister(healthx.Name(healthComponent, source))
+		if h.config != nil && h.config.Source == source {
+			h.resetDefault()
+		}
+	}
+	return nil
+}
+
+// 默认客户端被关闭后重新选择默认客户端，优先使用default，其次使用名称排序后的第一个，没有剩余时置空
+func (h *Handler) resetDefault() {
+	h.config, h.client = nil, nil
+	var sources = make([]string, 0, len(h.configs))
+	for source := range h.configs {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+	for _, source := range sources {
+		if h.config == nil || source == constx.DefaultSource {
+			h.config, h.client = h.configs[source], h.clients[source]
+		}
+	}
+}
+
 // IsInitialized 是否初始化
 func IsInitialized() bool {
 	return _handler != nil
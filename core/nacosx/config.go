@@ -1,7 +1,9 @@
 package nacosx
 
 import (
+	"context"
 	"fmt"
+	"net/http"
 	"path/filepath"
 	"strings"
 
@@ -24,13 +26,15 @@ const (
 	ConfigAndNaming        // 配置中心和服务发现都使用
 )
 
+const healthComponent = "nacos"
+
 // Config nacos连接配置
 type Config struct {
-	Address   string `yaml:"address" json:"address" default:"127.0.0.1"`  // nacos服务地址,多个以英文逗号分割
-	Username  string `yaml:"username" json:"username" default:"nacos"`    // 用户名
-	Password  string `yaml:"password" json:"password" default:"nacos"`    // 密码
-	NameSpace string `yaml:"nameSpace" json:"nameSpace" default:"public"` // 命名空间
-	Mode      int    `yaml:"mode" json:"mode" default:"2"`                // 模式（0-仅配置中心；1-仅服务发现；2-配置中心和服务发现）
+	Address   string `yaml:"address" json:"address" default:"127.0.0.1"`          // nacos服务地址,多个以英文逗号分割
+	Username  string `yaml:"username" json:"username" default:"nacos"`            // 用户名
+	Password  string `yaml:"password" json:"password" default:"nacos"`            // 密码
+	NameSpace string `yaml:"nameSpace" json:"nameSpace" default:"public"`         // 命名空间
+	Mode      int    `yaml:"mode" json:"mode" default:"2" validate:"oneof=0 1 2"` // 模式（0-仅配置中心；1-仅服务发现；2-配置中心和服务发现）
 }
 
 func (c *Config) Format() string {
@@ -43,37 +47,33 @@ func (*Config) Reader() *configx.Reader {
 }
 
 func (c *Config) Execute() error {
-	if handler == nil {
-		handler = &Handler{config: c}
-		var param = c.ClientParam()
-		switch c.Mode {
-		case OnlyConfig:
-			if configClient, err := c.ConfigClient(param); err != nil {
-				return errorx.Wrap(err, "init nacos config client error")
-			} else {
-				handler.configClient = configClient
-			}
-		case OnlyNaming:
-			if namingClient, err := c.NamingClient(param); err != nil {
-				return errorx.Wrap(err, "init nacos naming client error")
-			} else {
-				handler.namingClient = namingClient
-			}
-		case ConfigAndNaming:
-			if configClient, err := c.ConfigClient(param); err != nil {
-				return errorx.Wrap(err, "init nacos config client error")
-			} else {
-				handler.configClient = configClient
-			}
-			if namingClient, err := c.NamingClient(param); err != nil {
-				return errorx.Wrap(err, "init nacos naming client error")
-			} else {
-				handler.namingClient = namingClient
-			}
+	return Default().execute(c)
+}
+
+// Ping 检查nacos服务是否就绪，多个地址时任一可用即视为健康
+func (c *Config) Ping(ctx context.Context) error {
+	var err error
+	for _, server := range c.ServerConfigs() {
+		var port = server.Port
+		if port == 0 {
+			port = 8848
+		}
+		url := fmt.Sprintf("http://%s:%d%s/v1/console/health/readiness", server.IpAddr, port, server.ContextPath)
+		var req *http.Request
+		if req, err = http.NewRequestWithContext(ctx, http.MethodGet, url, nil); err != nil {
+			return errorx.Wrap(err, "new nacos health request failed")
 		}
+		var resp *http.Response
+		if resp, err = http.DefaultClient.Do(req); err != nil {
+			continue
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			return nil
+		}
+		err = errorx.Errorf("nacos readiness status: %d", resp.StatusCode)
 	}
-	log.Info("nacos connect success: ", c.Format())
-	return nil
+	return errorx.Wrap(err, "nacos server is unavailable")
 }
 
 // AddressUrl nacos访问地址
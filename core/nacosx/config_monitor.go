@@ -69,6 +69,22 @@ func (m *ConfigMonitor) Set(group, dataId, content string) {
 	return
 }
 
+// 保存首次读取的配置内容，已存在时不覆盖
+func (m *ConfigMonitor) init(group, dataId, content string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var key = getKey(group, dataId)
+	if _, exist := m.data[key]; !exist {
+		m.data[key] = &ConfigData{
+			group:   group,
+			dataId:  dataId,
+			content: content,
+			modify:  time.Now().UnixMilli(),
+		}
+		m.num++
+	}
+}
+
 // Get 获取nacos配置
 func (m *ConfigMonitor) Get(group, dataId string) (data *ConfigData, exist bool) {
 	m.mu.Lock()
@@ -4,19 +4,30 @@ import (
 	"fmt"
 	"reflect"
 
+	"github.com/nacos-group/nacos-sdk-go/clients/config_client"
 	"github.com/nacos-group/nacos-sdk-go/vo"
 	log "github.com/sirupsen/logrus"
 
+	"github.com/go-xuan/quanx/core/configx"
 	"github.com/go-xuan/quanx/os/errorx"
 	"github.com/go-xuan/quanx/utils/marshalx"
 )
 
 // Scanner nacos配置扫描器
 type Scanner struct {
-	Type   vo.ConfigType `yaml:"type"`   // 配置类型
-	Group  string        `yaml:"group"`  // 配置分组
-	DataId string        `yaml:"dataId"` // 配置文件ID
-	Listen bool          `yaml:"listen"` // 是否启用监听
+	Type   vo.ConfigType               `yaml:"type"`   // 配置类型
+	Group  string                      `yaml:"group"`  // 配置分组
+	DataId string                      `yaml:"dataId"` // 配置文件ID
+	Listen bool                        `yaml:"listen"` // 是否启用监听
+	client config_client.IConfigClient // 配置中心客户端，为空时使用默认句柄的客户端
+}
+
+// 配置中心客户端
+func (s *Scanner) configClient() config_client.IConfigClient {
+	if s.client != nil {
+		return s.client
+	}
+	return GetNacosConfigClient()
 }
 
 func (s *Scanner) Info() string {
@@ -35,7 +46,7 @@ func (s *Scanner) Scan(v any) error {
 		Type:   s.Type,
 	}
 	// 读取Nacos配置文本
-	content, err := GetNacosConfigClient().GetConfig(param)
+	content, err := s.configClient().GetConfig(param)
 	if err != nil {
 		log.Error("get nacos config content failed: ", s.Info(), err)
 		return errorx.Wrap(err, "get nacos config content failed")
@@ -55,11 +66,12 @@ func (s *Scanner) Scan(v any) error {
 			log.WithField("dataId", dataId).
 				WithField("group", group).
 				WithField("namespace", namespace).
-				WithField("content", content).
-				Error("the nacos config content has changed !!!")
+				Info("the nacos config content has changed !!!")
 			GetConfigMonitor().Set(group, dataId, data)
+			// 发布配置变更事件，触发订阅者热加载
+			configx.Publish(configx.NacosKey(group, dataId), []byte(data))
 		}
-		if err = GetNacosConfigClient().ListenConfig(param); err != nil {
+		if err = s.configClient().ListenConfig(param); err != nil {
 			log.Error("listen nacos config failed: ", s.Info(), err)
 			return errorx.Wrap(err, "listen nacos config failed")
 		} else {
@@ -6,6 +6,8 @@ import (
 	"github.com/nacos-group/nacos-sdk-go/vo"
 	log "github.com/sirupsen/logrus"
 
+	"github.com/go-xuan/quanx/core/configx"
+	"github.com/go-xuan/quanx/core/healthx"
 	"github.com/go-xuan/quanx/os/errorx"
 	"github.com/go-xuan/quanx/os/filex"
 	"github.com/go-xuan/quanx/types/anyx"
@@ -20,19 +22,123 @@ func this() *Handler {
 	return handler
 }
 
+// Default 包级别默认nacos句柄，ScanConfig()/Register()等包级别函数以及 Config.Execute() 均在此句柄上操作，首次调用时创建
+func Default() *Handler {
+	if handler == nil {
+		handler = NewHandler(nil)
+	}
+	return handler
+}
+
+// SetDefault 替换包级别默认nacos句柄，nacos配置源以及服务注册同样使用新句柄上的客户端
+func SetDefault(h *Handler) {
+	handler = h
+}
+
+// Handler nacos客户端句柄，持有配置中心以及服务发现客户端，每个Engine使用各自的句柄以及健康检查注册表
 type Handler struct {
 	config       *Config                     // nacos配置
 	configClient config_client.IConfigClient // nacos配置中心客户端
 	namingClient naming_client.INamingClient // nacos服务发现客户端
+	health       *healthx.Registry           // nacos健康检查注册表
 }
 
-// ScanConfig 从nacos获取配置并扫描
-func ScanConfig(v any, group, dataId string, listen ...bool) error {
+// NewHandler 创建nacos客户端句柄，health为nacos健康检查注册表，为空时使用healthx默认注册表
+func NewHandler(health *healthx.Registry) *Handler {
+	if health == nil {
+		health = healthx.Default()
+	}
+	return &Handler{health: health}
+}
+
+// IsInitialized 是否已创建nacos客户端
+func (h *Handler) IsInitialized() bool {
+	return h.config != nil
+}
+
+// Accept 是否为nacos配置器
+func (h *Handler) Accept(conf configx.Configurator) bool {
+	_, ok := conf.(*Config)
+	return ok
+}
+
+// Execute 使用当前句柄运行nacos配置器
+func (h *Handler) Execute(conf configx.Configurator) error {
+	if c, ok := conf.(*Config); ok {
+		return h.execute(c)
+	}
+	return errorx.Errorf("unsupported configurator: %T", conf)
+}
+
+// Reload nacos配置不支持热加载，已初始化时保持不变
+func (h *Handler) Reload(conf configx.Configurator) error {
+	return h.Execute(conf)
+}
+
+// Shutdown nacos客户端无需释放
+func (h *Handler) Shutdown(configx.Configurator) error {
+	return nil
+}
+
+func (h *Handler) execute(c *Config) error {
+	if h.config == nil {
+		var param = c.ClientParam()
+		switch c.Mode {
+		case OnlyConfig:
+			if configClient, err := c.ConfigClient(param); err != nil {
+				return errorx.Wrap(err, "init nacos config client error")
+			} else {
+				h.configClient = configClient
+			}
+		case OnlyNaming:
+			if namingClient, err := c.NamingClient(param); err != nil {
+				return errorx.Wrap(err, "init nacos naming client error")
+			} else {
+				h.namingClient = namingClient
+			}
+		case ConfigAndNaming:
+			if configClient, err := c.ConfigClient(param); err != nil {
+				return errorx.Wrap(err, "init nacos config client error")
+			} else {
+				h.configClient = configClient
+			}
+			if namingClient, err := c.NamingClient(param); err != nil {
+				return errorx.Wrap(err, "init nacos naming client error")
+			} else {
+				h.namingClient = namingClient
+			}
+		}
+		h.config = c
+	}
+	h.health.Register(healthComponent, c.Ping)
+	log.Info("nacos connect success: ", c.Format())
+	return nil
+}
+
+// ConfigClient 获取配置中心客户端
+func (h *Handler) ConfigClient() config_client.IConfigClient {
+	if h.configClient == nil {
+		panic("the nacos config client has not been initialized")
+	}
+	return h.configClient
+}
+
+// NamingClient 获取服务中心客户端
+func (h *Handler) NamingClient() naming_client.INamingClient {
+	if h.namingClient == nil {
+		panic("the nacos naming client has not been initialized")
+	}
+	return h.namingClient
+}
+
+// ScanConfig 使用当前句柄从nacos获取配置并扫描
+func (h *Handler) ScanConfig(v any, group, dataId string, listen ...bool) error {
 	var scanner = &Scanner{
 		Group:  group,
 		DataId: dataId,
 		Type:   vo.ConfigType(filex.GetSuffix(dataId)),
 		Listen: anyx.Default(false, listen...),
+		client: h.ConfigClient(),
 	}
 	if err := scanner.Scan(v); err != nil {
 		return errorx.Wrap(err, "nacos config scan failed")
@@ -40,27 +146,16 @@ func ScanConfig(v any, group, dataId string, listen ...bool) error {
 	return nil
 }
 
-// GetNacosConfigClient 获取配置中心客户端
-func GetNacosConfigClient() config_client.IConfigClient {
-	if client := this().configClient; client == nil {
-		panic("the nacos config client has not been initialized")
-	} else {
-		return client
-	}
-}
-
-// GetNacosNamingClient 获取服务中心客户端
-func GetNacosNamingClient() naming_client.INamingClient {
-	if client := this().namingClient; client == nil {
-		panic("the nacos naming client has not been initialized")
-	} else {
-		return client
-	}
+// Source 使用当前句柄读取配置的nacos配置源
+func (h *Handler) Source(group string) *Source {
+	var source = NewSource(group)
+	source.handler = h
+	return source
 }
 
 // Register 注册Nacos服务
-func Register(server ServerInstance) error {
-	if ok, err := GetNacosNamingClient().RegisterInstance(vo.RegisterInstanceParam{
+func (h *Handler) Register(server ServerInstance) error {
+	if ok, err := h.NamingClient().RegisterInstance(vo.RegisterInstanceParam{
 		Ip:          server.Host,
 		Port:        uint64(server.Port),
 		GroupName:   server.Group,
@@ -79,8 +174,8 @@ func Register(server ServerInstance) error {
 }
 
 // Deregister 注销nacos服务
-func Deregister(server ServerInstance) error {
-	if ok, err := GetNacosNamingClient().DeregisterInstance(vo.DeregisterInstanceParam{
+func (h *Handler) Deregister(server ServerInstance) error {
+	if ok, err := h.NamingClient().DeregisterInstance(vo.DeregisterInstanceParam{
 		Ip:          server.Host,
 		Port:        uint64(server.Port),
 		GroupName:   server.Group,
@@ -94,3 +189,28 @@ func Deregister(server ServerInstance) error {
 		return nil
 	}
 }
+
+// ScanConfig 从nacos获取配置并扫描
+func ScanConfig(v any, group, dataId string, listen ...bool) error {
+	return this().ScanConfig(v, group, dataId, listen...)
+}
+
+// GetNacosConfigClient 获取配置中心客户端
+func GetNacosConfigClient() config_client.IConfigClient {
+	return this().ConfigClient()
+}
+
+// GetNacosNamingClient 获取服务中心客户端
+func GetNacosNamingClient() naming_client.INamingClient {
+	return this().NamingClient()
+}
+
+// Register 注册Nacos服务
+func Register(server ServerInstance) error {
+	return this().Register(server)
+}
+
+// Deregister 注销nacos服务
+func Deregister(server ServerInstance) error {
+	return this().Deregister(server)
+}
@@ -16,3 +16,17 @@ func TestNacos(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestConfigMonitor(t *testing.T) {
+	var m = &ConfigMonitor{data: make(map[string]*ConfigData)}
+	m.init("DEFAULT_GROUP", "app.yaml", "port: 8080")
+	// 首次读取的内容作为比对基准，重复读取不覆盖
+	m.init("DEFAULT_GROUP", "app.yaml", "port: 9090")
+	if data, exist := m.Get("DEFAULT_GROUP", "app.yaml"); !exist || data.content != "port: 8080" || data.changed {
+		t.Fatalf("unexpected initial config: %+v", data)
+	}
+	m.Set("DEFAULT_GROUP", "app.yaml", "port: 9090")
+	if data, _ := m.Get("DEFAULT_GROUP", "app.yaml"); data.content != "port: 9090" || !data.changed {
+		t.Fatalf("config change should be recorded: %+v", data)
+	}
+}
@@ -0,0 +1,110 @@
+package nacosx
+
+import (
+	"sync"
+
+	"github.com/nacos-group/nacos-sdk-go/clients/config_client"
+	"github.com/nacos-group/nacos-sdk-go/vo"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/go-xuan/quanx/core/configx"
+	"github.com/go-xuan/quanx/os/errorx"
+	"github.com/go-xuan/quanx/os/filex"
+	"github.com/go-xuan/quanx/types/stringx"
+)
+
+// Source nacos配置源，读取 Reader.NacosDataId，Reader.NacosGroup为空时使用默认分组
+type Source struct {
+	mu        sync.Mutex
+	handler   *Handler        // nacos句柄，为空时使用默认句柄
+	group     string          // 默认分组
+	listening map[string]bool // 已监听的配置
+}
+
+// NewSource 创建nacos配置源
+func NewSource(group string) *Source {
+	return &Source{group: group, listening: make(map[string]bool)}
+}
+
+// 配置中心客户端
+func (s *Source) client() config_client.IConfigClient {
+	if s.handler != nil {
+		return s.handler.ConfigClient()
+	}
+	return GetNacosConfigClient()
+}
+
+// Group 配置分组
+func (s *Source) Group(reader *configx.Reader) string {
+	return stringx.IfZero(reader.NacosGroup, s.group)
+}
+
+func (s *Source) Name(reader *configx.Reader) string {
+	return configx.NacosKey(s.Group(reader), reader.NacosDataId)
+}
+
+func (s *Source) Get(reader *configx.Reader, _ any) (any, error) {
+	if reader.NacosDataId == "" {
+		return nil, nil
+	}
+	content, err := s.fetch(reader)
+	if err != nil {
+		return nil, err
+	} else if content == "" {
+		return nil, nil
+	}
+	return configx.ParseTree(reader.NacosDataId, []byte(content))
+}
+
+// 读取配置内容，首次读取的内容保存到 ConfigMonitor，作为后续变更的比对基准
+func (s *Source) fetch(reader *configx.Reader) (string, error) {
+	var group, dataId = s.Group(reader), reader.NacosDataId
+	content, err := s.client().GetConfig(vo.ConfigParam{
+		DataId: dataId,
+		Group:  group,
+		Type:   vo.ConfigType(filex.GetSuffix(dataId)),
+	})
+	if err != nil {
+		return "", errorx.Wrap(err, "get nacos config content failed")
+	}
+	GetConfigMonitor().init(group, dataId, content)
+	return content, nil
+}
+
+// Watch 监听nacos配置，配置变更时发布 configx.NacosKey() 变更事件
+func (s *Source) Watch(reader *configx.Reader, onChange func()) error {
+	if reader.NacosDataId == "" {
+		return nil
+	}
+	var group, dataId = s.Group(reader), reader.NacosDataId
+	var key = configx.NacosKey(group, dataId)
+	configx.Subscribe(key, func([]byte) { onChange() })
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.listening[key] {
+		return nil
+	}
+	if _, exist := GetConfigMonitor().Get(group, dataId); !exist {
+		if _, err := s.fetch(reader); err != nil {
+			return err
+		}
+	}
+	if err := s.client().ListenConfig(vo.ConfigParam{
+		DataId: dataId,
+		Group:  group,
+		Type:   vo.ConfigType(filex.GetSuffix(dataId)),
+		OnChange: func(namespace, group, dataId, data string) {
+			log.WithField("dataId", dataId).
+				WithField("group", group).
+				WithField("namespace", namespace).
+				Info("the nacos config content has changed !!!")
+			GetConfigMonitor().Set(group, dataId, data)
+			configx.Publish(configx.NacosKey(group, dataId), []byte(data))
+		},
+	}); err != nil {
+		return errorx.Wrap(err, "listen nacos config failed")
+	}
+	s.listening[key] = true
+	return nil
+}
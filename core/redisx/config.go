@@ -1,7 +1,6 @@
 package redisx
 
 import (
-	"context"
 	"fmt"
 	"net"
 	"strconv"
@@ -10,10 +9,8 @@ import (
 	"github.com/redis/go-redis/v9"
 	log "github.com/sirupsen/logrus"
 
-	"github.com/go-xuan/quanx/common/constx"
 	"github.com/go-xuan/quanx/core/configx"
 	"github.com/go-xuan/quanx/os/errorx"
-	"github.com/go-xuan/quanx/types/anyx"
 )
 
 const (
@@ -23,16 +20,16 @@ const (
 )
 
 type Config struct {
-	Source     string `json:"source" yaml:"source" default:"default"` // 数据源名称
-	Enable     bool   `json:"enable" yaml:"enable"`                   // 数据源启用
-	Mode       int    `json:"mode" yaml:"mode" default:"0"`           // 模式（0-单机；1-集群；3-哨兵。默认单机模式）
-	Host       string `json:"host" yaml:"host"`                       // 主机（单机模式使用）
-	Port       int    `json:"port" yaml:"port" default:"6379"`        // 端口
-	Username   string `json:"username" yaml:"username"`               // 用户名
-	Password   string `json:"password" yaml:"password"`               // 密码
-	Database   int    `json:"database" yaml:"database" default:"0"`   // 数据库，默认0
-	MasterName string `json:"masterName" yaml:"masterName"`           // 哨兵模式主服务器名称
-	PoolSize   int    `json:"poolSize" yaml:"poolSize"`               // 池大小
+	Source     string `json:"source" yaml:"source" default:"default"`                     // 数据源名称
+	Enable     bool   `json:"enable" yaml:"enable"`                                       // 数据源启用
+	Mode       int    `json:"mode" yaml:"mode" default:"0" validate:"oneof=0 1 2"`        // 模式（0-单机；1-集群；3-哨兵。默认单机模式）
+	Host       string `json:"host" yaml:"host"`                                           // 主机（单机模式使用）
+	Port       int    `json:"port" yaml:"port" default:"6379" validate:"min=0,max=65535"` // 端口
+	Username   string `json:"username" yaml:"username"`                                   // 用户名
+	Password   string `json:"password" yaml:"password"`                                   // 密码
+	Database   int    `json:"database" yaml:"database" default:"0" validate:"min=0"`      // 数据库，默认0
+	MasterName string `json:"masterName" yaml:"masterName"`                               // 哨兵模式主服务器名称
+	PoolSize   int    `json:"poolSize" yaml:"poolSize" validate:"min=0"`                  // 池大小
 }
 
 func (c *Config) Format() string {
@@ -44,36 +41,40 @@ func (*Config) Reader() *configx.Reader {
 	return &configx.Reader{
 		FilePath:    "redis.yaml",
 		NacosDataId: "redis.yaml",
-		Listen:      false,
+		Listen:      true,
 	}
 }
 
+// Validate 校验启用的redis必须设置主机，哨兵模式必须设置主服务器名称
+func (c *Config) Validate() error {
+	if !c.Enable {
+		return nil
+	}
+	var err = &configx.ValidationError{}
+	if c.Host == "" {
+		err.Add("host", "is required")
+	}
+	if c.Mode == Sentinel && c.MasterName == "" {
+		err.Add("masterName", "is required in sentinel mode")
+	}
+	return err.Err()
+}
+
 func (c *Config) Execute() error {
-	if c.Enable {
-		if err := anyx.SetDefaultValue(c); err != nil {
-			return errorx.Wrap(err, "set default value error")
-		}
-		client := c.NewRedisClient()
-		if result, err := client.Ping(context.TODO()).Result(); err != nil || result != "PONG" {
-			return errorx.Wrap(err, "redis client ping error")
-		}
-		if _handler == nil {
-			_handler = &Handler{
-				multi: false, config: c, client: client,
-				configs: make(map[string]*Config),
-				clients: make(map[string]redis.UniversalClient),
-			}
-		} else {
-			_handler.multi = true
-			if c.Source == constx.DefaultSource {
-				_handler.config = c
-				_handler.client = client
-			}
-		}
-		_handler.configs[c.Source] = c
-		_handler.clients[c.Source] = client
+	return Default().execute(c)
+}
+
+// Close 关闭当前redis客户端
+func (c *Config) Close() error {
+	if _handler == nil {
+		return nil
 	}
-	return nil
+	return _handler.close(c)
+}
+
+// Reload 重新加载redis客户端，新客户端就绪后替换旧客户端，旧客户端延迟关闭
+func (c *Config) Reload() error {
+	return Default().reload(c)
 }
 
 func (c *Config) Address() string {
@@ -93,21 +94,26 @@ func (c *Config) NewRedisClient() redis.UniversalClient {
 		PoolSize:   c.PoolSize,
 		DB:         c.Database,
 	}
+	var client redis.UniversalClient
 	switch c.Mode {
 	case StandAlone:
 		opts.Addrs = []string{net.JoinHostPort(c.Host, strconv.Itoa(c.Port))}
-		return redis.NewClient(opts.Simple())
+		client = redis.NewClient(opts.Simple())
 	case Cluster:
 		opts.Addrs = strings.Split(c.Host, ",")
-		return redis.NewClusterClient(opts.Cluster())
+		client = redis.NewClusterClient(opts.Cluster())
 	case Sentinel:
 		opts.Addrs = []string{net.JoinHostPort(c.Host, strconv.Itoa(c.Port))}
 		opts.MasterName = c.MasterName
-		return redis.NewFailoverClient(opts.Failover())
+		client = redis.NewFailoverClient(opts.Failover())
 	default:
 		log.Warn("redis mode is invalid: ")
 		return nil
 	}
+	client.AddHook(&metricsHook{source: c.Source})
+	client.AddHook(&traceHook{source: c.Source})
+	registerPoolMetrics(c.Source, client)
+	return client
 }
 
 // MultiConfig redis多连接配置
@@ -132,41 +138,34 @@ func (MultiConfig) Reader() *configx.Reader {
 	return &configx.Reader{
 		FilePath:    "redis.yaml",
 		NacosDataId: "redis.yaml",
-		Listen:      false,
+		Listen:      true,
 	}
 }
 
-func (m MultiConfig) Execute() error {
-	if len(m) == 0 {
-		return errorx.New("redis not connected! cause: redis.yaml is invalid")
-	}
-	if _handler == nil {
-		_handler = &Handler{
-			configs: make(map[string]*Config),
-			clients: make(map[string]redis.UniversalClient),
-		}
-	}
-	_handler.multi = true
-	var ctx = context.Background()
-	for i, c := range m {
-		if c.Enable {
-			if err := anyx.SetDefaultValue(c); err != nil {
-				return errorx.Wrap(err, "set default value error")
-			}
-			var client = c.NewRedisClient()
-			if result, err := client.Ping(ctx).Result(); err != nil || result != "PONG" {
-				return errorx.Wrap(err, "redis client ping error")
-			}
-			_handler.clients[c.Source] = client
-			_handler.configs[c.Source] = c
-			if i == 0 || c.Source == constx.DefaultSource {
-				_handler.client = client
-				_handler.config = c
-			}
+// Validate 校验数据源名称不能重复
+func (m MultiConfig) Validate() error {
+	var sources = make(map[string]bool)
+	for _, c := range m {
+		if c.Enable && sources[c.Source] {
+			return errorx.Errorf("source is duplicated: %s", c.Source)
 		}
-	}
-	if len(_handler.configs) == 0 {
-		log.Error("redis connect failed! cause: redis.yaml is empty or no enabled redis configured")
+		sources[c.Source] = true
 	}
 	return nil
 }
+
+func (m MultiConfig) Execute() error {
+	return Default().executeMulti(m)
+}
+
+func (m MultiConfig) Close() error {
+	if _handler == nil {
+		return nil
+	}
+	return _handler.closeMulti(m)
+}
+
+// Reload 重新加载多redis客户端，已移除的数据源将被关闭
+func (m MultiConfig) Reload() error {
+	return Default().reloadMulti(m)
+}
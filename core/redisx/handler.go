@@ -2,12 +2,32 @@ package redisx
 
 import (
 	"context"
+	"sort"
+	"sync"
+	"time"
 
 	"github.com/redis/go-redis/v9"
-	
+	log "github.com/sirupsen/logrus"
+
 	"github.com/go-xuan/quanx/common/constx"
+	"github.com/go-xuan/quanx/core/configx"
+	"github.com/go-xuan/quanx/core/healthx"
+	"github.com/go-xuan/quanx/os/errorx"
+	"github.com/go-xuan/quanx/types/anyx"
 )
 
+const healthComponent = "redis"
+
+// 注册redis健康检查
+func (h *Handler) registerHealthChecker(source string, client redis.UniversalClient) {
+	h.health.Register(healthx.Name(healthComponent, source), func(ctx context.Context) error {
+		return client.Ping(ctx).Err()
+	})
+}
+
+// 热加载后旧客户端延迟关闭的等待时间，确保执行中的命令完成
+const drainDelay = 30 * time.Second
+
 var _handler *Handler
 
 func this() *Handler {
@@ -17,16 +37,186 @@ func this() *Handler {
 	return _handler
 }
 
-// Handler redis连接句柄
+// Default 包级别默认redis句柄，GetClient()等包级别函数以及 Config.Execute() 均在此句柄上操作，首次调用时创建
+func Default() *Handler {
+	if _handler == nil {
+		_handler = NewHandler(nil)
+	}
+	return _handler
+}
+
+// SetDefault 替换包级别默认redis句柄，cachex未指定redis句柄的redis缓存同样使用新句柄
+func SetDefault(h *Handler) {
+	_handler = h
+}
+
+// Handler redis连接句柄，按照数据源名称持有redis客户端，每个Engine使用各自的句柄以及健康检查注册表
 type Handler struct {
+	mu      sync.RWMutex
 	multi   bool
 	config  *Config
 	client  redis.UniversalClient
 	configs map[string]*Config
 	clients map[string]redis.UniversalClient
+	health  *healthx.Registry // redis健康检查注册表
+}
+
+// NewHandler 创建redis连接句柄，health为redis健康检查注册表，为空时使用healthx默认注册表
+func NewHandler(health *healthx.Registry) *Handler {
+	if health == nil {
+		health = healthx.Default()
+	}
+	return &Handler{
+		configs: make(map[string]*Config),
+		clients: make(map[string]redis.UniversalClient),
+		health:  health,
+	}
+}
+
+// IsInitialized 是否已连接任一redis
+func (h *Handler) IsInitialized() bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.clients) > 0
+}
+
+// Accept 是否为单redis *Config 或者多redis MultiConfig
+func (h *Handler) Accept(conf configx.Configurator) bool {
+	return configx.Accepts[*Config, MultiConfig](conf)
+}
+
+// Execute 创建客户端并检查连接，连接失败的客户端不会被保留
+func (h *Handler) Execute(conf configx.Configurator) error {
+	return configx.Dispatch(conf, h.execute, h.executeMulti)
+}
+
+// Reload 重新创建变更的客户端并移除已删除的数据源，被替换的旧客户端延迟关闭
+func (h *Handler) Reload(conf configx.Configurator) error {
+	return configx.Dispatch(conf, h.reload, h.reloadMulti)
+}
+
+// Shutdown 按照配置逆序关闭redis客户端
+func (h *Handler) Shutdown(conf configx.Configurator) error {
+	return configx.Dispatch(conf, h.close, h.closeMulti)
+}
+
+// 创建redis客户端并检查连接
+func newPingedClient(c *Config) (redis.UniversalClient, error) {
+	if err := anyx.SetDefaultValue(c); err != nil {
+		return nil, errorx.Wrap(err, "set default value error")
+	}
+	client := c.NewRedisClient()
+	if client == nil {
+		return nil, errorx.Errorf("redis mode is invalid: %d", c.Mode)
+	}
+	if result, err := client.Ping(context.TODO()).Result(); err != nil || result != "PONG" {
+		_ = client.Close()
+		return nil, errorx.Wrap(err, "redis client ping error")
+	}
+	return client, nil
+}
+
+func (h *Handler) execute(c *Config) error {
+	if !c.Enable {
+		return nil
+	}
+	client, err := newPingedClient(c)
+	if err != nil {
+		return err
+	}
+	drainClient(c.Source, h.swapSource(c, client, c.Source == constx.DefaultSource))
+	h.registerHealthChecker(c.Source, client)
+	return nil
+}
+
+func (h *Handler) executeMulti(m MultiConfig) error {
+	if len(m) == 0 {
+		return errorx.New("redis not connected! cause: redis.yaml is invalid")
+	}
+	h.mu.Lock()
+	h.multi = true
+	h.mu.Unlock()
+	for i, c := range m {
+		if c.Enable {
+			client, err := newPingedClient(c)
+			if err != nil {
+				return err
+			}
+			drainClient(c.Source, h.swapSource(c, client, i == 0 || c.Source == constx.DefaultSource))
+			h.registerHealthChecker(c.Source, client)
+		}
+	}
+	if !h.IsInitialized() {
+		log.Error("redis connect failed! cause: redis.yaml is empty or no enabled redis configured")
+	}
+	return nil
+}
+
+func (h *Handler) close(c *Config) error {
+	if c.Enable {
+		if err := h.closeSource(c.Source); err != nil {
+			return errorx.Wrap(err, "close redis client error")
+		}
+		log.Info("redis client closed: ", c.Format())
+	}
+	return nil
+}
+
+func (h *Handler) closeMulti(m MultiConfig) error {
+	for i := len(m) - 1; i >= 0; i-- {
+		if err := h.close(m[i]); err != nil {
+			return errorx.Wrap(err, "close redis failed")
+		}
+	}
+	return nil
+}
+
+func (h *Handler) reload(c *Config) error {
+	if !h.IsInitialized() {
+		return h.execute(c)
+	}
+	return h.reloadSource(c, c.Source == constx.DefaultSource)
+}
+
+func (h *Handler) reloadSource(c *Config, isDefault bool) error {
+	if !c.Enable {
+		return h.closeSource(c.Source)
+	}
+	client, err := newPingedClient(c)
+	if err != nil {
+		return err
+	}
+	old := h.swapSource(c, client, isDefault)
+	h.registerHealthChecker(c.Source, client)
+	drainClient(c.Source, old)
+	log.Info("redis reload success: ", c.Format())
+	return nil
+}
+
+func (h *Handler) reloadMulti(m MultiConfig) error {
+	if !h.IsInitialized() {
+		return h.executeMulti(m)
+	}
+	var sources = make(map[string]bool)
+	for i, c := range m {
+		if err := h.reloadSource(c, i == 0 || c.Source == constx.DefaultSource); err != nil {
+			return errorx.Wrap(err, "reload redis failed")
+		}
+		sources[c.Source] = true
+	}
+	for _, source := range h.Sources() {
+		if !sources[source] {
+			if err := h.closeSource(source); err != nil {
+				return errorx.Wrap(err, "close removed redis failed")
+			}
+		}
+	}
+	return nil
 }
 
 func (h *Handler) GetClient(source ...string) redis.UniversalClient {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
 	if h.multi && len(source) > 0 && source[0] != constx.DefaultSource {
 		if client, ok := h.clients[source[0]]; ok {
 			return client
@@ -36,6 +226,8 @@ func (h *Handler) GetClient(source ...string) redis.UniversalClient {
 }
 
 func (h *Handler) GetConfig(source ...string) *Config {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
 	if h.multi && len(source) > 0 && source[0] != constx.DefaultSource {
 		if conf, ok := h.configs[source[0]]; ok {
 			return conf
@@ -44,9 +236,79 @@ func (h *Handler) GetConfig(source ...string) *Config {
 	return h.config
 }
 
-// IsInitialized 是否初始化
+// 关闭指定redis客户端并移除
+func (h *Handler) closeSource(source string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if client, ok := h.clients[source]; ok {
+		if err := client.Close(); err != nil {
+			return errorx.Wrap(err, "close redis client failed")
+		}
+		delete(h.clients, source)
+		delete(h.configs, source)
+		h.health.Unregister(healthx.Name(healthComponent, source))
+		if h.config != nil && h.config.Source == source {
+			h.resetDefault()
+		}
+	}
+	return nil
+}
+
+// 默认客户端被关闭后重新选择默认客户端，优先使用default，其次使用名称排序后的第一个，没有剩余时置空
+func (h *Handler) resetDefault() {
+	h.config, h.client = nil, nil
+	var sources = make([]string, 0, len(h.configs))
+	for source := range h.configs {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+	for _, source := range sources {
+		if h.config == nil || source == constx.DefaultSource {
+			h.config, h.client = h.configs[source], h.clients[source]
+		}
+	}
+}
+
+// 替换redis客户端，返回被替换的旧客户端
+func (h *Handler) swapSource(config *Config, client redis.UniversalClient, isDefault bool) redis.UniversalClient {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var old = h.clients[config.Source]
+	h.clients[config.Source] = client
+	h.configs[config.Source] = config
+	if isDefault || h.config == nil || h.config.Source == config.Source {
+		h.config, h.client = config, client
+	}
+	h.multi = h.multi || len(h.clients) > 1
+	return old
+}
+
+// Sources 所有数据源
+func (h *Handler) Sources() []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	var sources []string
+	for source := range h.configs {
+		sources = append(sources, source)
+	}
+	return sources
+}
+
+// 延迟关闭被替换的旧客户端
+func drainClient(source string, client redis.UniversalClient) {
+	if client == nil {
+		return
+	}
+	time.AfterFunc(drainDelay, func() {
+		if err := client.Close(); err != nil {
+			log.WithField("source", source).Error("close replaced redis client failed: ", err)
+		}
+	})
+}
+
+// IsInitialized 默认句柄是否已连接任一redis
 func IsInitialized() bool {
-	return _handler != nil
+	return _handler != nil && _handler.IsInitialized()
 }
 
 // GetConfig 获取配置
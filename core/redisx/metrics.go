@@ -0,0 +1,69 @@
+package redisx
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/go-xuan/quanx/core/metricx"
+)
+
+var (
+	redisCommandsTotal = metricx.NewCounter("redis_commands_total",
+		"Total number of redis commands", "source", "command", "status")
+	redisCommandDuration = metricx.NewHistogram("redis_command_duration_seconds",
+		"Redis command latency in seconds", nil, "source", "command")
+	redisPoolConnections = metricx.NewGauge("redis_pool_connections",
+		"Number of redis pool connections by state", "source", "state")
+)
+
+// metricsHook redis指标采集钩子，记录命令耗时以及执行结果
+type metricsHook struct {
+	source string
+}
+
+func (h *metricsHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (h *metricsHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		var start = time.Now()
+		err := next(ctx, cmd)
+		h.observe(cmd.Name(), start, err)
+		return err
+	}
+}
+
+func (h *metricsHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		var start = time.Now()
+		err := next(ctx, cmds)
+		h.observe("pipeline", start, err)
+		return err
+	}
+}
+
+func (h *metricsHook) observe(command string, start time.Time, err error) {
+	var status = "success"
+	if err != nil && !errors.Is(err, redis.Nil) {
+		status = "error"
+	}
+	redisCommandsTotal.With(h.source, command, status).Inc()
+	redisCommandDuration.With(h.source, command).Observe(time.Since(start).Seconds())
+}
+
+// 注册连接池指标，采集时读取连接池状态
+func registerPoolMetrics(source string, client redis.UniversalClient) {
+	redisPoolConnections.With(source, "total").Func(func() float64 {
+		return float64(client.PoolStats().TotalConns)
+	})
+	redisPoolConnections.With(source, "idle").Func(func() float64 {
+		return float64(client.PoolStats().IdleConns)
+	})
+}
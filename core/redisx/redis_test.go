@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"github.com/go-xuan/quanx/core/configx"
+	"github.com/redis/go-redis/v9"
 	"testing"
 )
 
@@ -26,3 +27,23 @@ func TestHandler(t *testing.T) {
 	value := GetClient().Get(ctx, "test_1").Val()
 	fmt.Println(value)
 }
+
+func TestCloseSource(t *testing.T) {
+	var h = NewHandler(nil)
+	for _, source := range []string{"default", "b", "a"} {
+		h.swapSource(&Config{Source: source}, redis.NewClient(&redis.Options{Addr: "127.0.0.1:0"}), source == "default")
+	}
+	// 关闭默认客户端后依次切换到剩余客户端，全部关闭后为空
+	for _, expected := range []string{"a", "b", ""} {
+		if err := h.closeSource(h.GetConfig().Source); err != nil {
+			t.Fatal(err)
+		}
+		if expected == "" {
+			if h.GetConfig() != nil || h.GetClient() != nil {
+				t.Fatal("default client should be cleared after all sources closed")
+			}
+		} else if h.GetConfig().Source != expected || h.GetClient() != h.clients[expected] {
+			t.Fatalf("expected default source %s, got %s", expected, h.GetConfig().Source)
+		}
+	}
+}
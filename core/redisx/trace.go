@@ -0,0 +1,61 @@
+package redisx
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/go-xuan/quanx/core/tracex"
+)
+
+// traceHook redis链路追踪钩子，上下文中存在链路信息时开启子span
+type traceHook struct {
+	source string
+}
+
+func (h *traceHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (h *traceHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if !tracex.SpanContextFromContext(ctx).IsValid() {
+			return next(ctx, cmd)
+		}
+		ctx, span := h.startSpan(ctx, "redis."+cmd.Name())
+		defer span.End()
+		err := next(ctx, cmd)
+		h.finishSpan(span, err)
+		return err
+	}
+}
+
+func (h *traceHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		if !tracex.SpanContextFromContext(ctx).IsValid() {
+			return next(ctx, cmds)
+		}
+		ctx, span := h.startSpan(ctx, "redis.pipeline")
+		span.SetAttribute("db.redis.commands", len(cmds))
+		defer span.End()
+		err := next(ctx, cmds)
+		h.finishSpan(span, err)
+		return err
+	}
+}
+
+func (h *traceHook) startSpan(ctx context.Context, name string) (context.Context, *tracex.Span) {
+	ctx, span := tracex.StartSpan(ctx, name, tracex.KindClient)
+	span.SetAttribute("db.system", "redis").SetAttribute("db.source", h.source)
+	return ctx, span
+}
+
+func (h *traceHook) finishSpan(span *tracex.Span, err error) {
+	if err != nil && !errors.Is(err, redis.Nil) {
+		span.SetError(err)
+	}
+}
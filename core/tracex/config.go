@@ -0,0 +1,79 @@
+package tracex
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/go-xuan/quanx/core/configx"
+	"github.com/go-xuan/quanx/os/errorx"
+	"github.com/go-xuan/quanx/types/anyx"
+)
+
+// 导出器类型
+const (
+	stdoutExporterType = "stdout" // 标准输出
+	fileExporterType   = "file"   // 本地文件
+)
+
+// Config 链路追踪配置
+type Config struct {
+	Enable   bool   `json:"enable" yaml:"enable"`                                                   // 是否启用
+	Exporter string `json:"exporter" yaml:"exporter" default:"stdout" validate:"oneof=stdout file"` // 导出器类型（stdout/file）
+	File     string `json:"file" yaml:"file" default:"resource/trace/trace.jsonl"`                  // 导出文件路径
+}
+
+func (c *Config) Format() string {
+	return fmt.Sprintf("enable=%v exporter=%s file=%s", c.Enable, c.Exporter, c.File)
+}
+
+func (*Config) Reader() *configx.Reader {
+	return &configx.Reader{
+		FilePath:    "trace.yaml",
+		NacosDataId: "trace.yaml",
+		Listen:      false,
+	}
+}
+
+func (c *Config) Execute() error {
+	if !c.Enable {
+		return nil
+	}
+	if err := anyx.SetDefaultValue(c); err != nil {
+		return errorx.Wrap(err, "set default value error")
+	}
+	exporter, err := c.NewExporter()
+	if err != nil {
+		return errorx.Wrap(err, "new trace exporter error")
+	}
+	SetExporter(exporter)
+	log.Info("trace exporter init success: ", c.Format())
+	return nil
+}
+
+// Close 关闭导出器
+func (c *Config) Close() error {
+	if exporter := _tracer.Exporter(); c.Enable && exporter != nil {
+		SetExporter(nil)
+		if err := exporter.Close(); err != nil {
+			return errorx.Wrap(err, "close trace exporter error")
+		}
+	}
+	return nil
+}
+
+// NewExporter 创建导出器
+func (c *Config) NewExporter() (Exporter, error) {
+	switch c.Exporter {
+	case stdoutExporterType:
+		return NewStdoutExporter(), nil
+	case fileExporterType:
+		exporter, err := NewFileExporter(c.File)
+		if err != nil {
+			return nil, err
+		}
+		return exporter, nil
+	default:
+		return nil, errorx.Errorf("trace exporter is invalid: %s", c.Exporter)
+	}
+}
@@ -0,0 +1,70 @@
+package tracex
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/go-xuan/quanx/os/errorx"
+)
+
+// Exporter span导出器
+type Exporter interface {
+	Export(span *Span) error // 导出已结束的span
+	Close() error            // 关闭导出器
+}
+
+// WriterExporter 以JSON行格式将span写入io.Writer
+type WriterExporter struct {
+	mu     sync.Mutex
+	writer io.Writer
+	closer io.Closer
+}
+
+// NewWriterExporter 创建写入io.Writer的导出器
+func NewWriterExporter(writer io.Writer) *WriterExporter {
+	return &WriterExporter{writer: writer}
+}
+
+// NewStdoutExporter 创建标准输出导出器
+func NewStdoutExporter() *WriterExporter {
+	return NewWriterExporter(os.Stdout)
+}
+
+// NewFileExporter 创建文件导出器，以追加方式写入
+func NewFileExporter(path string) (*WriterExporter, error) {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			return nil, errorx.Wrap(err, "create trace file dir failed")
+		}
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, errorx.Wrap(err, "open trace file failed")
+	}
+	return &WriterExporter{writer: file, closer: file}, nil
+}
+
+func (e *WriterExporter) Export(span *Span) error {
+	bytes, err := json.Marshal(span)
+	if err != nil {
+		return errorx.Wrap(err, "marshal span failed")
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if _, err = e.writer.Write(append(bytes, '\n')); err != nil {
+		return errorx.Wrap(err, "write span failed")
+	}
+	return nil
+}
+
+func (e *WriterExporter) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.closer != nil {
+		return e.closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package tracex
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+)
+
+// span类型
+const (
+	KindInternal = "internal" // 内部调用
+	KindServer   = "server"   // 服务端
+	KindClient   = "client"   // 客户端
+)
+
+// span状态
+const (
+	StatusUnset = "unset" // 未设置
+	StatusOk    = "ok"    // 成功
+	StatusError = "error" // 失败
+)
+
+const (
+	traceparentVersion = "00"
+	sampledFlag        = "01"
+	unsampledFlag      = "00"
+)
+
+var (
+	zeroTraceId = strings.Repeat("0", 32)
+	zeroSpanId  = strings.Repeat("0", 16)
+)
+
+// SpanContext 链路上下文，对应W3C traceparent
+type SpanContext struct {
+	TraceId string // 32位十六进制traceId
+	SpanId  string // 16位十六进制spanId
+	Sampled bool   // 是否采样
+}
+
+// IsValid 是否有效
+func (sc SpanContext) IsValid() bool {
+	return isHex(sc.TraceId, 32) && sc.TraceId != zeroTraceId &&
+		isHex(sc.SpanId, 16) && sc.SpanId != zeroSpanId
+}
+
+// Traceparent 格式化为traceparent请求头：version-traceId-spanId-flags
+func (sc SpanContext) Traceparent() string {
+	var flags = unsampledFlag
+	if sc.Sampled {
+		flags = sampledFlag
+	}
+	return traceparentVersion + "-" + sc.TraceId + "-" + sc.SpanId + "-" + flags
+}
+
+// ParseTraceparent 解析traceparent请求头
+func ParseTraceparent(traceparent string) (SpanContext, bool) {
+	var parts = strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(parts) < 4 || !isHex(parts[0], 2) || parts[0] == "ff" || !isHex(parts[3], 2) {
+		return SpanContext{}, false
+	}
+	// 版本00必须严格为4段，更高版本允许追加字段
+	if parts[0] == traceparentVersion && len(parts) != 4 {
+		return SpanContext{}, false
+	}
+	flags, _ := hex.DecodeString(parts[3])
+	var sc = SpanContext{
+		TraceId: parts[1],
+		SpanId:  parts[2],
+		Sampled: flags[0]&1 == 1,
+	}
+	if !sc.IsValid() {
+		return SpanContext{}, false
+	}
+	return sc, true
+}
+
+func isHex(s string, length int) bool {
+	if len(s) != length {
+		return false
+	}
+	for _, c := range s {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// 生成随机id
+func randomId(bytes int) string {
+	var b = make([]byte, bytes)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func newTraceId() string {
+	return randomId(16)
+}
+
+func newSpanId() string {
+	return randomId(8)
+}
+
+// Span 链路中的一次操作
+type Span struct {
+	mu           sync.Mutex
+	ended        bool
+	tracer       *Tracer
+	Name         string         `json:"name"`                   // 操作名称
+	Kind         string         `json:"kind"`                   // span类型
+	TraceId      string         `json:"traceId"`                // traceId
+	SpanId       string         `json:"spanId"`                 // spanId
+	ParentSpanId string         `json:"parentSpanId,omitempty"` // 父级spanId
+	Sampled      bool           `json:"-"`                      // 是否采样
+	StartTime    time.Time      `json:"startTime"`              // 开始时间
+	EndTime      time.Time      `json:"endTime"`                // 结束时间
+	Duration     int64          `json:"duration"`               // 耗时（微秒）
+	Status       string         `json:"status"`                 // 状态
+	Error        string         `json:"error,omitempty"`        // 错误信息
+	Attributes   map[string]any `json:"attributes,omitempty"`   // 属性
+}
+
+// Context 获取链路上下文
+func (s *Span) Context() SpanContext {
+	return SpanContext{TraceId: s.TraceId, SpanId: s.SpanId, Sampled: s.Sampled}
+}
+
+// SetAttribute 设置属性
+func (s *Span) SetAttribute(key string, value any) *Span {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Attributes == nil {
+		s.Attributes = make(map[string]any)
+	}
+	s.Attributes[key] = value
+	return s
+}
+
+// SetStatus 设置状态
+func (s *Span) SetStatus(status string) *Span {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Status = status
+	return s
+}
+
+// SetError 记录错误，err为nil时忽略
+func (s *Span) SetError(err error) *Span {
+	if err != nil {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.Status = StatusError
+		s.Error = err.Error()
+	}
+	return s
+}
+
+// End 结束span并导出，重复调用无效
+func (s *Span) End() {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.EndTime = time.Now()
+	s.Duration = s.EndTime.Sub(s.StartTime).Microseconds()
+	if s.Status == StatusUnset {
+		s.Status = StatusOk
+	}
+	s.mu.Unlock()
+	if s.Sampled && s.tracer != nil {
+		s.tracer.export(s)
+	}
+}
@@ -0,0 +1,70 @@
+package tracex
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestTraceparent(t *testing.T) {
+	var header = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	sc, ok := ParseTraceparent(header)
+	if !ok || sc.TraceId != "4bf92f3577b34da6a3ce929d0e0e4736" || sc.SpanId != "00f067aa0ba902b7" || !sc.Sampled {
+		t.Fatal("parse traceparent failed: ", sc)
+	}
+	if sc.Traceparent() != header {
+		t.Errorf("traceparent = %s, want %s", sc.Traceparent(), header)
+	}
+	for _, invalid := range []string{
+		"",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+	} {
+		if _, ok = ParseTraceparent(invalid); ok {
+			t.Errorf("traceparent %q should be invalid", invalid)
+		}
+	}
+}
+
+func TestSpan(t *testing.T) {
+	var buf bytes.Buffer
+	var tracer = &Tracer{}
+	tracer.SetExporter(NewWriterExporter(&buf))
+
+	var header = http.Header{}
+	header.Set(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx, parent := tracer.StartSpan(Extract(context.Background(), header), "parent", KindServer)
+	_, child := tracer.StartSpan(ctx, "child")
+	if parent.TraceId != "4bf92f3577b34da6a3ce929d0e0e4736" || parent.ParentSpanId != "00f067aa0ba902b7" {
+		t.Fatal("parent span should continue remote trace: ", parent.Context())
+	}
+	if child.TraceId != parent.TraceId || child.ParentSpanId != parent.SpanId {
+		t.Fatal("child span should be a child of parent span")
+	}
+	child.End()
+	parent.End()
+	parent.End()
+
+	var outbound = http.Header{}
+	Inject(ctx, outbound)
+	if outbound.Get(TraceparentHeader) != parent.Context().Traceparent() {
+		t.Errorf("injected traceparent = %s", outbound.Get(TraceparentHeader))
+	}
+
+	var lines = bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	if len(lines) != 2 {
+		t.Fatalf("exported %d spans, want 2", len(lines))
+	}
+	var exported Span
+	if err := json.Unmarshal(lines[0], &exported); err != nil {
+		t.Fatal(err)
+	}
+	if exported.Name != "child" || exported.Status != StatusOk {
+		t.Errorf("unexpected exported span: %s", lines[0])
+	}
+}
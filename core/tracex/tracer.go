@@ -0,0 +1,142 @@
+package tracex
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// TraceparentHeader W3C链路上下文请求头
+const TraceparentHeader = "traceparent"
+
+var _tracer = &Tracer{}
+
+type spanKey struct{}
+
+type remoteKey struct{}
+
+// Tracer 链路追踪器
+type Tracer struct {
+	mu       sync.RWMutex
+	exporter Exporter
+}
+
+// SetExporter 设置导出器，为nil时不导出
+func (t *Tracer) SetExporter(exporter Exporter) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.exporter = exporter
+}
+
+// Exporter 获取导出器
+func (t *Tracer) Exporter() Exporter {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.exporter
+}
+
+func (t *Tracer) export(span *Span) {
+	if exporter := t.Exporter(); exporter != nil {
+		if err := exporter.Export(span); err != nil {
+			log.WithField("span", span.Name).Error("export span failed: ", err)
+		}
+	}
+}
+
+// StartSpan 开启span，上下文中存在span或者远程链路上下文时作为其子span，否则开启新的链路
+func (t *Tracer) StartSpan(ctx context.Context, name string, kind ...string) (context.Context, *Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	var span = &Span{
+		tracer:    t,
+		Name:      name,
+		Kind:      KindInternal,
+		SpanId:    newSpanId(),
+		StartTime: time.Now(),
+		Status:    StatusUnset,
+	}
+	if len(kind) > 0 && kind[0] != "" {
+		span.Kind = kind[0]
+	}
+	if parent := SpanContextFromContext(ctx); parent.IsValid() {
+		span.TraceId = parent.TraceId
+		span.ParentSpanId = parent.SpanId
+		span.Sampled = parent.Sampled
+	} else {
+		span.TraceId = newTraceId()
+		span.Sampled = true
+	}
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// Default 默认链路追踪器
+func Default() *Tracer {
+	return _tracer
+}
+
+// SetExporter 设置默认链路追踪器的导出器
+func SetExporter(exporter Exporter) {
+	_tracer.SetExporter(exporter)
+}
+
+// StartSpan 使用默认链路追踪器开启span
+func StartSpan(ctx context.Context, name string, kind ...string) (context.Context, *Span) {
+	return _tracer.StartSpan(ctx, name, kind...)
+}
+
+// SpanFromContext 获取上下文中的span
+func SpanFromContext(ctx context.Context) *Span {
+	if ctx != nil {
+		if span, ok := ctx.Value(spanKey{}).(*Span); ok {
+			return span
+		}
+	}
+	return nil
+}
+
+// SpanContextFromContext 获取上下文中的链路上下文，优先取当前span，其次取远程链路上下文
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	if span := SpanFromContext(ctx); span != nil {
+		return span.Context()
+	}
+	if ctx != nil {
+		if sc, ok := ctx.Value(remoteKey{}).(SpanContext); ok {
+			return sc
+		}
+	}
+	return SpanContext{}
+}
+
+// ContextWithRemote 将远程链路上下文存入上下文
+func ContextWithRemote(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, remoteKey{}, sc)
+}
+
+// Extract 从请求头中提取链路上下文
+func Extract(ctx context.Context, header http.Header) context.Context {
+	if sc, ok := ParseTraceparent(header.Get(TraceparentHeader)); ok {
+		return ContextWithRemote(ctx, sc)
+	}
+	return ctx
+}
+
+// Inject 将上下文中的链路上下文注入请求头
+func Inject(ctx context.Context, header http.Header) {
+	if sc := SpanContextFromContext(ctx); sc.IsValid() {
+		header.Set(TraceparentHeader, sc.Traceparent())
+	}
+}
+
+// TraceId 获取上下文中的traceId
+func TraceId(ctx context.Context) string {
+	return SpanContextFromContext(ctx).TraceId
+}
+
+// SpanId 获取上下文中的spanId
+func SpanId(ctx context.Context) string {
+	return SpanContextFromContext(ctx).SpanId
+}
@@ -1,27 +1,38 @@
 package quanx
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
 	"path/filepath"
+	"reflect"
 	"strconv"
+	"strings"
+	"sync"
+	"syscall"
 
 	"github.com/gin-gonic/gin"
 	log "github.com/sirupsen/logrus"
-	
+
 	"github.com/go-xuan/quanx/common/constx"
 	"github.com/go-xuan/quanx/core/cachex"
 	"github.com/go-xuan/quanx/core/configx"
 	"github.com/go-xuan/quanx/core/ginx"
 	"github.com/go-xuan/quanx/core/gormx"
+	"github.com/go-xuan/quanx/core/healthx"
 	"github.com/go-xuan/quanx/core/logx"
 	"github.com/go-xuan/quanx/core/nacosx"
 	"github.com/go-xuan/quanx/core/redisx"
+	"github.com/go-xuan/quanx/core/tracex"
 	"github.com/go-xuan/quanx/net/ipx"
 	"github.com/go-xuan/quanx/os/errorx"
 	"github.com/go-xuan/quanx/os/filex"
 	"github.com/go-xuan/quanx/os/syncx"
 	"github.com/go-xuan/quanx/os/taskx"
 	"github.com/go-xuan/quanx/types/anyx"
-	"github.com/go-xuan/quanx/types/stringx"
 	"github.com/go-xuan/quanx/utils/marshalx"
 )
 
@@ -29,16 +40,33 @@ var engine *Engine
 
 // Engine 服务启动器
 type Engine struct {
-	switches       map[Option]bool          // 服务运行开关
-	config         *Config                  // 服务配置数据，使用 initAppConfig()将配置文件加载到此
-	configDir      string                   // 服务配置文件夹, 使用 SetConfigDir()设置配置文件读取路径
-	ginEngine      *gin.Engine              // gin框架引擎实例
-	ginRouters     []func(*gin.RouterGroup) // gin路由的预加载方法，使用 AddGinRouter()添加自行实现的路由注册方法
-	ginMiddlewares []gin.HandlerFunc        // gin中间件的预加载方法，使用 AddGinRouter()添加gin中间件
-	customFuncs    []func()                 // 自定义初始化函数 使用 AddCustomFunc()添加自定义函数
-	configurators  []configx.Configurator   // 配置器，使用 AddConfigurator()添加配置器对象，被添加对象必须为指针类型，且需要实现 configx.Configurator 接口
-	gormTablers    map[string][]interface{} // gorm表结构对象，使用 AddTable() / AddSourceTable() 添加至表结构初始化任务列表，需要实现 gormx.Tabler 接口
-	queue          *taskx.QueueScheduler    // Engine启动时的队列任务
+	switches       map[Option]bool               // 服务运行开关
+	config         *Config                       // 服务配置数据，使用 initAppConfig()将配置文件加载到此
+	configDir      string                        // 服务配置文件夹, 使用 SetConfigDir()设置配置文件读取路径
+	ginEngine      *gin.Engine                   // gin框架引擎实例
+	ginRouters     []func(*gin.RouterGroup)      // gin路由的预加载方法，使用 AddGinRouter()添加自行实现的路由注册方法
+	ginMiddlewares []gin.HandlerFunc             // gin中间件的预加载方法，使用 AddGinRouter()添加gin中间件
+	customFuncs    []func()                      // 自定义初始化函数 使用 AddCustomFunc()添加自定义函数
+	configurators  []configx.Configurator        // 配置器，使用 AddConfigurator()添加配置器对象，被添加对象必须为指针类型，且需要实现 configx.Configurator 接口，没有依赖关系的配置器并行运行
+	gormTablers    map[string][]interface{}      // gorm表结构对象，使用 AddTable() / AddSourceTable() 添加至表结构初始化任务列表，需要实现 gormx.Tabler 接口
+	queue          *taskx.QueueScheduler         // Engine启动时的队列任务
+	server         *http.Server                  // http服务，用于优雅停止
+	adminServer    *http.Server                  // 管理端口http服务，启用管理端口时创建
+	workers        workerGroup                   // 后台任务，使用 AddWorker()添加
+	serverErr      chan error                    // http服务异常退出
+	stopHooks      []func(context.Context) error // 服务停止钩子，使用 OnStop()添加
+	closers        []configx.Configurator        // 已初始化的配置器，服务停止时按逆序关闭
+	closerMutex    sync.Mutex                    // closers互斥锁
+	container      *configx.Container            // 组件容器，持有当前Engine的各组件句柄
+	gorm           *gormx.Handler                // 数据库句柄
+	redis          *redisx.Handler               // redis句柄
+	cache          *cachex.Handler               // 缓存句柄
+	nacos          *nacosx.Handler               // nacos句柄
+	health         *healthx.Registry             // 健康检查注册表，默认Engine使用healthx默认注册表
+	configChain    *configx.Chain                // 配置源优先级链
+	reloadMutex    sync.Mutex                    // 配置热加载互斥锁
+	shutdownOnce   sync.Once                     // 确保只执行一次停止流程
+	done           chan struct{}                 // 服务停止完成信号
 }
 
 // GetEngine 获取当前Engine
@@ -51,26 +79,76 @@ func GetEngine() *Engine {
 	return engine
 }
 
-// NewEngine 初始化Engine
+// NewEngine 创建Engine，首个Engine作为当前Engine并使用各组件包级别的默认句柄，
+// 此后创建的Engine以及使用 Isolate() 创建的Engine使用各自独立的句柄，同一进程中互不影响
 func NewEngine(opts ...EngineOptionFunc) *Engine {
-	if engine == nil {
-		engine = &Engine{
-			config:         &Config{},
-			configDir:      constx.DefaultConfDir,
-			customFuncs:    make([]func(), 0),
-			configurators:  make([]configx.Configurator, 0),
-			ginMiddlewares: make([]gin.HandlerFunc, 0),
-			gormTablers:    make(map[string][]interface{}),
-			switches:       make(map[Option]bool),
-		}
-		gin.SetMode(gin.ReleaseMode)
-		engine.doOptionFuncs(opts...)
+	var e = &Engine{
+		config:         &Config{},
+		configDir:      constx.DefaultConfDir,
+		customFuncs:    make([]func(), 0),
+		configurators:  make([]configx.Configurator, 0),
+		ginMiddlewares: make([]gin.HandlerFunc, 0),
+		gormTablers:    make(map[string][]interface{}),
+		switches:       make(map[Option]bool),
+		serverErr:      make(chan error, 1),
+		done:           make(chan struct{}),
+	}
+	gin.SetMode(gin.ReleaseMode)
+	e.doOptionFuncs(opts...)
+	if engine == nil && !e.switches[isolated] {
+		engine = e
+		e.health = healthx.Default()
+		e.bindHandlers(gormx.Default(), redisx.Default(), cachex.Default(), nacosx.Default())
+	} else {
+		e.health = healthx.NewRegistry()
+		var redis = redisx.NewHandler(e.health)
+		e.bindHandlers(gormx.NewHandler(e.health), redis, cachex.NewHandler(redis), nacosx.NewHandler(e.health))
 	}
 	// 设置默认日志输出
 	log.SetOutput(logx.DefaultWriter())
 	// 以队列方式启动服务
-	engine.enableQueue()
-	return engine
+	e.enableQueue()
+	return e
+}
+
+// 绑定组件句柄，配置器通过组件容器运行在当前Engine的句柄上
+func (e *Engine) bindHandlers(gorm *gormx.Handler, redis *redisx.Handler, cache *cachex.Handler, nacos *nacosx.Handler) {
+	e.gorm, e.redis, e.cache, e.nacos = gorm, redis, cache, nacos
+	e.container = configx.NewContainer()
+	e.container.Register("database", gorm)
+	e.container.Register("redis", redis)
+	e.container.Register("cache", cache)
+	e.container.Register("nacos", nacos)
+}
+
+// Container 当前Engine的组件容器
+func (e *Engine) Container() *configx.Container {
+	return e.container
+}
+
+// Gorm 当前Engine的数据库句柄
+func (e *Engine) Gorm() *gormx.Handler {
+	return e.gorm
+}
+
+// Redis 当前Engine的redis句柄
+func (e *Engine) Redis() *redisx.Handler {
+	return e.redis
+}
+
+// Cache 当前Engine的缓存句柄
+func (e *Engine) Cache() *cachex.Handler {
+	return e.cache
+}
+
+// Health 当前Engine的健康检查注册表
+func (e *Engine) Health() *healthx.Registry {
+	return e.health
+}
+
+// Nacos 当前Engine的nacos句柄
+func (e *Engine) Nacos() *nacosx.Handler {
+	return e.nacos
 }
 
 // RUN 服务运行
@@ -81,8 +159,8 @@ func (e *Engine) RUN() {
 	} else {
 		// 默认方式启动
 		syncx.OnceDo(e.initAppConfig)     // 1.初始化应用配置
-		syncx.OnceDo(e.initInnerConfig)   // 2.初始化内置组件
-		syncx.OnceDo(e.initOuterConfig)   // 3.初始化外置组件
+		syncx.OnceDo(e.validateConfig)    // 2.校验组件配置
+		syncx.OnceDo(e.initComponents)    // 3.按照依赖顺序初始化组件
 		syncx.OnceDo(e.runCustomFunction) // 4.运行自定义函数
 		syncx.OnceDo(e.startServer)       // 5.启动服务
 	}
@@ -97,8 +175,8 @@ func (e *Engine) checkRunning() {
 // Queue task id
 const (
 	stepInitAppConfig     = "init_app_config"     // 初始化应用配置
-	stepInitInnerConfig   = "init_inner_config"   // 初始化内置组件
-	stepInitOuterConfig   = "init_outer_config"   // 初始化外置组件
+	stepValidateConfig    = "validate_config"     // 校验组件配置
+	stepInitComponents    = "init_components"     // 按照依赖顺序初始化组件
 	stepRunCustomFunction = "run_custom_function" // 运行自定义函数
 	stepStartServer       = "start_server"        // 启动web服务
 )
@@ -107,12 +185,12 @@ const (
 func (e *Engine) enableQueue() {
 	if e.switches[enableQueue] && e.queue == nil {
 		queue := taskx.Queue()
-		queue.Add(engine.initAppConfig, stepInitAppConfig)         // 1.初始化应用配置
-		queue.Add(engine.initInnerConfig, stepInitInnerConfig)     // 2.初始化内置组件
-		queue.Add(engine.initOuterConfig, stepInitOuterConfig)     // 3.初始化外置组件
-		queue.Add(engine.runCustomFunction, stepRunCustomFunction) // 4.运行自定义函数
-		queue.Add(engine.startServer, stepStartServer)             // 5.启动服务
-		engine.queue = queue
+		queue.Add(e.initAppConfig, stepInitAppConfig)         // 1.初始化应用配置
+		queue.Add(e.validateConfig, stepValidateConfig)       // 2.校验组件配置
+		queue.Add(e.initComponents, stepInitComponents)       // 3.按照依赖顺序初始化组件
+		queue.Add(e.runCustomFunction, stepRunCustomFunction) // 4.运行自定义函数
+		queue.Add(e.startServer, stepStartServer)             // 5.启动服务
+		e.queue = queue
 	}
 }
 
@@ -128,19 +206,35 @@ func (e *Engine) initAppConfig() {
 	if err := anyx.SetDefaultValue(server); err != nil {
 		panic(errorx.Wrap(err, "set default value error"))
 	}
-	// 读取配置文件
+	// 读取配置文件，替换 ${ENV:default} 占位符，环境变量覆盖配置文件，例如 QUANX_SERVER_PORT 覆盖 server.port
 	if path := e.GetConfigPath(constx.DefaultConfigFilename); filex.Exists(path) {
-		if err := marshalx.Apply(path).Read(path, config); err != nil {
+		content, err := filex.ReadFile(path)
+		if err != nil {
+			panic(errorx.Wrap(err, "read file error:"+path))
+		}
+		if err = parseAppConfig(path, content, config); err != nil {
 			panic(errorx.Wrap(err, "unmarshal file error:"+path))
 		}
-		if e.switches[customPort] {
-			config.Server.Port = server.Port
+		if e.switches[enableWatch] {
+			e.watchAppConfig(path)
 		}
 	} else {
 		config.Server = server
 		if err := marshalx.Apply(path).Write(path, config); err != nil {
 			panic(errorx.Wrap(err, "save config file error:"+path))
 		}
+		if err := configx.ApplyEnv(configx.EnvPrefix, config); err != nil {
+			panic(errorx.Wrap(err, "apply env config error"))
+		}
+	}
+	if config.Server == nil {
+		config.Server = server
+		if err := configx.ApplyEnv(configx.EnvPrefix+"_SERVER", config.Server); err != nil {
+			panic(errorx.Wrap(err, "apply env config error"))
+		}
+	}
+	if e.switches[customPort] {
+		config.Server.Port = server.Port
 	}
 
 	// 设置host
@@ -153,7 +247,7 @@ func (e *Engine) initAppConfig() {
 		e.ExecuteConfigurator(config.Nacos, true)
 		if config.Nacos.EnableNaming() {
 			// 注册nacos服务Nacos
-			if err := nacosx.Register(config.Server.Instance()); err != nil {
+			if err := e.nacos.Register(config.Server.Instance()); err != nil {
 				panic(errorx.Wrap(err, "nacos register error"))
 			}
 		}
@@ -163,77 +257,336 @@ func (e *Engine) initAppConfig() {
 	e.config = config
 }
 
-// 初始化内置组件（log/gorm/redis/cache）
-func (e *Engine) initInnerConfig() {
+// 监听服务配置文件，文件变更时热加载发生变化的组件配置
+func (e *Engine) watchAppConfig(path string) {
+	var snapshot = &Config{}
+	if content, err := filex.ReadFile(path); err != nil {
+		log.WithField("path", path).Error("read config snapshot failed: ", err)
+		return
+	} else if err = parseAppConfig(path, content, snapshot); err != nil {
+		log.WithField("path", path).Error("parse config snapshot failed: ", err)
+		return
+	}
+	if err := configx.WatchFile(path); err != nil {
+		log.WithField("path", path).Error("watch config file failed: ", err)
+		return
+	}
+	configx.Subscribe(configx.LocalKey(path), func(content []byte) {
+		var next = &Config{}
+		if err := parseAppConfig(path, content, next); err != nil {
+			log.WithField("path", path).Error("parse changed config failed: ", err)
+			return
+		}
+		e.reloadAppConfig(path, snapshot, next)
+		snapshot = next
+	})
+}
+
+// 解析服务配置内容，替换占位符并合并环境变量后一次性反序列化，热加载与初始化保持一致
+func parseAppConfig(path string, content []byte, config *Config) error {
+	return configx.UnmarshalWithEnv(path, content, configx.EnvPrefix, config)
+}
+
+// 比较服务配置的各组件配置，热加载发生变化的组件
+func (e *Engine) reloadAppConfig(path string, prev, next *Config) {
+	var configFrom = configx.LocalKey(path)
+	if !reflect.DeepEqual(prev.Server, next.Server) || !reflect.DeepEqual(prev.Nacos, next.Nacos) {
+		log.WithField("configFrom", configFrom).Warn("server or nacos config changed, restart to apply the change")
+	}
+	// 日志名称缺省时与初始化保持一致，使用服务名
+	for _, config := range []*Config{prev, next} {
+		if config.Log != nil && config.Log.Name == "" {
+			config.Log.Name = e.config.Server.Name
+		}
+	}
+	var sections = []struct {
+		current    configx.Configurator
+		prev, next any
+	}{
+		{e.config.Log, prev.Log, next.Log},
+		{e.config.Trace, prev.Trace, next.Trace},
+		{e.config.Database, prev.Database, next.Database},
+		{e.config.Redis, prev.Redis, next.Redis},
+		{e.config.Cache, prev.Cache, next.Cache},
+	}
+	var marshal = marshalx.Apply(path)
+	for _, section := range sections {
+		if reflect.ValueOf(section.current).IsNil() || reflect.ValueOf(section.next).IsNil() ||
+			reflect.DeepEqual(section.prev, section.next) {
+			continue
+		}
+		var changed = section.next
+		// 通过序列化深拷贝，避免热加载时设置默认值影响配置快照
+		e.reloadConfigurator(section.current, configFrom, func(v any) error {
+			bytes, err := marshal.Marshal(changed)
+			if err != nil {
+				return errorx.Wrap(err, "marshal changed config failed")
+			}
+			return marshal.Unmarshal(bytes, v)
+		})
+	}
+}
+
+// 启动前校验全部组件配置，汇总全部校验失败项以及配置来源后一次性报告
+func (e *Engine) validateConfig() {
 	e.checkRunning()
+	var chain = e.ConfigChain()
+	var items []string
+	for _, c := range e.components() {
+		var configurator = cloneConfigurator(c.configurator)
+		var configFrom = "local@" + e.GetConfigPath(constx.DefaultConfigFilename) + " or " + configx.DefaultOrigin
+		var origin *configx.Origin
+		if reader := configurator.Reader(); reader != nil {
+			var err error
+			if origin, err = chain.Load(reader, configurator); err != nil {
+				items = append(items, fmt.Sprintf("%T: load failed: %v", configurator, err))
+				continue
+			}
+		}
+		if !c.must && (origin == nil || !origin.Found()) {
+			continue
+		}
+		var invalid *configx.ValidationError
+		if err := configx.Validate(configurator); err == nil {
+			continue
+		} else if !errors.As(err, &invalid) {
+			items = append(items, fmt.Sprintf("%T: %v", configurator, err))
+			continue
+		}
+		for _, field := range invalid.Fields {
+			var from = configFrom
+			if origin != nil && origin.Found() {
+				if from = origin.Of(field.Field); from == configx.DefaultOrigin {
+					from = strings.Join(origin.Sources(), ",")
+				}
+			}
+			items = append(items, fmt.Sprintf("%T: %s (from %s)", configurator, field.Error(), from))
+		}
+	}
+	if len(items) > 0 {
+		panic(errorx.Errorf("invalid configuration, %d problem(s) found:\n  %s", len(items), strings.Join(items, "\n  ")))
+	}
+}
+
+// 通过序列化深拷贝配置器，校验时设置默认值以及合并配置源不影响原配置器
+func cloneConfigurator(configurator configx.Configurator) configx.Configurator {
+	var typ = reflect.TypeOf(configurator)
+	if typ.Kind() != reflect.Ptr {
+		return configurator
+	}
+	var clone = reflect.New(typ.Elem()).Interface().(configx.Configurator)
+	var marshal = marshalx.Apply("yaml")
+	if bytes, err := marshal.Marshal(configurator); err == nil {
+		_ = marshal.Unmarshal(bytes, clone)
+	}
+	return clone
+}
+
+// 组件，组件名称以及依赖通过 configx.ComponentName() 以及 configx.DependsOn() 获取
+type component struct {
+	configurator configx.Configurator
+	must         bool         // 未从配置源读取到配置时是否仍运行
+	deps         []string     // 引擎声明的额外依赖
+	condition    func() bool  // 运行条件，为空时总是运行
+	after        func() error // 运行后执行，返回错误时停止启动
+}
 
-	// 初始化日志
+// 全部组件，包括内置组件（log/trace/database/redis/cache）以及 AddConfigurator() 添加的外置组件
+func (e *Engine) components() []*component {
+	// 日志
 	logConf := anyx.IfZero(e.config.Log, &logx.Config{})
 	if logConf.Name == "" {
 		logConf.Name = e.config.Server.Name
 	}
-	e.ExecuteConfigurator(logConf, true)
-	e.config.Log = logConf
+	var components = []*component{{
+		configurator: logConf,
+		must:         true,
+		after:        func() error { e.config.Log = logConf; return nil },
+	}}
+
+	// 链路追踪
+	var trace = anyx.IfZero(e.config.Trace, &tracex.Config{})
+	components = append(components, &component{
+		configurator: trace,
+		must:         e.config.Trace != nil,
+		after:        func() error { e.config.Trace = trace; return nil },
+	})
 
-	// 初始化数据库连接
+	// 数据库连接，连接成功后初始化表结构
+	var database configx.Configurator
+	var databases *gormx.MultiConfig
 	if e.config.Database != nil {
-		e.ExecuteConfigurator(e.config.Database)
+		database, databases = e.config.Database, e.config.Database
 	} else if e.switches[multiDatabase] {
-		var database = &gormx.MultiConfig{}
-		e.ExecuteConfigurator(database, true)
-		e.config.Database = database
+		databases = &gormx.MultiConfig{}
+		database = databases
 	} else {
-		var database = &gormx.Config{}
-		e.ExecuteConfigurator(database)
-		e.config.Database = &gormx.MultiConfig{database}
+		var single = &gormx.Config{}
+		database, databases = single, &gormx.MultiConfig{single}
 	}
-
-	// 初始化表结构
-	if gormx.IsInitialized() {
-		for _, source := range gormx.Sources() {
-			if tablers, ok := e.gormTablers[source]; ok {
-				if err := gormx.InitTable(source, tablers...); err != nil {
-					panic(errorx.Wrap(err, "init table struct and data failed"))
+	components = append(components, &component{
+		configurator: database,
+		must:         e.config.Database == nil && e.switches[multiDatabase],
+		after: func() error {
+			e.config.Database = databases
+			if e.gorm.IsInitialized() {
+				for _, source := range e.gorm.Sources() {
+					if tablers, ok := e.gormTablers[source]; ok {
+						if err := e.gorm.InitTabler(source, tablers...); err != nil {
+							return errorx.Wrap(err, "init table struct and data failed")
+						}
+					}
 				}
 			}
-		}
-	}
+			return nil
+		},
+	})
 
-	// 初始化redis连接
+	// redis连接
+	var redis configx.Configurator
+	var redises *redisx.MultiConfig
 	if e.config.Redis != nil {
-		e.ExecuteConfigurator(e.config.Redis)
+		redis, redises = e.config.Redis, e.config.Redis
 	} else if e.switches[multiRedis] {
-		var redis = &redisx.MultiConfig{}
-		e.ExecuteConfigurator(redis, true)
-		e.config.Redis = redis
+		redises = &redisx.MultiConfig{}
+		redis = redises
 	} else {
-		var redis = &redisx.Config{}
-		e.ExecuteConfigurator(redis)
-		e.config.Redis = &redisx.MultiConfig{redis}
+		var single = &redisx.Config{}
+		redis, redises = single, &redisx.MultiConfig{single}
 	}
+	components = append(components, &component{
+		configurator: redis,
+		must:         e.config.Redis == nil && e.switches[multiRedis],
+		after:        func() error { e.config.Redis = redises; return nil },
+	})
 
-	// 初始化缓存
-	if redisx.IsInitialized() {
-		if e.config.Cache != nil {
-			e.ExecuteConfigurator(e.config.Cache)
-		} else if e.switches[multiCache] {
-			var cache = &cachex.MultiConfig{}
-			e.ExecuteConfigurator(cache, true)
-			e.config.Cache = cache
-		} else {
-			var cache = &cachex.Config{}
-			e.ExecuteConfigurator(cache)
-			e.config.Cache = &cachex.MultiConfig{cache}
-		}
+	// 缓存，redis连接成功后初始化
+	var cache configx.Configurator
+	var caches *cachex.MultiConfig
+	if e.config.Cache != nil {
+		cache, caches = e.config.Cache, e.config.Cache
+	} else if e.switches[multiCache] {
+		caches = &cachex.MultiConfig{}
+		cache = caches
+	} else {
+		var single = &cachex.Config{}
+		cache, caches = single, &cachex.MultiConfig{single}
 	}
+	components = append(components, &component{
+		configurator: cache,
+		must:         e.config.Cache == nil && e.switches[multiCache],
+		deps:         []string{configx.ComponentName(redis)},
+		condition:    e.redis.IsInitialized,
+		after: func() error {
+			if e.redis.IsInitialized() {
+				e.config.Cache = caches
+			}
+			return nil
+		},
+	})
+
+	// 外置组件
+	for _, configurator := range e.configurators {
+		components = append(components, &component{configurator: configurator})
+	}
+	return components
 }
 
-// 运行自定义配置器
-func (e *Engine) initOuterConfig() {
+// 按照依赖顺序初始化全部组件，没有依赖关系的组件并行初始化，
+// 除日志组件及其依赖外，其他组件均依赖日志组件
+func (e *Engine) initComponents() {
 	e.checkRunning()
-	for _, configurator := range e.configurators {
-		e.ExecuteConfigurator(configurator)
+	var components = e.components()
+	var names = make([]string, len(components))
+	var exists = make(map[string]bool, len(components))
+	for i, c := range components {
+		// 同名组件（例如相同配置文件或者相同类型且无配置文件的配置器）使用下标后缀区分，依赖同名组件时依赖首个组件
+		if names[i] = configx.ComponentName(c.configurator); exists[names[i]] {
+			names[i] = names[i] + "#" + strconv.Itoa(i)
+		}
+		exists[names[i]] = true
+	}
+	// 先读取全部组件配置，组件依赖可能取决于配置内容
+	var deps = make([][]string, len(components))
+	var configFroms = make([]string, len(components))
+	var mustRuns = make([]bool, len(components))
+	for i, c := range components {
+		configFroms[i], mustRuns[i] = e.loadConfigurator(c.configurator, c.must)
+		for _, dep := range append(configx.DependsOn(c.configurator), c.deps...) {
+			if exists[dep] {
+				deps[i] = append(deps[i], dep)
+			} else {
+				log.WithField("component", names[i]).Warn("the dependent component is not registered: ", dep)
+			}
+		}
+	}
+	var logName = names[0]
+	var logDeps = dependencyClosure(logName, names, deps)
+
+	var graph = configx.NewGraph()
+	var fatalMutex sync.Mutex
+	var fatal []string
+	for i, c := range components {
+		var i, c = i, c
+		if names[i] != logName && !logDeps[names[i]] {
+			deps[i] = append(deps[i], logName)
+		}
+		if err := graph.Add(names[i], func() error {
+			if c.condition != nil && !c.condition() {
+				return nil
+			}
+			err := e.runConfigurator(c.configurator, configFroms[i], mustRuns[i])
+			if c.after != nil {
+				if afterErr := c.after(); afterErr != nil {
+					fatalMutex.Lock()
+					fatal = append(fatal, names[i]+": "+afterErr.Error())
+					fatalMutex.Unlock()
+				}
+			}
+			// 其他组件对日志组件的依赖仅用于保证初始化顺序，日志组件运行失败时不跳过其他组件
+			if names[i] == logName {
+				return nil
+			}
+			return err
+		}, deps[i]...); err != nil {
+			panic(errorx.Wrap(err, "register component failed"))
+		}
+	}
+	levels, err := graph.Levels()
+	if err != nil {
+		panic(errorx.Wrap(err, "sort components failed"))
+	}
+	if e.switches[enableDebug] {
+		log.Info("component levels: ", levels)
+	}
+	if err = graph.Run(); err != nil {
+		log.Error("some components are not initialized ==> ", err)
+	}
+	if len(fatal) > 0 {
+		panic(errorx.New(strings.Join(fatal, "; ")))
+	}
+}
+
+// 组件的全部直接以及间接依赖
+func dependencyClosure(name string, names []string, deps [][]string) map[string]bool {
+	var index = make(map[string]int, len(names))
+	for i, n := range names {
+		index[n] = i
+	}
+	var closure = make(map[string]bool)
+	var visit func(string)
+	visit = func(n string) {
+		if i, ok := index[n]; ok {
+			for _, dep := range deps[i] {
+				if !closure[dep] {
+					closure[dep] = true
+					visit(dep)
+				}
+			}
+		}
 	}
+	visit(name)
+	return closure
 }
 
 // 运行自定义函数
@@ -244,24 +597,57 @@ func (e *Engine) runCustomFunction() {
 	}
 }
 
-// 启动服务
+// 启动服务以及后台任务，并阻塞直至服务停止，后台任务模式下不启动web服务
 func (e *Engine) startServer() {
-	e.startWebServer()
+	if e.switches[workerMode] {
+		e.startWorkerMode()
+	} else {
+		e.startWebServer()
+	}
+	e.startWorkers()
+	e.waitForShutdown()
+}
+
+// 后台任务模式，仅启动定时任务调度器以及管理端口（启用时）
+func (e *Engine) startWorkerMode() {
+	e.checkRunning()
+	e.startAdminServer()
+	e.switches[running] = true
+	if scheduler := taskx.Corn(); scheduler.Status() == "readiness" {
+		if err := scheduler.Start(); err != nil {
+			panic(errorx.Wrap(err, "start cron scheduler failed"))
+		}
+	}
+	log.Info("engine is running in worker mode")
+	e.health.SetReady(true)
+}
+
+// 启动管理端口
+func (e *Engine) startAdminServer() {
+	if e.config.Server.EnableAdmin() {
+		var err error
+		if e.adminServer, err = newAdminServer(e.config.Server.Admin, e.health); err != nil {
+			panic(errorx.Wrap(err, "create admin server failed"))
+		}
+		log.Infof(`管理接口请求地址: http://%s`, e.adminServer.Addr)
+		e.listen(e.adminServer, "admin server run failed")
+	}
 }
 
 // 启动web服务
 func (e *Engine) startWebServer() {
 	e.checkRunning()
-	defer PanicRecover()
 	if e.switches[enableDebug] {
 		gin.SetMode(gin.DebugMode)
 	}
 	if e.ginEngine == nil {
 		e.ginEngine = gin.New()
 	}
+	// gin.Context作为context.Context使用时，取值回退到请求上下文，便于透传链路信息
+	e.ginEngine.ContextWithFallback = true
 
 	// 初始化中间件
-	e.ginEngine.Use(gin.Recovery(), ginx.Trace)
+	e.ginEngine.Use(gin.Recovery(), ginx.Trace, ginx.Metrics)
 	if e.config.Log.Formatter == "json" {
 		e.ginEngine.Use(ginx.JsonLogFormatter)
 	} else {
@@ -272,17 +658,138 @@ func (e *Engine) startWebServer() {
 	host := e.config.Server.Host
 	_ = e.ginEngine.SetTrustedProxies([]string{host})
 
-	// 注册服务根路由
+	// 注册服务根路由，启用管理端口时健康检查以及指标采集仅在管理端口暴露
 	group := e.ginEngine.Group(e.config.Server.ApiPrefix())
+	if !e.config.Server.EnableAdmin() {
+		initHealthRouter(group, e.health)
+	}
 	e.initGinRouter(group)
 
-	// 获取服务端口
-	port := strconv.Itoa(e.config.Server.Port)
+	// 创建服务
+	server, err := newWebServer(e.config.Server, e.ginEngine)
+	if err != nil {
+		panic(errorx.Wrap(err, "create http server failed"))
+	}
 	// 启动服务
+	e.startAdminServer()
 	e.switches[running] = true
-	log.Infof(`API接口请求地址: http://%s:%s`, host, port)
-	if err := e.ginEngine.Run(":" + port); err != nil {
-		panic(errorx.Wrap(err, "gin engine run failed"))
+	e.server = server
+	log.Infof(`API接口请求地址: %s://%s:%d`, e.config.Server.Scheme(), host, e.config.Server.Port)
+	e.listen(e.server, "http server run failed")
+	e.health.SetReady(true)
+}
+
+// 后台启动http服务，异常退出时通知停止服务
+func (e *Engine) listen(server *http.Server, message string) {
+	go func() {
+		if err := serve(server); err != nil && err != http.ErrServerClosed {
+			select {
+			case e.serverErr <- errorx.Wrap(err, message):
+			default:
+			}
+		}
+	}()
+}
+
+// 等待系统退出信号或者服务异常，随后执行优雅停止
+func (e *Engine) waitForShutdown() {
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(quit)
+	select {
+	case sig := <-quit:
+		log.Info("received signal, server is shutting down: ", sig)
+	case err := <-e.serverErr:
+		log.Error("server or worker exited abnormally, shutting down: ", err)
+	case <-e.done:
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), e.config.Server.GetShutdownTimeout())
+	defer cancel()
+	if err := e.Shutdown(ctx); err != nil {
+		log.Error("server shutdown failed: ", err)
+	}
+}
+
+// Shutdown 优雅停止服务，重复调用仅首次生效
+// 1.注销nacos服务实例 2.停止接收新请求并等待处理中的请求结束 3.取消后台任务并等待结束 4.停止定时任务
+// 5.逆序执行 OnStop 钩子 6.按照初始化的逆序关闭各组件
+func (e *Engine) Shutdown(ctx context.Context) error {
+	var err error
+	e.shutdownOnce.Do(func() {
+		err = e.shutdown(ctx)
+		close(e.done)
+	})
+	return err
+}
+
+func (e *Engine) shutdown(ctx context.Context) error {
+	e.health.SetReady(false)
+	var errs []error
+	if e.switches[enableNacos] && e.config.Nacos != nil && e.config.Nacos.EnableNaming() {
+		if err := e.nacos.Deregister(e.config.Server.Instance()); err != nil {
+			errs = append(errs, errorx.Wrap(err, "nacos deregister failed"))
+		}
+	}
+	if e.server != nil {
+		if err := e.server.Shutdown(ctx); err != nil {
+			errs = append(errs, errorx.Wrap(err, "http server shutdown failed"))
+		}
+	}
+	if e.adminServer != nil {
+		if err := e.adminServer.Shutdown(ctx); err != nil {
+			errs = append(errs, errorx.Wrap(err, "admin server shutdown failed"))
+		}
+	}
+	configx.StopWatch()
+	if err := e.stopWorkers(ctx); err != nil {
+		errs = append(errs, errorx.Wrap(err, "stop workers failed"))
+	}
+	if err := taskx.Corn().Shutdown(ctx); err != nil {
+		errs = append(errs, errorx.Wrap(err, "cron scheduler shutdown failed"))
+	}
+	for i := len(e.stopHooks) - 1; i >= 0; i-- {
+		if err := e.stopHooks[i](ctx); err != nil {
+			errs = append(errs, errorx.Wrap(err, "run stop hook failed"))
+		}
+	}
+	e.closerMutex.Lock()
+	defer e.closerMutex.Unlock()
+	for i := len(e.closers) - 1; i >= 0; i-- {
+		if err := e.container.Close(e.closers[i]); err != nil {
+			errs = append(errs, errorx.Wrap(err, "close configurator failed"))
+		}
+	}
+	e.closers = nil
+	for _, err := range errs {
+		log.Error(err)
+	}
+	if len(errs) > 0 {
+		return errorx.Join(errs...)
+	}
+	log.Info("server shutdown completed")
+	return nil
+}
+
+// Done 服务停止完成后关闭的通道
+func (e *Engine) Done() <-chan struct{} {
+	return e.done
+}
+
+// OnStop 添加服务停止钩子，服务停止时按照添加的逆序执行
+func (e *Engine) OnStop(hooks ...func(ctx context.Context) error) {
+	e.checkRunning()
+	if len(hooks) > 0 {
+		e.stopHooks = append(e.stopHooks, hooks...)
+	}
+}
+
+// 记录可关闭的配置器，服务停止时按逆序关闭
+func (e *Engine) addCloser(configurator configx.Configurator) {
+	if _, ok := configurator.(configx.Closer); ok || e.container.Target(configurator) != nil {
+		e.closerMutex.Lock()
+		defer e.closerMutex.Unlock()
+		e.closers = append(e.closers, configurator)
 	}
 }
 
@@ -305,7 +812,8 @@ func (e *Engine) AddCustomFunc(funcs ...func()) {
 	}
 }
 
-// AddConfigurator 新增自定义配置器
+// AddConfigurator 新增自定义配置器，配置器在日志组件之后按照依赖关系初始化，
+// 相互之间没有依赖的配置器并行运行，需要保证运行顺序时通过 configx.Dependent 声明依赖的组件名称
 func (e *Engine) AddConfigurator(configurators ...configx.Configurator) {
 	e.checkRunning()
 	if len(configurators) > 0 {
@@ -316,33 +824,116 @@ func (e *Engine) AddConfigurator(configurators ...configx.Configurator) {
 // ExecuteConfigurator 执行配置器（立即执行）
 func (e *Engine) ExecuteConfigurator(configurator configx.Configurator, must ...bool) {
 	e.checkRunning()
-	configFrom := "local@" + e.GetConfigPath(constx.DefaultConfigFilename) + " or tag@default"
-	mustRun := anyx.Default(false, must...)
+	configFrom, mustRun := e.loadConfigurator(configurator, anyx.Default(false, must...))
+	_ = e.runConfigurator(configurator, configFrom, mustRun)
+}
+
+// 从配置源读取配置器配置，返回配置来源以及是否需要运行，读取到配置时总是需要运行
+func (e *Engine) loadConfigurator(configurator configx.Configurator, must bool) (string, bool) {
+	configFrom := "local@" + e.GetConfigPath(constx.DefaultConfigFilename) + " or " + configx.DefaultOrigin
 	if reader := configurator.Reader(); reader != nil {
-		if e.switches[enableNacos] {
-			group := stringx.IfZero(reader.NacosGroup, e.config.Server.Name)
-			reader.NacosGroup = group
-			if err := nacosx.ScanConfig(configurator, group, reader.NacosDataId, reader.Listen); err == nil {
-				configFrom, mustRun = "nacos@"+group+"@"+reader.NacosDataId, true
-			}
-		} else {
-			path := e.GetConfigPath(reader.FilePath)
-			if err := marshalx.Apply(path).Read(path, configurator); err == nil {
-				configFrom, mustRun = "local@"+path, true
-			}
+		if origin, err := e.ConfigChain().Load(reader, configurator); err != nil {
+			log.WithField("configFrom", configFrom).Error("configurator load failed ==> ", err)
+		} else if origin.Found() {
+			return origin.String(), true
 		}
 	}
-	if mustRun {
-		if err := configx.Execute(configurator); err != nil {
-			log.WithField("configFrom", configFrom).
-				Error("configurator execute failed ==> ", err)
-		} else {
-			log.WithField("configFrom", configFrom).
-				Info("configurator execute success")
-		}
-		if e.switches[enableDebug] {
-			log.Info("configurator data: ", configurator.Format())
+	return configFrom, must
+}
+
+// 运行配置器，运行成功后监听配置变更
+func (e *Engine) runConfigurator(configurator configx.Configurator, configFrom string, mustRun bool) error {
+	if !mustRun {
+		return nil
+	}
+	if e.switches[enableDebug] {
+		defer func() { log.Info("configurator data: ", configurator.Format()) }()
+	}
+	if err := e.container.Execute(configurator); err != nil {
+		log.WithField("configFrom", configFrom).
+			Error("configurator execute failed ==> ", err)
+		return err
+	}
+	e.addCloser(configurator)
+	log.WithField("configFrom", configFrom).
+		Info("configurator execute success")
+	if reader := configurator.Reader(); reader != nil && reader.Listen {
+		e.watchConfigurator(e.ConfigChain(), reader, configurator)
+	}
+	return nil
+}
+
+// 监听配置器的全部配置源，任一配置源变更时重新合并配置并热加载
+func (e *Engine) watchConfigurator(chain *configx.Chain, reader *configx.Reader, configurator configx.Configurator) {
+	if err := chain.Watch(reader, func() {
+		e.reloadConfigurator(configurator, "chain@"+reader.FilePath, func(v any) error {
+			origin, err := chain.Load(reader, v)
+			if err == nil {
+				log.WithField("configFrom", origin.String()).Info("configurator sources merged")
+			}
+			return err
+		})
+	}); err != nil {
+		log.WithField("reader", reader.FilePath).Error("watch configurator failed ==> ", err)
+	}
+}
+
+// ConfigChain 配置源优先级链，未设置时默认为 环境变量 > nacos(启用时) > 本地文件 > default标签
+func (e *Engine) ConfigChain() *configx.Chain {
+	if e.configChain == nil {
+		var sources = []configx.Source{configx.NewEnvSource(configx.EnvPrefix)}
+		if e.switches[enableNacos] {
+			sources = append(sources, e.nacos.Source(e.config.Server.Name))
 		}
+		sources = append(sources, configx.NewFileSource(e.configDir, e.switches[enableWatch]))
+		e.configChain = configx.NewChain(sources...)
+	}
+	return e.configChain
+}
+
+// SetConfigChain 自定义配置源优先级链，sources按优先级从高到低排列
+func (e *Engine) SetConfigChain(sources ...configx.Source) {
+	e.checkRunning()
+	e.configChain = configx.NewChain(sources...)
+}
+
+// 配置变更后热加载配置器，变更内容反序列化到新的配置器实例，加载成功后再覆盖原配置器
+func (e *Engine) reloadConfigurator(configurator configx.Configurator, configFrom string, unmarshal func(v any) error) {
+	e.reloadMutex.Lock()
+	defer e.reloadMutex.Unlock()
+	var logger = log.WithField("configFrom", configFrom)
+	if _, ok := configurator.(configx.Reloadable); !ok {
+		logger.Warn("configurator does not support reload, restart to apply the change")
+		return
+	}
+	var value = reflect.ValueOf(configurator)
+	if value.Kind() != reflect.Ptr || value.IsNil() {
+		logger.Error("configurator reload failed ==> configurator must be a non-nil pointer")
+		return
+	}
+	var next = reflect.New(value.Type().Elem())
+	if err := unmarshal(next.Interface()); err != nil {
+		logger.Error("configurator reload failed ==> ", err)
+		return
+	}
+	reloadable, ok := next.Interface().(configx.Reloadable)
+	if !ok {
+		logger.Error("configurator reload failed ==> new instance is not reloadable")
+		return
+	}
+	// 校验失败时保留原配置
+	if err := configx.Validate(reloadable); err != nil {
+		logger.Error("configurator reload failed, invalid configuration ==> ", err)
+		return
+	}
+	if err := e.container.Reload(reloadable); err != nil {
+		logger.Error("configurator reload failed ==> ", err)
+		return
+	}
+	value.Elem().Set(next.Elem())
+	logger.Info("configurator reload success")
+	if e.switches[enableDebug] {
+		log.Info("configurator data: ", configurator.Format())
 	}
 }
 
@@ -356,7 +947,7 @@ func (e *Engine) ReadLocalConfig(v any, path string) {
 // ScanNacosConfig 扫描nacos配置（以自定义函数的形式延迟执行，确保nacos已经提前初始化）
 func (e *Engine) ScanNacosConfig(v any, dataId string, listen ...bool) {
 	e.AddCustomFunc(func() {
-		if err := nacosx.ScanConfig(v, e.config.Server.Name, dataId, listen...); err != nil {
+		if err := e.nacos.ScanConfig(v, e.config.Server.Name, dataId, listen...); err != nil {
 			panic(errorx.Wrap(err, "scan nacos config failed"))
 		}
 	})
@@ -430,11 +1021,9 @@ func (e *Engine) AddQueueTask(task func(), id string) {
 	return
 }
 
-// PanicRecover 服务保活
+// PanicRecover 捕获panic并记录日志
 func PanicRecover() {
 	if err := recover(); err != nil {
 		log.Error("server run panic: ", err)
-		return
 	}
-	select {}
 }
@@ -1,7 +1,21 @@
 package quanx
 
 import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
 	"testing"
+
+	"github.com/go-xuan/quanx/common/constx"
+	"github.com/go-xuan/quanx/core/configx"
+	"github.com/go-xuan/quanx/core/healthx"
 )
 
 func TestEngineRun(t *testing.T) {
@@ -11,3 +25,172 @@ func TestEngineRun(t *testing.T) {
 		EnableDebug(),
 	).RUN()
 }
+
+func TestServerTLS(t *testing.T) {
+	config, err := (&TLS{Enable: true, SelfSigned: true}).Config("quanx.local")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(config.Certificates[0].Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	} else if err = cert.VerifyHostname("quanx.local"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = (&TLS{Enable: true}).Config(""); err == nil {
+		t.Fatal("certificate should be required without selfSigned")
+	}
+
+	admin, err := newAdminServer(&Admin{Enable: true, Pprof: true}, healthx.NewRegistry())
+	if err != nil {
+		t.Fatal(err)
+	} else if admin.Addr != ":8889" {
+		t.Fatalf("unexpected admin address: %s", admin.Addr)
+	}
+	for _, path := range []string{"/healthz", "/debug/pprof/"} {
+		var recorder = httptest.NewRecorder()
+		admin.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("%s: unexpected status %d", path, recorder.Code)
+		}
+	}
+}
+
+func TestWorkerMode(t *testing.T) {
+	var stopped = make(chan struct{})
+	var engine = NewEngine(
+		Isolate(),
+		WorkerMode(),
+		SetConfigDir(t.TempDir()),
+		AddWorker("consumer", func(ctx context.Context) error {
+			<-ctx.Done()
+			close(stopped)
+			return ctx.Err()
+		}),
+		AddWorker("failed", func(ctx context.Context) error {
+			return errors.New("consume failed")
+		}),
+	)
+	engine.RUN()
+	select {
+	case <-stopped:
+	default:
+		t.Fatal("worker should be cancelled when engine stopped")
+	}
+	if engine.server != nil {
+		t.Fatal("web server should not be started in worker mode")
+	}
+}
+
+// 记录关闭顺序的配置器
+type closerConfigurator struct {
+	name   string
+	closed *[]string
+}
+
+func (c *closerConfigurator) Format() string          { return c.name }
+func (c *closerConfigurator) Reader() *configx.Reader { return nil }
+func (c *closerConfigurator) Execute() error          { return nil }
+func (c *closerConfigurator) Close() error {
+	*c.closed = append(*c.closed, "close:"+c.name)
+	return errors.New(c.name + " close failed")
+}
+
+func TestEngineShutdown(t *testing.T) {
+	var engine = NewEngine(Isolate(), SetConfigDir(t.TempDir()))
+	var order []string
+	var hook = func(name string, err error) func(context.Context) error {
+		return func(context.Context) error {
+			order = append(order, "hook:"+name)
+			return err
+		}
+	}
+	var hookErr = errors.New("hook failed")
+	engine.OnStop(hook("first", nil), hook("second", hookErr))
+	engine.OnStop(hook("third", nil))
+	engine.addCloser(&closerConfigurator{name: "database", closed: &order})
+	engine.addCloser(&closerConfigurator{name: "cache", closed: &order})
+
+	var err = engine.Shutdown(context.Background())
+	var expected = []string{"hook:third", "hook:second", "hook:first", "close:cache", "close:database"}
+	if !reflect.DeepEqual(order, expected) {
+		t.Fatalf("unexpected shutdown order: %v", order)
+	}
+	// 全部错误均需返回
+	if !errors.Is(err, hookErr) || !strings.Contains(err.Error(), "cache close failed") || !strings.Contains(err.Error(), "database close failed") {
+		t.Fatalf("all shutdown errors should be returned, got: %v", err)
+	}
+	select {
+	case <-engine.Done():
+	default:
+		t.Fatal("done channel should be closed after shutdown")
+	}
+	// 重复调用不再执行
+	if err = engine.Shutdown(context.Background()); err != nil || len(order) != len(expected) {
+		t.Fatalf("shutdown should only run once, got %v %v", err, order)
+	}
+}
+
+// 记录运行次数的配置器
+type countConfigurator struct {
+	Name  string `yaml:"name"`
+	count *int32
+}
+
+func (c *countConfigurator) Format() string { return c.Name }
+func (c *countConfigurator) Reader() *configx.Reader {
+	return &configx.Reader{FilePath: "count.yaml"}
+}
+func (c *countConfigurator) Execute() error {
+	atomic.AddInt32(c.count, 1)
+	return nil
+}
+
+func TestDuplicateComponents(t *testing.T) {
+	var dir = t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "count.yaml"), []byte("name: count\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var engine = NewEngine(Isolate(), SetConfigDir(dir))
+	var count int32
+	// 相同配置文件的配置器均需运行
+	engine.AddConfigurator(&countConfigurator{count: &count}, &countConfigurator{count: &count})
+	engine.initAppConfig()
+	engine.initComponents()
+	if count != 2 {
+		t.Fatalf("expected 2 executions, got %d", count)
+	}
+}
+
+func TestIsolatedHealth(t *testing.T) {
+	var first = NewEngine(Isolate(), SetConfigDir(t.TempDir()))
+	var second = NewEngine(Isolate(), SetConfigDir(t.TempDir()))
+	// 同名检查项注册在各自的注册表中，互不覆盖
+	var name = healthx.Name("database", constx.DefaultSource)
+	first.Health().Register(name, func(context.Context) error { return nil })
+	second.Health().Register(name, func(context.Context) error { return errors.New("connection refused") })
+	first.Health().SetReady(true)
+	second.Health().SetReady(true)
+	// 停止其中一个Engine不影响另一个Engine的就绪状态
+	if err := second.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var readyz = func(e *Engine) int {
+		admin, err := newAdminServer(&Admin{Enable: true}, e.Health())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var recorder = httptest.NewRecorder()
+		admin.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		return recorder.Code
+	}
+	if code := readyz(first); code != http.StatusOK {
+		t.Errorf("first engine: unexpected status %d", code)
+	}
+	if code := readyz(second); code != http.StatusServiceUnavailable {
+		t.Errorf("second engine: unexpected status %d", code)
+	}
+	if healthx.Default() == first.Health() || first.Health() == second.Health() {
+		t.Error("isolated engines should use their own health registry")
+	}
+}
@@ -125,6 +125,9 @@ func newTransport(crt, proxy string) *http.Transport {
 			panic(err)
 		}
 	}
+	if proxy == "" {
+		return transport
+	}
 	if u, err := url.Parse(proxy); err == nil {
 		transport.Proxy = http.ProxyURL(u)
 	}
@@ -2,6 +2,7 @@ package httpx
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"io"
 	"net/http"
@@ -10,6 +11,7 @@ import (
 
 	log "github.com/sirupsen/logrus"
 
+	"github.com/go-xuan/quanx/core/tracex"
 	"github.com/go-xuan/quanx/os/errorx"
 )
 
@@ -35,6 +37,7 @@ func Post(url string) *Request {
 }
 
 type Request struct {
+	ctx     context.Context
 	method  string
 	url     string
 	headers map[string]string
@@ -54,6 +57,12 @@ func (r *Request) Params(params map[string]string) *Request {
 	return r
 }
 
+// Context 设置请求上下文，上下文中的链路信息将通过traceparent请求头透传
+func (r *Request) Context(ctx context.Context) *Request {
+	r.ctx = ctx
+	return r
+}
+
 func (r *Request) Debug() *Request {
 	r.debug = true
 	return r
@@ -102,7 +111,18 @@ func (r *Request) Do(category ...ClientCategory) (*Response, error) {
 	if r.url == "" {
 		return nil, errorx.New("url is empty")
 	}
-	httpRequest, err := http.NewRequest(r.method, r.url, r.body)
+	var ctx = r.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	// 上下文中存在链路信息时开启客户端span
+	var span *tracex.Span
+	if tracex.SpanContextFromContext(ctx).IsValid() {
+		ctx, span = tracex.StartSpan(ctx, "HTTP "+r.method, tracex.KindClient)
+		span.SetAttribute("http.method", r.method).SetAttribute("http.url", r.url)
+		defer span.End()
+	}
+	httpRequest, err := http.NewRequestWithContext(ctx, r.method, r.url, r.body)
 	if err != nil {
 		return nil, errorx.Wrap(err, "new http request error")
 	}
@@ -114,8 +134,17 @@ func (r *Request) Do(category ...ClientCategory) (*Response, error) {
 			httpRequest.Header.Set(key, val)
 		}
 	}
+	tracex.Inject(ctx, httpRequest.Header)
 	var httpResponse *http.Response
-	if httpResponse, err = GetClient(category...).HttpClient().Do(httpRequest); err != nil {
+	httpResponse, err = GetClient(category...).HttpClient().Do(httpRequest)
+	if span != nil {
+		if err != nil {
+			span.SetError(err)
+		} else {
+			span.SetAttribute("http.status_code", httpResponse.StatusCode)
+		}
+	}
+	if err != nil {
 		return nil, errorx.Wrap(err, "do http request error")
 	}
 	resp := &Response{
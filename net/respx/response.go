@@ -14,6 +14,7 @@ const (
 	SuccessCode      = 10000
 	FailedCode       = 10001
 	AuthFailedCode   = 10401
+	TooManyCode      = 10429
 	ParamErrorCode   = 10501
 	RequiredCode     = 10502
 	UploadFailedCode = 10601
@@ -27,6 +28,7 @@ func init() {
 	CodeMsgEnum.Add(SuccessCode, "success").
 		Add(FailedCode, "failed").
 		Add(AuthFailedCode, "auth failed").
+		Add(TooManyCode, "too many requests").
 		Add(ParamErrorCode, "request parameter error").
 		Add(RequiredCode, "request parameter required").
 		Add(UploadFailedCode, "upload failed").
@@ -74,6 +76,10 @@ func Forbidden(ctx *gin.Context, err error) {
 	ctx.JSON(http.StatusForbidden, NewResponseData(AuthFailedCode, err.Error()))
 }
 
+func TooManyRequests(ctx *gin.Context, err error) {
+	ctx.JSON(http.StatusTooManyRequests, NewResponseData(TooManyCode, err.Error()))
+}
+
 func Custom(ctx *gin.Context, httpCode int, data *ResponseData) {
 	ctx.JSON(httpCode, data)
 }
@@ -15,7 +15,10 @@ const (
 	multiRedis                  // 开启多redis源
 	multiCache                  // 开启多缓存源
 	enableQueue                 // 使用队列任务启动
+	enableWatch                 // 监听本地配置文件变更
 	customPort                  // 自定义端口
+	isolated                    // 使用独立的组件句柄
+	workerMode                  // 后台任务模式，不启动web服务
 	running                     // 正在运行中
 )
 
@@ -46,6 +49,13 @@ func SetConfig(cof *Config) EngineOptionFunc {
 	}
 }
 
+// SetConfigChain 自定义配置源优先级链，sources按优先级从高到低排列
+func SetConfigChain(sources ...configx.Source) EngineOptionFunc {
+	return func(e *Engine) {
+		e.SetConfigChain(sources...)
+	}
+}
+
 // AddConfigurator 自定义配置器
 func AddConfigurator(configurators ...configx.Configurator) EngineOptionFunc {
 	return func(e *Engine) {
@@ -107,6 +117,34 @@ func EnableNacos() EngineOptionFunc {
 	}
 }
 
+// EnableWatch 监听本地配置文件，文件变更时热加载对应配置器
+func EnableWatch() EngineOptionFunc {
+	return func(e *Engine) {
+		e.switches[enableWatch] = true
+	}
+}
+
+// Isolate 使用独立的组件句柄，不影响各组件包级别的默认句柄，适用于同一进程中运行多个Engine以及测试隔离
+func Isolate() EngineOptionFunc {
+	return func(e *Engine) {
+		e.switches[isolated] = true
+	}
+}
+
+// WorkerMode 后台任务模式，初始化配置和组件后仅启动后台任务以及定时任务，不启动web服务，阻塞直至收到停止信号
+func WorkerMode() EngineOptionFunc {
+	return func(e *Engine) {
+		e.switches[workerMode] = true
+	}
+}
+
+// AddWorker 添加后台任务
+func AddWorker(name string, run Worker) EngineOptionFunc {
+	return func(e *Engine) {
+		e.AddWorker(name, run)
+	}
+}
+
 func EnableQueue() EngineOptionFunc {
 	return func(e *Engine) {
 		e.switches[enableQueue] = true
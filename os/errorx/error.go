@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"runtime"
+	"strings"
 )
 
 // Error 通用error
@@ -104,3 +105,38 @@ func getStack() []uintptr {
 	n := runtime.Callers(3, pcs[:])
 	return pcs[:n-1]
 }
+
+// Errors 多个error的聚合
+type Errors []error
+
+// 报错信息（用以实现error接口）
+func (errs Errors) Error() string {
+	var msgs = make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Unwrap 解包装，errors.Is()/errors.As() 会依次匹配每个error
+func (errs Errors) Unwrap() []error {
+	return errs
+}
+
+// Join 聚合多个error，忽略nil，全部为nil时返回nil，仅有一个时直接返回
+func Join(errs ...error) error {
+	var joined Errors
+	for _, err := range errs {
+		if err != nil {
+			joined = append(joined, err)
+		}
+	}
+	switch len(joined) {
+	case 0:
+		return nil
+	case 1:
+		return joined[0]
+	default:
+		return joined
+	}
+}
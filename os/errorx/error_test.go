@@ -28,3 +28,20 @@ func TestError(t *testing.T) {
 	err = Unwrap(err)
 	fmt.Println(err)
 }
+
+func TestJoin(t *testing.T) {
+	if Join(nil, nil) != nil {
+		t.Fatal("join of nil errors should be nil")
+	}
+	var first, second = errors.New("first"), errors.New("second")
+	if err := Join(nil, first); err != first {
+		t.Fatalf("join of single error should return it, got %v", err)
+	}
+	var err = Join(first, nil, Wrap(second, "wrapped"))
+	if !errors.Is(err, first) || !errors.Is(err, second) {
+		t.Fatalf("joined error should match every error: %v", err)
+	}
+	if err.Error() != "first; wrapped ==> second" {
+		t.Fatalf("unexpected message: %s", err.Error())
+	}
+}
@@ -155,6 +155,24 @@ func (s *CronScheduler) Stop() error {
 	}
 }
 
+// Shutdown 停止调度器并等待正在执行的定时任务结束，未运行时直接返回
+func (s *CronScheduler) Shutdown(ctx context.Context) error {
+	s.mutex.Lock()
+	if s.status != runningStatus {
+		s.mutex.Unlock()
+		return nil
+	}
+	var done = s.cron.Stop().Done()
+	s.status = stopStatus
+	s.mutex.Unlock()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return errorx.Wrap(ctx.Err(), "wait for running cron jobs timeout")
+	}
+}
+
 func (s *CronScheduler) Status() string {
 	switch s.status {
 	case initializationStatus:
@@ -6,10 +6,20 @@ import (
 
 	log "github.com/sirupsen/logrus"
 
+	"github.com/go-xuan/quanx/core/metricx"
 	"github.com/go-xuan/quanx/core/redisx"
 	"github.com/go-xuan/quanx/net/ipx"
 )
 
+var (
+	cronJobRunsTotal = metricx.NewCounter("cron_job_runs_total",
+		"Total number of cron job runs", "job", "status")
+	cronJobDuration = metricx.NewHistogram("cron_job_duration_seconds",
+		"Cron job execution duration in seconds", nil, "job")
+	cronJobLastRun = metricx.NewGauge("cron_job_last_run_timestamp_seconds",
+		"Unix timestamp of the last cron job run", "job")
+)
+
 // CronJobWrapper 定时任务包装器
 type CronJobWrapper func(name, spec string, job func(context.Context)) func(context.Context)
 
@@ -40,3 +50,20 @@ func DurationWarp(name, spec string, job func(context.Context)) func(context.Con
 		logger.WithField("duration", time.Since(start).String()).Info("cron job execute finish")
 	}
 }
+
+// MetricsWarp 指标采集装饰器，记录定时任务执行次数、耗时以及最近执行时间，任务panic时记为失败并继续抛出
+func MetricsWarp(name, spec string, job func(context.Context)) func(context.Context) {
+	return func(ctx context.Context) {
+		var start = time.Now()
+		cronJobLastRun.With(name).Set(float64(start.Unix()))
+		defer func() {
+			cronJobDuration.With(name).Observe(time.Since(start).Seconds())
+			if err := recover(); err != nil {
+				cronJobRunsTotal.With(name, "panic").Inc()
+				panic(err)
+			}
+			cronJobRunsTotal.With(name, "success").Inc()
+		}()
+		job(ctx) // 执行
+	}
+}
@@ -0,0 +1,160 @@
+package quanx
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+
+	"github.com/go-xuan/quanx/core/healthx"
+	"github.com/go-xuan/quanx/core/metricx"
+	"github.com/go-xuan/quanx/os/errorx"
+	"github.com/go-xuan/quanx/types/anyx"
+)
+
+// 创建服务端口的http服务，启用https时加载证书并按需启用mTLS，未启用https时按需支持h2c
+func newWebServer(server *Server, handler http.Handler) (*http.Server, error) {
+	var srv = &http.Server{
+		Addr:    ":" + strconv.Itoa(server.Port),
+		Handler: handler,
+	}
+	if server.EnableTLS() {
+		tlsConfig, err := server.TLS.Config(server.Host)
+		if err != nil {
+			return nil, errorx.Wrap(err, "init tls config failed")
+		}
+		srv.TLSConfig = tlsConfig
+		if server.TLS.DisableHTTP2 {
+			// TLSNextProto非nil时不再自动启用HTTP/2
+			srv.TLSNextProto = make(map[string]func(*http.Server, *tls.Conn, http.Handler))
+		}
+	} else if server.H2C {
+		srv.Handler = h2c.NewHandler(handler, &http2.Server{})
+	}
+	return srv, nil
+}
+
+// 启动http服务，证书已加载到TLSConfig中
+func serve(srv *http.Server) error {
+	if srv.TLSConfig != nil {
+		return srv.ListenAndServeTLS("", "")
+	}
+	return srv.ListenAndServe()
+}
+
+// Config 创建https配置，未设置证书且启用自签名时生成自签名证书
+func (t *TLS) Config(host string) (*tls.Config, error) {
+	var config = &tls.Config{MinVersion: tls.VersionTLS12}
+	if t.CertFile != "" && t.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, errorx.Wrap(err, "load tls certificate failed")
+		}
+		config.Certificates = []tls.Certificate{cert}
+	} else if t.SelfSigned {
+		cert, err := selfSignedCertificate(host)
+		if err != nil {
+			return nil, errorx.Wrap(err, "generate self-signed certificate failed")
+		}
+		log.Warn("using self-signed certificate, do not use it in production")
+		config.Certificates = []tls.Certificate{cert}
+	} else {
+		return nil, errorx.New("tls certFile and keyFile are required when selfSigned is disabled")
+	}
+	if t.ClientCAFile != "" {
+		content, err := os.ReadFile(t.ClientCAFile)
+		if err != nil {
+			return nil, errorx.Wrap(err, "read client ca file failed")
+		}
+		var pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(content) {
+			return nil, errorx.New("no valid certificate found in client ca file: " + t.ClientCAFile)
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return config, nil
+}
+
+// 生成自签名证书，证书包含localhost、127.0.0.1以及服务host，有效期一年
+func selfSignedCertificate(host string) (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, errorx.Wrap(err, "generate private key failed")
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return tls.Certificate{}, errorx.Wrap(err, "generate serial number failed")
+	}
+	var now = time.Now()
+	var template = &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"quanx self-signed"}},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.AddDate(1, 0, 0),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+	}
+	if host != "" {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, errorx.Wrap(err, "create certificate failed")
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
+
+// 创建管理端口的http服务，暴露健康检查、指标采集以及pprof
+func newAdminServer(admin *Admin, health *healthx.Registry) (*http.Server, error) {
+	if err := anyx.SetDefaultValue(admin); err != nil {
+		return nil, errorx.Wrap(err, "set default value error")
+	}
+	var router = gin.New()
+	router.Use(gin.Recovery())
+	initHealthRouter(&router.RouterGroup, health)
+	if admin.Pprof {
+		var group = router.Group("/debug/pprof")
+		group.GET("/", gin.WrapF(pprof.Index))
+		group.GET("/cmdline", gin.WrapF(pprof.Cmdline))
+		group.GET("/profile", gin.WrapF(pprof.Profile))
+		group.GET("/symbol", gin.WrapF(pprof.Symbol))
+		group.POST("/symbol", gin.WrapF(pprof.Symbol))
+		group.GET("/trace", gin.WrapF(pprof.Trace))
+		group.GET("/:name", func(ctx *gin.Context) {
+			pprof.Handler(ctx.Param("name")).ServeHTTP(ctx.Writer, ctx.Request)
+		})
+	}
+	return &http.Server{
+		Addr:    net.JoinHostPort(admin.Host, strconv.Itoa(admin.Port)),
+		Handler: router,
+	}, nil
+}
+
+// 注册健康检查以及指标采集路由
+func initHealthRouter(group *gin.RouterGroup, health *healthx.Registry) {
+	group.GET("/healthz", health.Liveness)
+	group.GET("/readyz", health.Readiness)
+	group.GET("/metrics", metricx.Handler)
+}
@@ -29,7 +29,7 @@ func (impl *ClickCaptcha) New(ctx context.Context) (string, string, string, erro
 		return "", "", "", errorx.Wrap(err, "generate captcha failed")
 	} else {
 		expiration := time.Duration(impl.store.expired) * time.Second
-		if err = impl.store.client.Set(ctx, key, dots, expiration); err != nil {
+		if err = impl.store.client().Set(ctx, key, dots, expiration); err != nil {
 			return "", "", "", errorx.Wrap(err, "store captcha failed")
 		}
 		return key, image, thumb, nil
@@ -38,7 +38,7 @@ func (impl *ClickCaptcha) New(ctx context.Context) (string, string, string, erro
 
 func (impl *ClickCaptcha) Verify(ctx context.Context, key, answer string) bool {
 	var dots = make(map[int]captcha.CharDot)
-	dotsCache := impl.store.client.GetString(ctx, key)
+	dotsCache := impl.store.client().GetString(ctx, key)
 	if err := json.Unmarshal([]byte(dotsCache), &dots); err != nil {
 		return false
 	}
@@ -55,7 +55,7 @@ func (impl *ClickCaptcha) Verify(ctx context.Context, key, answer string) bool {
 		}
 	}
 	if ok {
-		impl.store.client.Delete(ctx, key)
+		impl.store.client().Delete(ctx, key)
 	}
 	return ok
 }
@@ -7,31 +7,41 @@ import (
 	"github.com/go-xuan/quanx/core/cachex"
 )
 
+// DefaultStore 默认验证码存储，使用 Config 设置的缓存源以及有效期
 func DefaultStore() *CaptchaStore {
+	var config = getConfig()
 	return &CaptchaStore{
-		client:  cachex.GetClient(),
-		expired: 120,
+		source:  config.Source,
+		expired: config.Expired,
 		clear:   false,
 	}
 }
 
 // CaptchaStore 验证码存储
 type CaptchaStore struct {
-	client  cachex.Client
+	source  string
 	expired int
 	clear   bool
 }
 
+// 缓存客户端，使用时获取，避免在缓存组件初始化前创建验证码时客户端为空
+func (s *CaptchaStore) client() cachex.Client {
+	if s.source == "" {
+		return cachex.GetClient()
+	}
+	return cachex.GetClient(s.source)
+}
+
 // 存储
 func (s *CaptchaStore) set(ctx context.Context, key, value string) error {
-	return s.client.Set(ctx, key, value, time.Duration(s.expired)*time.Second)
+	return s.client().Set(ctx, key, value, time.Duration(s.expired)*time.Second)
 }
 
 // 获取
 func (s *CaptchaStore) get(ctx context.Context, key string) string {
 	var value string
-	if ok := s.client.Get(ctx, key, &value); ok && s.clear {
-		s.client.Delete(ctx, key)
+	if ok := s.client().Get(ctx, key, &value); ok && s.clear {
+		s.client().Delete(ctx, key)
 	}
 	return value
 }
@@ -39,7 +49,7 @@ func (s *CaptchaStore) get(ctx context.Context, key string) string {
 // 验证
 func (s *CaptchaStore) verify(ctx context.Context, key, value string) bool {
 	if s.get(ctx, key) == value {
-		s.client.Delete(ctx, key)
+		s.client().Delete(ctx, key)
 		return true
 	}
 	return false
@@ -0,0 +1,53 @@
+package captchax
+
+import (
+	"fmt"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/go-xuan/quanx/core/configx"
+)
+
+var (
+	_config      = &Config{Expired: 120}
+	_configMutex sync.RWMutex
+)
+
+// Config 验证码配置，验证码存储于cachex缓存，依赖缓存组件先完成初始化
+type Config struct {
+	Source  string `json:"source" yaml:"source" default:"default"` // 存储验证码的缓存源
+	Expired int    `json:"expired" yaml:"expired" default:"120"`   // 验证码有效期(秒)
+}
+
+func (c *Config) Format() string {
+	return fmt.Sprintf("source=%s expired=%d", c.Source, c.Expired)
+}
+
+func (c *Config) Reader() *configx.Reader {
+	return &configx.Reader{
+		FilePath:    "captcha.yaml",
+		NacosDataId: "captcha.yaml",
+		Listen:      false,
+	}
+}
+
+// DependsOn 验证码存储于缓存，依赖缓存组件先完成初始化
+func (c *Config) DependsOn() []string {
+	return []string{"cache"}
+}
+
+func (c *Config) Execute() error {
+	_configMutex.Lock()
+	defer _configMutex.Unlock()
+	_config = c
+	log.Info("captcha init success: ", c.Format())
+	return nil
+}
+
+// 当前验证码配置
+func getConfig() *Config {
+	_configMutex.RLock()
+	defer _configMutex.RUnlock()
+	return _config
+}
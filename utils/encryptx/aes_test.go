@@ -30,3 +30,23 @@ func TestAES(t *testing.T) {
 	plaintext := aes.Decrypt(ciphertext)
 	fmt.Println(string(plaintext))
 }
+
+func TestAesGcm(t *testing.T) {
+	var key = []byte("0123456789abcdef0123456789abcdef")
+	if _, err := AesGcmEncrypt([]byte("123456"), []byte("secret")); err == nil {
+		t.Fatal("key of invalid length should be rejected")
+	}
+	ciphertext, err := AesGcmEncrypt([]byte("123456"), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext, err := AesGcmDecrypt(ciphertext, key)
+	if err != nil {
+		t.Fatal(err)
+	} else if string(plaintext) != "123456" {
+		t.Fatalf("unexpected plaintext: %s", plaintext)
+	}
+	if _, err = AesGcmDecrypt(ciphertext, []byte("fedcba9876543210fedcba9876543210")); err == nil {
+		t.Fatal("decrypt with wrong key should fail")
+	}
+}
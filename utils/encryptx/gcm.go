@@ -0,0 +1,58 @@
+package encryptx
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"io"
+
+	"github.com/go-xuan/quanx/os/errorx"
+)
+
+// AesGcmEncrypt AES-GCM加密，密文格式为 nonce + ciphertext，
+// key长度必须为16/24/32字节，分别对应AES-128/AES-192/AES-256
+func AesGcmEncrypt(plaintext, key []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	var nonce = make([]byte, gcm.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, errorx.Wrap(err, "generate nonce error")
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// AesGcmDecrypt AES-GCM解密，秘钥错误或密文被篡改时返回错误
+func AesGcmDecrypt(ciphertext, key []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	var size = gcm.NonceSize()
+	if len(ciphertext) < size+gcm.Overhead() {
+		return nil, errorx.New("ciphertext is too short")
+	}
+	plaintext, err := gcm.Open(nil, ciphertext[:size], ciphertext[size:], nil)
+	if err != nil {
+		return nil, errorx.Wrap(err, "gcm decrypt error")
+	}
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	switch len(key) {
+	case 16, 24, 32:
+	default:
+		return nil, errorx.Errorf("invalid aes key length: %d, must be 16, 24 or 32 bytes", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errorx.Wrap(err, "new cipher error")
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errorx.Wrap(err, "new gcm error")
+	}
+	return gcm, nil
+}
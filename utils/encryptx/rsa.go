@@ -188,3 +188,8 @@ func RsaDecryptPKIX(ciphertext []byte, priPem string) ([]byte, error) {
 		return rsa.DecryptPKCS1v15(rand.Reader, privateKey.(*rsa.PrivateKey), ciphertext)
 	}
 }
+
+// PrivateKey rsa私钥
+func (m *Rsa) PrivateKey() *rsa.PrivateKey {
+	return m.privateKey
+}
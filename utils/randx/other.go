@@ -26,7 +26,8 @@ func Enum[T string | int | int64 | float64](enums []T) T {
 		i := IntRange(0, l-1)
 		return enums[i]
 	}
-	return T(0)
+	var zero T
+	return zero
 }
 
 // Time 一天内随机时间
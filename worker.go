@@ -0,0 +1,91 @@
+package quanx
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/go-xuan/quanx/os/errorx"
+)
+
+// Worker 后台任务，ctx在服务停止时取消，任务应在ctx取消后尽快返回，
+// 返回非nil错误（context.Canceled除外）时视为异常退出，服务随之停止
+type Worker func(ctx context.Context) error
+
+// 已注册的后台任务
+type worker struct {
+	name string
+	run  Worker
+}
+
+// 后台任务运行状态
+type workerGroup struct {
+	workers []*worker          // 后台任务，使用 AddWorker()添加
+	cancel  context.CancelFunc // 取消全部后台任务
+	wg      sync.WaitGroup     // 等待全部后台任务结束
+}
+
+// AddWorker 添加后台任务，服务启动时与定时任务一起启动，服务停止时取消
+func (e *Engine) AddWorker(name string, run Worker) {
+	e.checkRunning()
+	if name == "" || run == nil {
+		log.Error(`add worker failed, cause: the worker name and function are required`)
+		return
+	}
+	e.workers.workers = append(e.workers.workers, &worker{name: name, run: run})
+}
+
+// 启动全部后台任务
+func (e *Engine) startWorkers() {
+	var ctx context.Context
+	ctx, e.workers.cancel = context.WithCancel(context.Background())
+	for _, w := range e.workers.workers {
+		var w = w
+		e.workers.wg.Add(1)
+		go func() {
+			defer e.workers.wg.Done()
+			var logger = log.WithField("worker", w.name)
+			logger.Info("worker started")
+			if err := runWorker(ctx, w.run); err != nil && !errors.Is(err, context.Canceled) {
+				logger.Error("worker exited abnormally: ", err)
+				select {
+				case e.serverErr <- errorx.Wrap(err, "worker run failed: "+w.name):
+				default:
+				}
+				return
+			}
+			logger.Info("worker stopped")
+		}()
+	}
+}
+
+// 运行后台任务，panic时转换为错误
+func runWorker(ctx context.Context, run Worker) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errorx.Errorf("panic: %v", r)
+		}
+	}()
+	return run(ctx)
+}
+
+// 取消全部后台任务并等待结束
+func (e *Engine) stopWorkers(ctx context.Context) error {
+	if e.workers.cancel == nil {
+		return nil
+	}
+	e.workers.cancel()
+	var done = make(chan struct{})
+	go func() {
+		e.workers.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return errorx.Wrap(ctx.Err(), "wait for workers timeout")
+	}
+}